@@ -27,6 +27,10 @@ const (
 	EventService_ListEventsForDate_FullMethodName  = "/api.EventService/ListEventsForDate"
 	EventService_ListEventsForWeek_FullMethodName  = "/api.EventService/ListEventsForWeek"
 	EventService_ListEventsForMonth_FullMethodName = "/api.EventService/ListEventsForMonth"
+	EventService_WatchEvents_FullMethodName        = "/api.EventService/WatchEvents"
+	EventService_SyncEvents_FullMethodName         = "/api.EventService/SyncEvents"
+	EventService_SearchEvents_FullMethodName       = "/api.EventService/SearchEvents"
+	EventService_BatchEvents_FullMethodName        = "/api.EventService/BatchEvents"
 )
 
 // EventServiceClient is the client API for EventService service.
@@ -41,6 +45,10 @@ type EventServiceClient interface {
 	ListEventsForDate(ctx context.Context, in *ListEventsForDateRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
 	ListEventsForWeek(ctx context.Context, in *ListEventsForWeekRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
 	ListEventsForMonth(ctx context.Context, in *ListEventsForMonthRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (EventService_WatchEventsClient, error)
+	SyncEvents(ctx context.Context, in *SyncEventsRequest, opts ...grpc.CallOption) (*SyncEventsResponse, error)
+	SearchEvents(ctx context.Context, in *SearchEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
+	BatchEvents(ctx context.Context, in *BatchEventsRequest, opts ...grpc.CallOption) (*BatchEventsResponse, error)
 }
 
 type eventServiceClient struct {
@@ -131,6 +139,69 @@ func (c *eventServiceClient) ListEventsForMonth(ctx context.Context, in *ListEve
 	return out, nil
 }
 
+func (c *eventServiceClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (EventService_WatchEventsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EventService_ServiceDesc.Streams[0], EventService_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventServiceWatchEventsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EventService_WatchEventsClient interface {
+	Recv() (*EventChange, error)
+	grpc.ClientStream
+}
+
+type eventServiceWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventServiceWatchEventsClient) Recv() (*EventChange, error) {
+	m := new(EventChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *eventServiceClient) SyncEvents(ctx context.Context, in *SyncEventsRequest, opts ...grpc.CallOption) (*SyncEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SyncEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_SyncEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) SearchEvents(ctx context.Context, in *SearchEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_SearchEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) BatchEvents(ctx context.Context, in *BatchEventsRequest, opts ...grpc.CallOption) (*BatchEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_BatchEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EventServiceServer is the server API for EventService service.
 // All implementations must embed UnimplementedEventServiceServer
 // for forward compatibility
@@ -143,6 +214,10 @@ type EventServiceServer interface {
 	ListEventsForDate(context.Context, *ListEventsForDateRequest) (*ListEventsResponse, error)
 	ListEventsForWeek(context.Context, *ListEventsForWeekRequest) (*ListEventsResponse, error)
 	ListEventsForMonth(context.Context, *ListEventsForMonthRequest) (*ListEventsResponse, error)
+	WatchEvents(*WatchEventsRequest, EventService_WatchEventsServer) error
+	SyncEvents(context.Context, *SyncEventsRequest) (*SyncEventsResponse, error)
+	SearchEvents(context.Context, *SearchEventsRequest) (*ListEventsResponse, error)
+	BatchEvents(context.Context, *BatchEventsRequest) (*BatchEventsResponse, error)
 	mustEmbedUnimplementedEventServiceServer()
 }
 
@@ -174,6 +249,18 @@ func (UnimplementedEventServiceServer) ListEventsForWeek(context.Context, *ListE
 func (UnimplementedEventServiceServer) ListEventsForMonth(context.Context, *ListEventsForMonthRequest) (*ListEventsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListEventsForMonth not implemented")
 }
+func (UnimplementedEventServiceServer) WatchEvents(*WatchEventsRequest, EventService_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedEventServiceServer) SyncEvents(context.Context, *SyncEventsRequest) (*SyncEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncEvents not implemented")
+}
+func (UnimplementedEventServiceServer) SearchEvents(context.Context, *SearchEventsRequest) (*ListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchEvents not implemented")
+}
+func (UnimplementedEventServiceServer) BatchEvents(context.Context, *BatchEventsRequest) (*BatchEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchEvents not implemented")
+}
 func (UnimplementedEventServiceServer) mustEmbedUnimplementedEventServiceServer() {}
 
 // UnsafeEventServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -331,6 +418,81 @@ func _EventService_ListEventsForMonth_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventServiceServer).WatchEvents(m, &eventServiceWatchEventsServer{ServerStream: stream})
+}
+
+type EventService_WatchEventsServer interface {
+	Send(*EventChange) error
+	grpc.ServerStream
+}
+
+type eventServiceWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventServiceWatchEventsServer) Send(m *EventChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _EventService_SyncEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).SyncEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_SyncEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).SyncEvents(ctx, req.(*SyncEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_SearchEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).SearchEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_SearchEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).SearchEvents(ctx, req.(*SearchEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_BatchEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).BatchEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_BatchEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).BatchEvents(ctx, req.(*BatchEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EventService_ServiceDesc is the grpc.ServiceDesc for EventService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -370,7 +532,25 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListEventsForMonth",
 			Handler:    _EventService_ListEventsForMonth_Handler,
 		},
+		{
+			MethodName: "SyncEvents",
+			Handler:    _EventService_SyncEvents_Handler,
+		},
+		{
+			MethodName: "SearchEvents",
+			Handler:    _EventService_SearchEvents_Handler,
+		},
+		{
+			MethodName: "BatchEvents",
+			Handler:    _EventService_BatchEvents_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _EventService_WatchEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "event_service.proto",
 }
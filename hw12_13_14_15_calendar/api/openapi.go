@@ -0,0 +1,8 @@
+package api
+
+import _ "embed"
+
+// OpenAPISpec контракт HTTP API версии v1. Сервер проверяет по нему запросы и отдает его клиентам.
+//
+//go:embed openapi.yaml
+var OpenAPISpec []byte
@@ -8,26 +8,50 @@ import (
 	"log"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"strconv"
 	"syscall"
-	"time"
+	// "time/tzdata" встраивает базу часовых поясов, которой может не быть в образе, для проверки timezone календарей.
+	_ "time/tzdata"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/app"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	sqlstorage "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/sql"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/version"
 )
 
 func main() {
 	// Парсинг флагов командной строки
-	fmt.Println("app started")
-
 	configPath := flag.String("config", "configs/config.yaml", "path to the config file")
-	migrationsPath := flag.String("migrations", "migrations", "path to the migrations directory")
-	command := flag.String("command", "run", "command to execute: run, migrate_up, migrate_down")
+	command := flag.String(
+		"command",
+		"run",
+		"command to execute: run, version, config_check, migrate_up, migrate_down, migrate_status, "+
+			"migrate_to <version>, migrate_force <version>, secrets_keygen, secrets_encrypt [file], secrets_decrypt [file]",
+	)
 	flag.Parse()
 
+	// Версия печатается без конфигурации, чтобы ее можно было узнать у любого бинарника
+	if *command == "version" {
+		if err := version.Print(os.Stdout); err != nil {
+			log.Fatalf("Error printing version, %s", err)
+		}
+		return
+	}
+	if *command == "config_check" {
+		if err := config.Check(os.Stdout, *configPath, config.AppCalendar); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+	switch *command {
+	case "secrets_keygen", "secrets_encrypt", "secrets_decrypt":
+		if err := runSecretsCommand(*command, flag.Arg(0)); err != nil {
+			log.Fatalf("Error running %s, %s", *command, err)
+		}
+		return
+	}
+	fmt.Println("app started")
+
 	// Загрузка конфигурации
 	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
@@ -39,76 +63,83 @@ func main() {
 	case "run":
 		fmt.Println("command is run")
 
-		runApplication(cfg)
-	case "migrate_up":
-		fmt.Println("command is migrate_up")
-
-		runMigrations(cfg, *migrationsPath, "up")
-	case "migrate_down":
-		fmt.Println("command is migrate_down")
+		if err := cfg.Validate(config.AppCalendar); err != nil {
+			log.Fatalf("Invalid config:\n%s", err)
+		}
+		runApplication(cfg, *configPath)
+	case "migrate_up", "migrate_down", "migrate_status", "migrate_to", "migrate_force":
+		fmt.Printf("command is %s\n", *command)
 
-		runMigrations(cfg, *migrationsPath, "down")
+		if err := runMigrations(cfg, *command, flag.Arg(0)); err != nil {
+			log.Fatalf("Error running migrations, %s", err)
+		}
 	default:
-		log.Fatalf("Unknown command: %s. Use 'run', 'migrate_up' or 'migrate_down'", *command)
+		log.Fatalf(
+			"Unknown command: %s. Use 'run', 'version', 'config_check', 'migrate_up', 'migrate_down', "+
+				"'migrate_status', 'migrate_to', 'migrate_force', 'secrets_keygen', 'secrets_encrypt' or 'secrets_decrypt'",
+			*command,
+		)
 	}
 }
 
-func runApplication(cfg *config.Config) {
+func runApplication(cfg *config.Config, configPath string) {
 	// Инициализация приложения
 	fmt.Println("runApplication")
 
-	application, err := app.NewApp(cfg)
+	application, err := app.NewApp(cfg, configPath)
 	if err != nil {
 		log.Fatalf("Error initializing application, %s", err)
 	}
 
-	// Контекст и корректное завершение работы
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	// Контекст и корректное завершение работы: остановкой компонентов управляет само приложение.
+	// SIGHUP перезагружает конфигурацию и не останавливает приложение
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	go func() {
-		<-ctx.Done()
-
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
-
-		if err := application.Stop(ctx); err != nil {
-			log.Printf("failed to stop application: %s", err)
-		}
-	}()
-
-	if err := application.Start(ctx); err != nil {
-		log.Printf("failed to start application: %s", err)
+	if err := application.Run(ctx); err != nil {
+		log.Printf("application failed: %s", err)
 		cancel()
 		os.Exit(1) //nolint:gocritic
 	}
 }
 
-func runMigrations(cfg *config.Config, migrationsPath string, direction string) {
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
-		cfg.Database.User, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.Name)
-
-	migrationPath := fmt.Sprintf("file://%s", filepath.Clean(migrationsPath))
-
-	m, err := migrate.New(
-		migrationPath,
-		dsn)
+// runMigrations выполняет команду над встроенными миграциями и печатает версию схемы.
+// Для migrate_to и migrate_force version задает целевую версию.
+func runMigrations(cfg *config.Config, command string, version string) (err error) {
+	migrator, err := sqlstorage.NewMigrator(cfg.Database)
 	if err != nil {
-		log.Fatalf("Error initializing migration, %s", err)
+		return fmt.Errorf("on initializing migrations, %w", err)
 	}
+	defer func() {
+		err = errors.Join(err, migrator.Close())
+	}()
 
-	switch direction {
-	case "up":
-		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-			log.Fatalf("Error applying migrations, %s", err)
+	switch command {
+	case "migrate_up":
+		err = migrator.Up()
+	case "migrate_down":
+		err = migrator.Down()
+	case "migrate_to":
+		var target uint64
+		if target, err = strconv.ParseUint(version, 10, 64); err != nil {
+			return fmt.Errorf("invalid migration version %q, %w", version, err)
 		}
-	case "down":
-		if err := m.Down(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-			log.Fatalf("Error reverting migrations, %s", err)
+		err = migrator.To(uint(target))
+	case "migrate_force":
+		var target int
+		if target, err = strconv.Atoi(version); err != nil {
+			return fmt.Errorf("invalid migration version %q, %w", version, err)
 		}
-	default:
-		log.Fatalf("Unknown migration direction: %s. Use 'up' or 'down'", direction)
+		err = migrator.Force(target)
+	}
+	if err != nil {
+		return fmt.Errorf("on applying migrations, %w", err)
 	}
 
-	fmt.Println("Migrations applied successfully.")
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema version: %d, dirty: %t, latest migration: %d\n", status.Version, status.Dirty, status.Latest)
+	return nil
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/secrets"
+	"gopkg.in/yaml.v3"
+)
+
+// runSecretsCommand работает с зашифрованным файлом секретов, ключ берется из CALENDAR_SECRETS_KEY.
+// secrets_keygen печатает новый ключ, secrets_encrypt шифрует YAML из файла path,
+// secrets_decrypt печатает расшифрованный файл path. Если path не задан, читается stdin.
+func runSecretsCommand(command, path string) error {
+	if command == "secrets_keygen" {
+		key, err := secrets.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	}
+
+	key, err := config.SecretsKey()
+	if err != nil {
+		return err
+	}
+	input, err := readInput(path)
+	if err != nil {
+		return err
+	}
+
+	var output []byte
+	switch command {
+	case "secrets_encrypt":
+		// Ошибка в YAML обнаружилась бы только при запуске приложения
+		var values map[string]interface{}
+		if err := yaml.Unmarshal(input, &values); err != nil {
+			return fmt.Errorf("secrets must be a YAML document: %w", err)
+		}
+		output, err = secrets.Encrypt(input, key)
+	case "secrets_decrypt":
+		output, err = secrets.Decrypt(input, key)
+	default:
+		return errors.New("unknown secrets command " + command)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(output)
+	return err
+}
+
+func readInput(path string) ([]byte, error) {
+	if path == "" || path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
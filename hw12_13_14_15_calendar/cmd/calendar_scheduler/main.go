@@ -8,46 +8,56 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/app"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/version"
 )
 
 func main() {
-	// Инициализация приложения
-	fmt.Println("run sender application")
-
 	configPath := flag.String("config", "configs/config.yaml", "path to the config file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [version|config_check]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	if flag.Arg(0) == "version" {
+		if err := version.Print(os.Stdout); err != nil {
+			log.Fatalf("Error printing version, %s", err)
+		}
+		return
+	}
+	if flag.Arg(0) == "config_check" {
+		if err := config.Check(os.Stdout, *configPath, config.AppScheduler); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Инициализация приложения
+	fmt.Println("run scheduler application")
+
 	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Error reading config file, %s", err)
 	}
+	if err := cfg.Validate(config.AppScheduler); err != nil {
+		log.Fatalf("Invalid config:\n%s", err)
+	}
 
-	application, err := app.NewSchedulerApp(cfg)
+	application, err := app.NewSchedulerApp(cfg, *configPath)
 	if err != nil {
 		log.Fatalf("Error initializing application, %s", err)
 	}
 
-	// Контекст и корректное завершение работы
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	// Контекст и корректное завершение работы: остановкой компонентов управляет само приложение.
+	// SIGHUP перезагружает конфигурацию и не останавливает приложение
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	go func() {
-		<-ctx.Done()
-
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
-
-		if err := application.Stop(ctx); err != nil {
-			log.Printf("failed to stop application: %s", err)
-		}
-	}()
-
-	if err := application.Start(ctx); err != nil {
-		log.Printf("failed to start application: %s", err)
+	if err := application.Run(ctx); err != nil {
+		log.Printf("application failed: %s", err)
 		cancel()
 		os.Exit(1) //nolint:gocritic
 	}
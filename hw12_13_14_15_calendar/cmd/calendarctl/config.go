@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/certs"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// clientConfig - настройки подключения calendarctl. Значения читаются из файла конфигурации,
+// переменных окружения CALENDARCTL_* и флагов командной строки, флаги имеют наивысший приоритет.
+type clientConfig struct {
+	Address string // Адрес gRPC-сервера календаря
+	Token   string // Токен авторизации, передаваемый в метаданных authorization как Bearer
+	// InsecureToken разрешает передавать токен по соединению без TLS, открытым текстом
+	InsecureToken bool
+	Output        string // Формат вывода: table, json или yaml
+	Timeout       int    // Таймаут вызова в секундах
+	TLS           clientTLSConfig
+}
+
+type clientTLSConfig struct {
+	Enabled                bool
+	config.ClientTLSConfig `mapstructure:",squash"`
+}
+
+// defaultConfigPath возвращает путь к файлу конфигурации по умолчанию в каталоге пользователя.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "calendarctl", "config.yaml")
+}
+
+// loadClientConfig читает конфигурацию. Отсутствие файла по умолчанию не является ошибкой,
+// явно указанный файл обязан существовать.
+func loadClientConfig(path string, explicit bool) (*clientConfig, error) {
+	v := viper.New()
+	v.SetDefault("address", "localhost:9090")
+	v.SetDefault("output", "table")
+	v.SetDefault("timeout", 10)
+	v.SetDefault("insecureToken", false)
+	v.SetDefault("tls.enabled", false)
+	v.SetDefault("tls.insecureSkipVerify", false)
+
+	v.SetEnvPrefix("calendarctl")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	// AutomaticEnv не видит вложенные ключи без значения по умолчанию, поэтому привязываем их явно.
+	for _, key := range []string{"token", "tls.caFile", "tls.certFile", "tls.keyFile", "tls.serverName"} {
+		if err := v.BindEnv(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if path != "" {
+		v.SetConfigFile(path)
+		v.SetConfigType("yaml")
+		if err := v.ReadInConfig(); err != nil {
+			if explicit || !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("failed to read config: %w", err)
+			}
+		}
+	}
+
+	var cfg clientConfig
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// validate проверяет итоговые настройки после применения флагов.
+func (c *clientConfig) validate() error {
+	if c.Token != "" && !c.TLS.Enabled && !c.InsecureToken {
+		return errors.New("refusing to send the token without TLS: enable --tls or pass --insecure-token")
+	}
+	return nil
+}
+
+// dial открывает соединение с сервером с учетом настроек TLS и токена.
+func dial(cfg *clientConfig) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if cfg.TLS.Enabled {
+		tlsConfig, err := certs.ClientConfig(cfg.TLS.ClientTLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+	}
+	if cfg.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials{
+			token:    cfg.Token,
+			insecure: cfg.InsecureToken && !cfg.TLS.Enabled,
+		}))
+	}
+
+	conn, err := grpc.Dial(cfg.Address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("on connecting to %s, %w", cfg.Address, err)
+	}
+	return conn, nil
+}
+
+// tokenCredentials передает токен авторизации в метаданных каждого вызова. Токен требует TLS, кроме
+// явно разрешенного флагом --insecure-token случая: без TLS он уходит открытым текстом.
+type tokenCredentials struct {
+	token    string
+	insecure bool
+}
+
+func (c tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return !c.insecure
+}
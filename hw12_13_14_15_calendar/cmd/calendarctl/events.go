@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	grpcserver "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/server/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// eventFlags - флаги полей события для create и update.
+type eventFlags struct {
+	title       string
+	description string
+	start       timeFlag
+	end         timeFlag
+	userID      string
+	calendarID  string
+	attendees   stringList
+}
+
+// eventMaskPaths сопоставляет флаги путям update_mask.
+var eventMaskPaths = map[string]string{
+	"title":       "title",
+	"description": "description",
+	"start":       "start_time",
+	"end":         "end_time",
+	"user":        "user_id",
+	"calendar":    "calendar_id",
+	"attendee":    "attendees",
+}
+
+func (f *eventFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.title, "title", "", "event title")
+	fs.StringVar(&f.description, "description", "", "event description")
+	fs.Var(&f.start, "start", "start time")
+	fs.Var(&f.end, "end", "end time")
+	fs.StringVar(&f.userID, "user", "", "owner user id")
+	fs.StringVar(&f.calendarID, "calendar", "", "calendar id")
+	fs.Var(&f.attendees, "attendee", "attendee email, repeatable")
+}
+
+// defaultRange возвращает интервал по умолчанию для list: неделя от начала текущего дня.
+func defaultRange(start, end timeFlag) (time.Time, time.Time) {
+	from, to := start.Time, end.Time
+	if from.IsZero() {
+		now := time.Now()
+		from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	}
+	if to.IsZero() {
+		to = from.AddDate(0, 0, 7)
+	}
+	return from, to
+}
+
+func eventsList(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("events list", "")
+	var start, end timeFlag
+	fs.Var(&start, "start", "range start, default beginning of today")
+	fs.Var(&end, "end", "range end, default a week after start")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	from, to := defaultRange(start, end)
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	resp, err := c.events.ListEvents(ctx, &api.ListEventsRequest{
+		StartTime: timestamppb.New(from),
+		EndTime:   timestamppb.New(to),
+	})
+	if err != nil {
+		return err
+	}
+	return c.out.events(resp.GetEvents())
+}
+
+func eventsGet(ctx context.Context, c *cli, args []string) error {
+	id, err := parseWithID(newFlagSet("events get", "<id>"), args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	resp, err := c.events.GetEvent(ctx, &api.GetEventRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	return c.out.event(resp.GetEvent())
+}
+
+func eventsCreate(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("events create", "")
+	var fields eventFlags
+	fields.register(fs)
+	duration := fs.Duration("duration", time.Hour, "event duration if -end is not set")
+	idempotencyKey := fs.String("idempotency-key", "", "Idempotency-Key to make retries safe")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fields.start.IsZero() {
+		fs.Usage()
+		return errUsage
+	}
+	if fields.end.IsZero() {
+		fields.end.Time = fields.start.Add(*duration)
+	}
+
+	if *idempotencyKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, grpcserver.IdempotencyKeyMetadata, *idempotencyKey)
+	}
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	resp, err := c.events.CreateEvent(ctx, &api.CreateEventRequest{
+		Title:       fields.title,
+		Description: fields.description,
+		StartTime:   timestamppb.New(fields.start.Time),
+		EndTime:     timestamppb.New(fields.end.Time),
+		UserId:      fields.userID,
+		CalendarId:  fields.calendarID,
+		Attendees:   fields.attendees,
+	})
+	if err != nil {
+		return err
+	}
+	return c.out.id(resp.GetId())
+}
+
+// eventsUpdate обновляет только явно заданные флагами поля через update_mask.
+func eventsUpdate(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("events update", "<id>")
+	var fields eventFlags
+	fields.register(fs)
+	expectedVersion := fs.Int64("expected-version", 0, "fail unless the event has this version, 0 - no check")
+	id, err := parseWithID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	mask := &fieldmaskpb.FieldMask{}
+	fs.Visit(func(f *flag.Flag) {
+		if path, ok := eventMaskPaths[f.Name]; ok {
+			mask.Paths = append(mask.Paths, path)
+		}
+	})
+	if len(mask.GetPaths()) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	_, err = c.events.UpdateEvent(ctx, &api.UpdateEventRequest{
+		Id:              id,
+		Title:           fields.title,
+		Description:     fields.description,
+		StartTime:       optionalTimestamp(fields.start),
+		EndTime:         optionalTimestamp(fields.end),
+		UserId:          fields.userID,
+		CalendarId:      fields.calendarID,
+		Attendees:       fields.attendees,
+		ExpectedVersion: *expectedVersion,
+		UpdateMask:      mask,
+	})
+	return err
+}
+
+func eventsDelete(ctx context.Context, c *cli, args []string) error {
+	id, err := parseWithID(newFlagSet("events delete", "<id>"), args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	_, err = c.events.DeleteEvent(ctx, &api.DeleteEventRequest{Id: id})
+	return err
+}
+
+// eventsPeriod возвращает команду просмотра событий за день, неделю или месяц, начиная с даты.
+func eventsPeriod(period string) handler {
+	return func(ctx context.Context, c *cli, args []string) error {
+		fs := newFlagSet("events "+period, "")
+		var date timeFlag
+		var calendars stringList
+		fs.Var(&date, "date", "period start, default beginning of today")
+		fs.Var(&calendars, "calendar", "calendar id to filter by, repeatable")
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+
+		ctx, cancel := c.call(ctx)
+		defer cancel()
+		var (
+			resp *api.ListEventsResponse
+			err  error
+		)
+		from, _ := defaultRange(date, timeFlag{})
+		ts := timestamppb.New(from)
+		switch period {
+		case "day":
+			resp, err = c.events.ListEventsForDate(ctx, &api.ListEventsForDateRequest{Date: ts, CalendarIds: calendars})
+		case "week":
+			resp, err = c.events.ListEventsForWeek(ctx, &api.ListEventsForWeekRequest{Date: ts, CalendarIds: calendars})
+		default:
+			resp, err = c.events.ListEventsForMonth(ctx, &api.ListEventsForMonthRequest{Date: ts, CalendarIds: calendars})
+		}
+		if err != nil {
+			return err
+		}
+		return c.out.events(resp.GetEvents())
+	}
+}
+
+func optionalTimestamp(t timeFlag) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t.Time)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/ics"
+	grpcserver "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/server/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// icsExport выгружает события интервала в iCalendar.
+func icsExport(ctx context.Context, c *cli, args []string) (err error) {
+	fs := newFlagSet("ics export", "")
+	var start, end timeFlag
+	fs.Var(&start, "start", "range start, default beginning of today")
+	fs.Var(&end, "end", "range end, default a week after start")
+	file := fs.String("file", "-", "output file, - for stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	from, to := defaultRange(start, end)
+	callCtx, cancel := c.call(ctx)
+	defer cancel()
+	resp, err := c.events.ListEvents(callCtx, &api.ListEventsRequest{
+		StartTime: timestamppb.New(from),
+		EndTime:   timestamppb.New(to),
+	})
+	if err != nil {
+		return err
+	}
+	events := make([]dto.EventData, 0, len(resp.GetEvents()))
+	for _, event := range resp.GetEvents() {
+		data, err := dto.FromAPIEvent(event)
+		if err != nil {
+			return err
+		}
+		events = append(events, data)
+	}
+
+	var w io.Writer = os.Stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			err = errors.Join(err, f.Close())
+		}()
+		w = f
+	}
+	return ics.Encode(w, events)
+}
+
+// icsImport создает события из iCalendar. Событие с UUID в UID создается с ключом идемпотентности
+// на основе UID, поэтому повторный импорт того же файла не создает дубликатов.
+func icsImport(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("ics import", "")
+	file := fs.String("file", "-", "input file, - for stdin")
+	userID := fs.String("user", "", "owner user id for imported events")
+	calendarID := fs.String("calendar", "", "calendar id for imported events")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	events, err := ics.Decode(r)
+	if err != nil {
+		return fmt.Errorf("on parsing %s, %w", *file, err)
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		id, err := c.importEvent(ctx, event, *userID, *calendarID)
+		if err != nil {
+			// Уже созданные события выводим, чтобы после исправления ошибки было понятно, что импортировано.
+			_ = c.out.ids(ids)
+			return fmt.Errorf("on importing %q, %w", event.Title, err)
+		}
+		ids = append(ids, id)
+	}
+	return c.out.ids(ids)
+}
+
+func (c *cli) importEvent(ctx context.Context, event dto.EventData, userID, calendarID string) (string, error) {
+	if event.ID != uuid.Nil {
+		ctx = metadata.AppendToOutgoingContext(ctx, grpcserver.IdempotencyKeyMetadata, "ics-"+event.ID.String())
+	}
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	resp, err := c.events.CreateEvent(ctx, &api.CreateEventRequest{
+		Title:       event.Title,
+		Description: event.Description,
+		StartTime:   timestamppb.New(event.StartTime),
+		EndTime:     timestamppb.New(event.EndTime),
+		UserId:      userID,
+		CalendarId:  calendarID,
+		Attendees:   event.Attendees,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetId(), nil
+}
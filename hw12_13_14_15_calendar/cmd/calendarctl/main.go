@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"google.golang.org/grpc/status"
+)
+
+const usage = `calendarctl - клиент gRPC API календаря
+
+Usage:
+  calendarctl [global flags] <group> <command> [flags] [args]
+
+Commands:
+  events list|get|create|update|delete     управление событиями
+  events day|week|month                    события за день, неделю или месяц
+  notifications list|get|create|update|delete
+                                           управление уведомлениями
+  ics export|import                        выгрузка и загрузка событий в формате iCalendar
+  version                                  версии calendarctl и сервера
+
+Run "calendarctl <group> <command> -h" for command flags.
+
+Global flags:
+`
+
+// errUsage означает неверный вызов команды, сообщение об ошибке уже выведено flag.FlagSet.
+var errUsage = errors.New("usage error")
+
+// cli хранит клиентов API и общие настройки выполнения команд.
+type cli struct {
+	events        api.EventServiceClient
+	notifications api.NotificationServiceClient
+	version       api.VersionServiceClient
+	out           *printer
+	timeout       time.Duration
+}
+
+type handler func(ctx context.Context, c *cli, args []string) error
+
+// topLevel - команды без группы.
+var topLevel = map[string]handler{
+	"version": versionCommand,
+}
+
+var commands = map[string]map[string]handler{
+	"events": {
+		"list":   eventsList,
+		"get":    eventsGet,
+		"create": eventsCreate,
+		"update": eventsUpdate,
+		"delete": eventsDelete,
+		"day":    eventsPeriod("day"),
+		"week":   eventsPeriod("week"),
+		"month":  eventsPeriod("month"),
+	},
+	"notifications": {
+		"list":   notificationsList,
+		"get":    notificationsGet,
+		"create": notificationsCreate,
+		"update": notificationsUpdate,
+		"delete": notificationsDelete,
+	},
+	"ics": {
+		"export": icsExport,
+		"import": icsImport,
+	},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	global := flag.NewFlagSet("calendarctl", flag.ContinueOnError)
+	global.Usage = func() {
+		fmt.Fprint(global.Output(), usage)
+		global.PrintDefaults()
+	}
+	configPath := global.String("config", defaultConfigPath(), "path to the config file (env CALENDARCTL_CONFIG)")
+	address := global.String("address", "", "gRPC server address (env CALENDARCTL_ADDRESS)")
+	token := global.String("token", "", "auth token sent as Bearer, requires TLS (env CALENDARCTL_TOKEN)")
+	insecureToken := global.Bool("insecure-token", false, "allow sending the token without TLS in plaintext")
+	output := global.String("output", "", "output format: table, json or yaml (env CALENDARCTL_OUTPUT)")
+	timeout := global.Int("timeout", 0, "call timeout in seconds (env CALENDARCTL_TIMEOUT)")
+	useTLS := global.Bool("tls", false, "connect over TLS (env CALENDARCTL_TLS_ENABLED)")
+	caFile := global.String("tls-ca", "", "PEM CA bundle to verify the server")
+	certFile := global.String("tls-cert", "", "client certificate for mutual TLS")
+	keyFile := global.String("tls-key", "", "client certificate key for mutual TLS")
+	serverName := global.String("tls-server-name", "", "server name to verify the certificate against")
+	skipVerify := global.Bool("tls-insecure", false, "do not verify the server certificate")
+	if err := parseFlags(global, args); err != nil {
+		return exitCode(err)
+	}
+
+	cmd, cmdArgs, ok := lookup(global)
+	if !ok {
+		return 2
+	}
+
+	path, explicit := *configPath, false
+	if env := os.Getenv("CALENDARCTL_CONFIG"); env != "" {
+		path, explicit = env, true
+	}
+	global.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			path, explicit = *configPath, true
+		}
+	})
+	cfg, err := loadClientConfig(path, explicit)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	// Флаги переопределяют значения из файла и окружения, только если заданы явно.
+	global.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "address":
+			cfg.Address = *address
+		case "token":
+			cfg.Token = *token
+		case "insecure-token":
+			cfg.InsecureToken = *insecureToken
+		case "output":
+			cfg.Output = *output
+		case "timeout":
+			cfg.Timeout = *timeout
+		case "tls":
+			cfg.TLS.Enabled = *useTLS
+		case "tls-ca":
+			cfg.TLS.CAFile = *caFile
+		case "tls-cert":
+			cfg.TLS.CertFile = *certFile
+		case "tls-key":
+			cfg.TLS.KeyFile = *keyFile
+		case "tls-server-name":
+			cfg.TLS.ServerName = *serverName
+		case "tls-insecure":
+			cfg.TLS.InsecureSkipVerify = *skipVerify
+		}
+	})
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	out, err := newPrinter(os.Stdout, cfg.Output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	conn, err := dial(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer conn.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	c := &cli{
+		events:        api.NewEventServiceClient(conn),
+		notifications: api.NewNotificationServiceClient(conn),
+		version:       api.NewVersionServiceClient(conn),
+		out:           out,
+		timeout:       time.Duration(cfg.Timeout) * time.Second,
+	}
+	if err := cmd(ctx, c, cmdArgs); err != nil {
+		if !errors.Is(err, errUsage) && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, errorMessage(err))
+		}
+		return exitCode(err)
+	}
+	return 0
+}
+
+// lookup находит команду по аргументам после глобальных флагов и возвращает ее аргументы.
+func lookup(global *flag.FlagSet) (handler, []string, bool) {
+	args := global.Args()
+	if len(args) == 0 {
+		global.Usage()
+		return nil, nil, false
+	}
+	if cmd, ok := topLevel[args[0]]; ok {
+		return cmd, args[1:], true
+	}
+	group, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command group %q\n", args[0])
+		return nil, nil, false
+	}
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "command is required, available: %s\n", strings.Join(names(group), ", "))
+		return nil, nil, false
+	}
+	cmd, ok := group[args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q, available: %s\n", args[1], strings.Join(names(group), ", "))
+		return nil, nil, false
+	}
+	return cmd, args[2:], true
+}
+
+// call возвращает контекст одного вызова API с таймаутом из конфигурации.
+func (c *cli) call(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// newFlagSet создает набор флагов команды, печатающий использование в stderr.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: calendarctl %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags разбирает флаги, сводя ошибки разбора к errUsage: flag.FlagSet уже вывел их вместе с usage.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// parseWithID разбирает флаги команды с обязательным позиционным идентификатором, который может стоять
+// как до, так и после флагов.
+func parseWithID(fs *flag.FlagSet, args []string) (string, error) {
+	var id string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, args = args[0], args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return "", err
+	}
+	if id == "" && fs.NArg() > 0 {
+		id = fs.Arg(0)
+	}
+	if id == "" {
+		fmt.Fprintln(fs.Output(), "id is required")
+		fs.Usage()
+		return "", errUsage
+	}
+	return id, nil
+}
+
+// stringList - повторяемый строковый флаг.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// timeFlag - флаг времени в форматах parseTime.
+type timeFlag struct {
+	time.Time
+}
+
+func (t *timeFlag) String() string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (t *timeFlag) Set(value string) (err error) {
+	t.Time, err = parseTime(value)
+	return err
+}
+
+func names(group map[string]handler) []string {
+	result := make([]string, 0, len(group))
+	for name := range group {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// errorMessage выводит для ошибок gRPC код и сообщение без служебного префикса.
+func errorMessage(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return fmt.Sprintf("%s: %s", st.Code(), st.Message())
+	}
+	return err.Error()
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		return 1
+	}
+}
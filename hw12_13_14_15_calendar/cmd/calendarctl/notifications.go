@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// notificationFlags - флаги полей уведомления для create и update.
+type notificationFlags struct {
+	eventID string
+	userID  string
+	time    timeFlag
+	message string
+	sent    string
+}
+
+// notificationMaskPaths сопоставляет флаги путям update_mask.
+var notificationMaskPaths = map[string]string{
+	"event":   "event_id",
+	"user":    "user_id",
+	"time":    "time",
+	"message": "message",
+	"sent":    "sent",
+}
+
+func (f *notificationFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.eventID, "event", "", "event id")
+	fs.StringVar(&f.userID, "user", "", "recipient user id")
+	fs.Var(&f.time, "time", "time to send the notification")
+	fs.StringVar(&f.message, "message", "", "notification message")
+	fs.StringVar(&f.sent, "sent", dto.NotificationOnWait, "status: wait, on-queue or sent")
+}
+
+func notificationsList(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("notifications list", "")
+	var start, end timeFlag
+	fs.Var(&start, "start", "range start, default beginning of today")
+	fs.Var(&end, "end", "range end, default a week after start")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	from, to := defaultRange(start, end)
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	resp, err := c.notifications.ListNotifications(ctx, &api.ListNotificationsRequest{
+		StartTime: timestamppb.New(from),
+		EndTime:   timestamppb.New(to),
+	})
+	if err != nil {
+		return err
+	}
+	return c.out.notifications(resp.GetNotifications())
+}
+
+func notificationsGet(ctx context.Context, c *cli, args []string) error {
+	id, err := parseWithID(newFlagSet("notifications get", "<id>"), args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	resp, err := c.notifications.GetNotification(ctx, &api.GetNotificationRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	return c.out.notification(resp.GetNotification())
+}
+
+func notificationsCreate(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("notifications create", "")
+	var fields notificationFlags
+	fields.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fields.time.IsZero() {
+		fs.Usage()
+		return errUsage
+	}
+
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	resp, err := c.notifications.CreateNotification(ctx, &api.CreateNotificationRequest{
+		EventId: fields.eventID,
+		UserId:  fields.userID,
+		Time:    timestamppb.New(fields.time.Time),
+		Message: fields.message,
+		Sent:    fields.sent,
+	})
+	if err != nil {
+		return err
+	}
+	return c.out.id(resp.GetId())
+}
+
+// notificationsUpdate обновляет только явно заданные флагами поля через update_mask.
+func notificationsUpdate(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("notifications update", "<id>")
+	var fields notificationFlags
+	fields.register(fs)
+	expectedVersion := fs.Int64("expected-version", 0, "fail unless the notification has this version, 0 - no check")
+	id, err := parseWithID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	mask := &fieldmaskpb.FieldMask{}
+	fs.Visit(func(f *flag.Flag) {
+		if path, ok := notificationMaskPaths[f.Name]; ok {
+			mask.Paths = append(mask.Paths, path)
+		}
+	})
+	if len(mask.GetPaths()) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	_, err = c.notifications.UpdateNotification(ctx, &api.UpdateNotificationRequest{
+		Id:              id,
+		EventId:         fields.eventID,
+		UserId:          fields.userID,
+		Time:            optionalTimestamp(fields.time),
+		Message:         fields.message,
+		Sent:            fields.sent,
+		ExpectedVersion: *expectedVersion,
+		UpdateMask:      mask,
+	})
+	return err
+}
+
+func notificationsDelete(ctx context.Context, c *cli, args []string) error {
+	id, err := parseWithID(newFlagSet("notifications delete", "<id>"), args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	_, err = c.notifications.DeleteNotification(ctx, &api.DeleteNotificationRequest{Id: id})
+	return err
+}
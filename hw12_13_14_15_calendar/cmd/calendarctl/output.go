@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+
+	tableTimeLayout = "2006-01-02 15:04"
+)
+
+// printer выводит ответы API в выбранном формате. JSON и YAML строятся из protojson, поэтому имена
+// полей совпадают с REST API.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+		return &printer{w: w, format: format}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, use table, json or yaml", format)
+	}
+}
+
+func (p *printer) events(events []*api.Event) error {
+	if p.format != outputTable {
+		return p.structured(messages(events), true)
+	}
+	return p.table([]string{"ID", "TITLE", "START", "END", "CALENDAR", "ATTENDEES", "VERSION"}, len(events),
+		func(i int) []string {
+			e := events[i]
+			return []string{
+				e.GetId(), e.GetTitle(), formatTime(e.GetStartTime()), formatTime(e.GetEndTime()),
+				e.GetCalendarId(), strings.Join(e.GetAttendees(), ","), strconv.FormatInt(e.GetVersion(), 10),
+			}
+		})
+}
+
+func (p *printer) event(event *api.Event) error {
+	if p.format != outputTable {
+		return p.structured([]proto.Message{event}, false)
+	}
+	return p.fields([][2]string{
+		{"ID", event.GetId()},
+		{"Title", event.GetTitle()},
+		{"Description", event.GetDescription()},
+		{"Start", formatTime(event.GetStartTime())},
+		{"End", formatTime(event.GetEndTime())},
+		{"User", event.GetUserId()},
+		{"Calendar", event.GetCalendarId()},
+		{"Attendees", strings.Join(event.GetAttendees(), ", ")},
+		{"Version", strconv.FormatInt(event.GetVersion(), 10)},
+		{"Updated", formatTime(event.GetUpdatedAt())},
+	})
+}
+
+func (p *printer) notifications(notifications []*api.Notification) error {
+	if p.format != outputTable {
+		return p.structured(messages(notifications), true)
+	}
+	return p.table([]string{"ID", "EVENT", "TIME", "MESSAGE", "SENT", "VERSION"}, len(notifications),
+		func(i int) []string {
+			n := notifications[i]
+			return []string{
+				n.GetId(), n.GetEventId(), formatTime(n.GetTime()), n.GetMessage(), n.GetSent(),
+				strconv.FormatInt(n.GetVersion(), 10),
+			}
+		})
+}
+
+func (p *printer) notification(notification *api.Notification) error {
+	if p.format != outputTable {
+		return p.structured([]proto.Message{notification}, false)
+	}
+	return p.fields([][2]string{
+		{"ID", notification.GetId()},
+		{"Event", notification.GetEventId()},
+		{"User", notification.GetUserId()},
+		{"Time", formatTime(notification.GetTime())},
+		{"Message", notification.GetMessage()},
+		{"Sent", notification.GetSent()},
+		{"Version", strconv.FormatInt(notification.GetVersion(), 10)},
+	})
+}
+
+// id выводит идентификатор созданной сущности.
+func (p *printer) id(id string) error {
+	switch p.format {
+	case outputJSON:
+		return p.encodeJSON(map[string]string{"id": id})
+	case outputYAML:
+		return p.encodeYAML(map[string]string{"id": id})
+	default:
+		_, err := fmt.Fprintln(p.w, id)
+		return err
+	}
+}
+
+// ids выводит список идентификаторов созданных сущностей.
+func (p *printer) ids(ids []string) error {
+	values := make([]map[string]string, len(ids))
+	for i, id := range ids {
+		values[i] = map[string]string{"id": id}
+	}
+	switch p.format {
+	case outputJSON:
+		return p.encodeJSON(values)
+	case outputYAML:
+		return p.encodeYAML(values)
+	default:
+		for _, id := range ids {
+			if _, err := fmt.Fprintln(p.w, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (p *printer) table(header []string, n int, row func(i int) []string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for i := 0; i < n; i++ {
+		fmt.Fprintln(tw, strings.Join(row(i), "\t"))
+	}
+	return tw.Flush()
+}
+
+func (p *printer) fields(fields [][2]string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	for _, field := range fields {
+		fmt.Fprintf(tw, "%s:\t%s\n", field[0], field[1])
+	}
+	return tw.Flush()
+}
+
+// structured выводит сообщения в JSON или YAML: списком, если list, иначе единственный объект.
+func (p *printer) structured(items []proto.Message, list bool) error {
+	values := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		raw, err := protojson.Marshal(item)
+		if err != nil {
+			return err
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		values = append(values, value)
+	}
+
+	var data interface{} = values
+	if !list && len(values) == 1 {
+		data = values[0]
+	}
+	if p.format == outputYAML {
+		return p.encodeYAML(data)
+	}
+	return p.encodeJSON(data)
+}
+
+func (p *printer) encodeJSON(v interface{}) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (p *printer) encodeYAML(v interface{}) error {
+	encoder := yaml.NewEncoder(p.w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func messages[T proto.Message](items []T) []proto.Message {
+	result := make([]proto.Message, len(items))
+	for i, item := range items {
+		result[i] = item
+	}
+	return result
+}
+
+func formatTime(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return ""
+	}
+	return ts.AsTime().Local().Format(tableTimeLayout)
+}
+
+// parseTime разбирает время из флага: RFC 3339, дату с временем или дату в локальном часовом поясе.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", tableTimeLayout, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use RFC 3339, YYYY-MM-DD HH:MM or YYYY-MM-DD", value)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/version"
+)
+
+// versionCommand выводит версию calendarctl и сервера. Версия клиента выводится, даже если сервер
+// недоступен.
+func versionCommand(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("version", "")
+	clientOnly := fs.Bool("client", false, "print only the client version")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	versions := struct {
+		Client version.Info  `json:"client" yaml:"client"`
+		Server *version.Info `json:"server,omitempty" yaml:"server,omitempty"`
+	}{Client: version.Get()}
+
+	var err error
+	if !*clientOnly {
+		ctx, cancel := c.call(ctx)
+		defer cancel()
+		var resp *api.GetVersionResponse
+		if resp, err = c.version.GetVersion(ctx, &api.GetVersionRequest{}); err == nil {
+			versions.Server = &version.Info{
+				Release:   resp.GetRelease(),
+				BuildDate: resp.GetBuildDate(),
+				GitHash:   resp.GetGitHash(),
+				GoVersion: resp.GetGoVersion(),
+			}
+		}
+	}
+
+	switch c.out.format {
+	case outputJSON:
+		if printErr := c.out.encodeJSON(versions); printErr != nil {
+			return printErr
+		}
+	case outputYAML:
+		if printErr := c.out.encodeYAML(versions); printErr != nil {
+			return printErr
+		}
+	default:
+		fmt.Fprintf(c.out.w, "Client: %s\n", formatVersion(versions.Client))
+		if versions.Server != nil {
+			fmt.Fprintf(c.out.w, "Server: %s\n", formatVersion(*versions.Server))
+		}
+	}
+	return err
+}
+
+func formatVersion(info version.Info) string {
+	return fmt.Sprintf("%s (git %s, built %s, %s)", info.Release, info.GitHash, info.BuildDate, info.GoVersion)
+}
@@ -12,11 +12,14 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/certs"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -33,7 +36,11 @@ func TestMain(m *testing.M) {
 
 	fmt.Printf("grpcAddress: %s\n", grpcAddress)
 
-	conn, err = grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	creds, err := getTransportCredentials()
+	if err != nil {
+		panic(err)
+	}
+	conn, err = grpc.Dial(grpcAddress, grpc.WithTransportCredentials(creds))
 	if err != nil {
 		panic(err)
 	}
@@ -66,6 +73,27 @@ func getGRPCAddress() string {
 	return "localhost:9090"
 }
 
+// getTransportCredentials возвращает настройки TLS подключения к gRPC-серверу из переменных окружения
+// GRPC_TLS, GRPC_TLS_CA_FILE, GRPC_TLS_CERT_FILE, GRPC_TLS_KEY_FILE и GRPC_TLS_SERVER_NAME.
+// Без них подключение идет без TLS.
+func getTransportCredentials() (credentials.TransportCredentials, error) {
+	cfg := config.ClientTLSConfig{
+		CAFile:     os.Getenv("GRPC_TLS_CA_FILE"),
+		CertFile:   os.Getenv("GRPC_TLS_CERT_FILE"),
+		KeyFile:    os.Getenv("GRPC_TLS_KEY_FILE"),
+		ServerName: os.Getenv("GRPC_TLS_SERVER_NAME"),
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv("GRPC_TLS"))
+	if !enabled && cfg.CAFile == "" && cfg.CertFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+	tlsConfig, err := certs.ClientConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
 // TestCreateEvent Тест на добавление события.
 func TestCreateEvent(t *testing.T) {
 	ctx := context.Background()
@@ -3,6 +3,7 @@ package app
 import "context"
 
 type App interface {
-	Start(ctx context.Context) error
-	Stop(ctx context.Context) error
+	// Run запускает приложение и работает до отмены ctx, после чего останавливает его. Возвращает
+	// ошибку запуска, отказа компонента или остановки.
+	Run(ctx context.Context) error
 }
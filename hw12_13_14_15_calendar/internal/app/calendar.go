@@ -4,15 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
+	"time"
 
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/certs"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config/reload"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/lifecycle"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/server/grpc"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/server/internalhttp"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/services"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	cachestorage "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/cache"
 	memorystorage "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
 	sqlstorage "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/sql"
+	sqlitestorage "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/sqlite"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 type CalendarApp struct {
@@ -20,13 +31,18 @@ type CalendarApp struct {
 	logger              logger.Logger
 	httpServer          *internalhttp.Server
 	grpcServer          *grpc.Server
+	gatewayConn         *grpcgo.ClientConn
 	storage             storage.Storage
 	eventService        services.EventService
 	notificationService services.NotificationService
+	calendarService     services.CalendarService
 	healthService       services.HealthService
+	lifecycle           *lifecycle.Manager
 }
 
-func NewApp(config *config.Config) (*CalendarApp, error) {
+// NewApp создает приложение с конфигурацией config, загруженной из файла configPath. Файл
+// перечитывается по SIGHUP и при изменении.
+func NewApp(config *config.Config, configPath string) (*CalendarApp, error) {
 	// Инициализация логгера
 	logInstance, err := logger.New(config.Logger)
 	if err != nil {
@@ -38,6 +54,11 @@ func NewApp(config *config.Config) (*CalendarApp, error) {
 		return nil, fmt.Errorf("on initializing storage, %w", err)
 	}
 
+	store, err = initCache(config.Cache, store, logInstance)
+	if err != nil {
+		return nil, fmt.Errorf("on initializing cache, %w", err)
+	}
+
 	app := &CalendarApp{
 		config:  config,
 		logger:  logInstance,
@@ -47,87 +68,213 @@ func NewApp(config *config.Config) (*CalendarApp, error) {
 	// Инициализация сервисов
 	app.eventService = services.NewEventService(store)
 	app.notificationService = services.NewNotificationService(store)
+	app.calendarService = services.NewCalendarService(store)
 	app.healthService = services.NewHealthService(store)
 
+	limiter, err := initRateLimiter(config.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("on initializing rate limiter, %w", err)
+	}
+
+	// REST API /v1 из grpc-gateway проксирует запросы в собственный gRPC-сервер приложения
+	if config.HTTPServer.Gateway {
+		app.gatewayConn, err = dialGateway(config, limiter)
+		if err != nil {
+			return nil, fmt.Errorf("on dialing gRPC server for gateway, %w", err)
+		}
+	}
+
 	// Initialize servers
-	app.httpServer = internalhttp.New(
+	httpServer, err := internalhttp.New(
 		config.HTTPServer,
 		logInstance,
 		app.eventService,
 		app.notificationService,
+		app.calendarService,
 		app.healthService,
+		app.gatewayConn,
+		limiter,
 	)
+	if err != nil {
+		return nil, fmt.Errorf("on initializing HTTP server, %w", err)
+	}
+	app.httpServer = httpServer
 
 	grpcServer, err := grpc.New(
 		app.eventService,
 		app.notificationService,
+		app.calendarService,
 		app.healthService,
 		logInstance,
 		config.GRPCServer,
+		limiter,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("on initializing gRPC server, %w", err)
 	}
 	app.grpcServer = grpcServer
 
+	app.lifecycle = lifecycle.NewManager(config.Shutdown, logInstance)
+	app.lifecycle.Add(lifecycle.Component{
+		Name:  "storage",
+		Start: app.startStorage,
+		Stop:  func(context.Context) error { return app.storage.Close() },
+	})
+	if certificates := app.grpcServer.Certificates(); certificates != nil {
+		app.lifecycle.Add(lifecycle.Component{
+			Name: "gRPC TLS certificates",
+			Run:  certificates.Run,
+		})
+	}
+	if certificates := app.httpServer.Certificates(); certificates != nil {
+		app.lifecycle.Add(lifecycle.Component{
+			Name: "HTTP TLS certificates",
+			Run:  certificates.Run,
+		})
+	}
+	app.lifecycle.Add(lifecycle.Component{
+		Name:  "gRPC server",
+		Run:   app.grpcServer.Start,
+		Drain: app.grpcServer.Drain,
+		Stop:  app.grpcServer.Stop,
+	})
+	if app.gatewayConn != nil {
+		app.lifecycle.Add(lifecycle.Component{
+			Name: "gateway connection",
+			Stop: func(context.Context) error { return app.gatewayConn.Close() },
+		})
+	}
+	app.lifecycle.Add(lifecycle.Component{
+		Name: "HTTP server",
+		Run:  app.httpServer.Start,
+		Stop: app.httpServer.Stop,
+	})
+	app.httpServer.SetReadiness(app.lifecycle.Ready)
+	app.lifecycle.Add(lifecycle.Component{
+		Name: "config watcher",
+		Run:  app.configWatcher(configPath, limiter).Run,
+	})
+
 	return app, nil
 }
 
-func (a *CalendarApp) Start(ctx context.Context) error {
+// Run запускает хранилище и серверы и работает до отмены ctx или отказа одного из них. Остановка идет
+// в обратном порядке: HTTP, соединение grpc-gateway, gRPC, хранилище.
+func (a *CalendarApp) Run(ctx context.Context) error {
 	a.logger.Info("Календарь запущен...")
 	a.logger.Info(a.config)
 
-	// Подключение к хранилищу
-	if err := a.storage.Connect(ctx); err != nil {
-		return fmt.Errorf("on connecting to storage, %w", err)
-	}
+	return a.lifecycle.Run(ctx)
+}
 
-	// Запуск HTTP сервера
-	go func() {
-		if err := a.httpServer.Start(ctx); err != nil {
-			a.logger.Error("failed to start HTTP server: " + err.Error())
+// configWatcher возвращает наблюдатель за файлом конфигурации, который применяет уровень логирования
+// и ограничение частоты запросов без перезапуска.
+func (a *CalendarApp) configWatcher(path string, limiter *ratelimit.Limiter) *reload.Watcher {
+	watcher := reload.NewWatcher(path, config.AppCalendar, a.config, a.logger)
+	watcher.OnReload(func(cfg *config.Config) error {
+		if err := a.logger.SetLevel(cfg.Logger.Level); err != nil {
+			return err
 		}
-		a.logger.Info("http сервер запущен")
-	}()
+		return limiter.Update(cfg.RateLimit)
+	})
+	return watcher
+}
 
-	// Запуск gRPC сервера
-	go func() {
-		if err := a.grpcServer.Start(ctx); err != nil {
-			a.logger.Error("failed to start gRPC server: " + err.Error())
+// startStorage применяет миграции, если это разрешено, и подключается к хранилищу.
+func (a *CalendarApp) startStorage(ctx context.Context) error {
+	if a.config.Database.MigrateOnStart {
+		if err := migrateUp(a.config.Database); err != nil {
+			return fmt.Errorf("on migrating database, %w", err)
 		}
-	}()
+	}
 
-	<-ctx.Done()
-	return a.Stop(ctx)
+	if err := a.storage.Connect(ctx); err != nil {
+		return fmt.Errorf("on connecting to storage, %w", err)
+	}
+	return nil
 }
 
-func (a *CalendarApp) Stop(ctx context.Context) error {
-	// Остановка HTTP сервера
-	if err := a.httpServer.Stop(ctx); err != nil {
-		return err
+// dialGateway подключается к gRPC-серверу приложения для grpc-gateway. Если на gRPC-сервере включен TLS,
+// используются настройки httpserver.gatewayTLS. Вызовы отмечаются секретом limiter: запросы к gateway
+// ограничивает HTTP-сервер.
+func dialGateway(cfg *config.Config, limiter *ratelimit.Limiter) (*grpcgo.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if cfg.GRPCServer.TLS.Enabled {
+		tlsConfig, err := certs.ClientConfig(cfg.HTTPServer.GatewayTLS)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
 	}
+	opts := append(grpc.GatewayDialOptions(limiter), grpcgo.WithTransportCredentials(creds))
+	return grpcgo.Dial(dialTarget(cfg.GRPCServer.Address), opts...)
+}
 
-	// Остановка gRPC сервера
-	a.grpcServer.Stop(ctx)
-
-	if err := a.storage.Close(); err != nil {
-		return err
+// dialTarget возвращает адрес для подключения к серверу, слушающему address. Сервер, слушающий все
+// интерфейсы, доступен через localhost.
+func dialTarget(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
 	}
-	return nil
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
 }
 
 func initStorage(config config.DatabaseConfig, logger logger.Logger) (store storage.Storage, err error) {
 	switch config.Storage {
 	case "memory":
-		store = memorystorage.New()
+		if config.Memory.Dir == "" {
+			store = memorystorage.New()
+			break
+		}
+		store, err = memorystorage.Open(config.Memory, logger)
+		if err != nil {
+			return nil, fmt.Errorf("on opening memory storage, %w", err)
+		}
 	case "sql":
 		store, err = sqlstorage.New(config, logger)
 		if err != nil {
 			return nil, fmt.Errorf("on initializing SQL storage, %w", err)
 		}
+	case "sqlite":
+		store, err = sqlitestorage.New(config, logger)
+		if err != nil {
+			return nil, fmt.Errorf("on initializing SQLite storage, %w", err)
+		}
 	default:
 		return nil, fmt.Errorf("unknown storage type: %s", config.Storage)
 	}
 
 	return store, nil
 }
+
+// initCache оборачивает хранилище кэшем выборок событий, если он включен.
+func initCache(config config.CacheConfig, store storage.Storage, logger logger.Logger) (storage.Storage, error) {
+	var backend cachestorage.Backend
+	switch config.Backend {
+	case "":
+		return store, nil
+	case "lru":
+		lru, err := cachestorage.NewLRU(config.Size)
+		if err != nil {
+			return nil, err
+		}
+		backend = lru
+	case "redis":
+		backend = cachestorage.NewRedis(config)
+	default:
+		return nil, fmt.Errorf("unknown cache backend: %s", config.Backend)
+	}
+
+	return cachestorage.New(store, backend, time.Duration(config.TTL)*time.Second, logger), nil
+}
+
+// initRateLimiter создает ограничитель частоты запросов, общий для HTTP и gRPC серверов.
+// Ограничитель создается и при выключенном ограничении, чтобы его можно было включить
+// перезагрузкой конфигурации.
+func initRateLimiter(config config.RateLimitConfig) (*ratelimit.Limiter, error) {
+	return ratelimit.New(config, ratelimit.NewMemoryStore())
+}
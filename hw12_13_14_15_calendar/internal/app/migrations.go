@@ -0,0 +1,47 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	sqlstorage "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/sql"
+)
+
+// migrateUp применяет встроенные миграции, если хранилище sql. Хранилище sqlite применяет свои миграции
+// при подключении, остальным хранилищам миграции не нужны.
+func migrateUp(cfg config.DatabaseConfig) (err error) {
+	if cfg.Storage != "sql" {
+		return nil
+	}
+
+	migrator, err := sqlstorage.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, migrator.Close())
+	}()
+
+	if err := migrator.Up(); err != nil {
+		return fmt.Errorf("on applying migrations, %w", err)
+	}
+	return nil
+}
+
+// checkSchema возвращает ошибку, если хранилище sql и схема базы отстает от встроенных миграций.
+func checkSchema(cfg config.DatabaseConfig) (err error) {
+	if cfg.Storage != "sql" {
+		return nil
+	}
+
+	migrator, err := sqlstorage.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, migrator.Close())
+	}()
+
+	return migrator.CheckVersion()
+}
@@ -6,9 +6,12 @@ import (
 	"time"
 
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config/reload"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/lifecycle"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/rabbitmq"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/server/grpc"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/services"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
@@ -19,9 +22,15 @@ type Scheduler struct {
 	notificationService services.NotificationService
 	rabbitClient        rabbitmq.Client
 	storage             storage.Storage
+	healthServer        *grpc.HealthServer
+	lifecycle           *lifecycle.Manager
+	// interval передает циклу планировщика новый интервал после перезагрузки конфигурации
+	interval chan time.Duration
 }
 
-func NewSchedulerApp(cfg *config.Config) (*Scheduler, error) {
+// NewSchedulerApp создает планировщик с конфигурацией cfg, загруженной из файла configPath. Файл
+// перечитывается по SIGHUP и при изменении.
+func NewSchedulerApp(cfg *config.Config, configPath string) (*Scheduler, error) {
 	// Инициализация логгера
 	logInstance, err := logger.New(cfg.Logger)
 	if err != nil {
@@ -43,29 +52,92 @@ func NewSchedulerApp(cfg *config.Config) (*Scheduler, error) {
 	// Инициализация сервиса уведомлений
 	notificationService := services.NewNotificationService(store)
 
-	return &Scheduler{
+	scheduler := &Scheduler{
 		config:              cfg,
 		logger:              logInstance,
 		notificationService: notificationService,
 		rabbitClient:        rabbitClient,
 		storage:             store,
-	}, nil
+		interval:            make(chan time.Duration, 1),
+	}
+	scheduler.lifecycle = lifecycle.NewManager(cfg.Shutdown, logInstance)
+	scheduler.lifecycle.Add(
+		lifecycle.Component{
+			Name:  "storage",
+			Start: scheduler.startStorage,
+			Stop:  func(context.Context) error { return store.Close() },
+		},
+		lifecycle.Component{
+			Name:  "RabbitMQ client",
+			Start: func(context.Context) error { return rabbitClient.Connect() },
+			Stop:  func(context.Context) error { return rabbitClient.Close() },
+		},
+	)
+	if cfg.Scheduler.HealthAddress != "" {
+		healthService := services.NewWorkerHealthService(store, rabbitClient)
+		scheduler.healthServer = grpc.NewHealthServer(cfg.Scheduler.HealthAddress, healthService, logInstance)
+		scheduler.lifecycle.Add(lifecycle.Component{
+			Name:  "gRPC health server",
+			Run:   scheduler.healthServer.Start,
+			Drain: scheduler.healthServer.Drain,
+			Stop:  scheduler.healthServer.Stop,
+		})
+	}
+	scheduler.lifecycle.Add(
+		lifecycle.Component{
+			Name: "scheduler",
+			Run:  scheduler.schedule,
+		},
+		lifecycle.Component{
+			Name: "config watcher",
+			Run:  scheduler.configWatcher(configPath).Run,
+		},
+	)
+	return scheduler, nil
 }
 
-func (s *Scheduler) Start(ctx context.Context) error {
+// configWatcher возвращает наблюдатель за файлом конфигурации, который применяет уровень логирования
+// и интервал планировщика без перезапуска.
+func (s *Scheduler) configWatcher(path string) *reload.Watcher {
+	watcher := reload.NewWatcher(path, config.AppScheduler, s.config, s.logger)
+	watcher.OnReload(func(cfg *config.Config) error {
+		if err := s.logger.SetLevel(cfg.Logger.Level); err != nil {
+			return err
+		}
+		// В канале остается только последний интервал
+		select {
+		case <-s.interval:
+		default:
+		}
+		s.interval <- time.Duration(cfg.Scheduler.Interval) * time.Second
+		return nil
+	})
+	return watcher
+}
+
+// Run запускает планировщик и работает до отмены ctx или отказа одного из компонентов.
+func (s *Scheduler) Run(ctx context.Context) error {
 	s.logger.Info("Scheduler started")
+	return s.lifecycle.Run(ctx)
+}
 
+func (s *Scheduler) startStorage(ctx context.Context) error {
 	// Подключение к хранилищу
 	if err := s.storage.Connect(ctx); err != nil {
 		return fmt.Errorf("on connecting to storage, %w", err)
 	}
 
-	// Подключение к RabbitMQ
-	if err := s.rabbitClient.Connect(); err != nil {
-		return fmt.Errorf("on connecting to rabbitMQ, %w", err)
+	// Планировщик не применяет миграции сам и не работает со схемой старее ожидаемой
+	if err := checkSchema(s.config.Database); err != nil {
+		return fmt.Errorf("on checking database schema, %w", err)
 	}
+	return nil
+}
 
-	ticker := time.NewTicker(time.Duration(s.config.Scheduler.Interval) * time.Second)
+// schedule публикует уведомления с заданным интервалом до отмены ctx.
+func (s *Scheduler) schedule(ctx context.Context) error {
+	interval := time.Duration(s.config.Scheduler.Interval) * time.Second
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -73,26 +145,19 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		case <-ctx.Done():
 			s.logger.Info("Scheduler stopped")
 			return nil
+		case next := <-s.interval:
+			if next != interval {
+				interval = next
+				ticker.Reset(interval)
+				s.logger.Infof("Scheduler interval changed to %s", interval)
+			}
 		case <-ticker.C:
 			s.processNotifications(ctx)
+			s.cleanupIdempotencyRecords(ctx)
 		}
 	}
 }
 
-func (s *Scheduler) Stop(_ context.Context) error {
-	s.logger.Info("Stopping Scheduler")
-
-	if err := s.storage.Close(); err != nil {
-		return fmt.Errorf("on close storage connection: %w", err)
-	}
-
-	if err := s.rabbitClient.Close(); err != nil {
-		return fmt.Errorf("on close rabbit client: %w", err)
-	}
-
-	return nil
-}
-
 func (s *Scheduler) processNotifications(ctx context.Context) {
 	// Получаем уведомления, которые необходимо отправить
 	notifications, err := s.notificationService.ListNotifications(ctx, time.Now().Add(-time.Hour*24), time.Now())
@@ -108,6 +173,8 @@ func (s *Scheduler) processNotifications(ctx context.Context) {
 			s.logger.Infof("Notification %s published", notification.ID)
 
 			notification.Sent = dto.NotificationOnQueue
+			// Уведомление уже опубликовано, поэтому статус выставляется без проверки версии
+			notification.Version = 0
 			err = s.notificationService.UpdateNotification(ctx, notification.ID, notification)
 			if err != nil {
 				s.logger.Errorf("on setting notification.Sent flag %s: %v", notification.ID, err)
@@ -118,6 +185,13 @@ func (s *Scheduler) processNotifications(ctx context.Context) {
 	}
 }
 
+// cleanupIdempotencyRecords удаляет истекшие ключи идемпотентности.
+func (s *Scheduler) cleanupIdempotencyRecords(ctx context.Context) {
+	if err := s.storage.IdempotencyRepository().DeleteExpiredIdempotencyRecords(ctx, time.Now()); err != nil {
+		s.logger.Errorf("on deleting expired idempotency records: %v", err)
+	}
+}
+
 // Метод cleanupOldNotifications временно не используется. Раньше после отправки уведомления
 // оно сразу удалялось. Сейчас чтобы можно было отследить статус уведомления, уведомления не удаляются.
 //
@@ -2,26 +2,37 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config/reload"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/email"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/lifecycle"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/rabbitmq"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/server/grpc"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/services"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
 type SenderApp struct {
 	config              config.SenderConfig
+	database            config.DatabaseConfig
 	logger              logger.Logger
 	rabbitClient        rabbitmq.Client
 	senderService       *services.SenderService
 	notificationService services.NotificationService
+	storage             storage.Storage
+	healthServer        *grpc.HealthServer
+	lifecycle           *lifecycle.Manager
 }
 
-func NewSenderApp(cfg *config.Config) (*SenderApp, error) {
+// NewSenderApp создает рассылку с конфигурацией cfg, загруженной из файла configPath. Файл
+// перечитывается по SIGHUP и при изменении.
+func NewSenderApp(cfg *config.Config, configPath string) (*SenderApp, error) {
 	logInstance, err := logger.New(cfg.Logger)
 	if err != nil {
 		log.Fatalf("on initializing logger, %s", err)
@@ -33,7 +44,11 @@ func NewSenderApp(cfg *config.Config) (*SenderApp, error) {
 	}
 
 	emailClient := email.NewSMTPClient(&cfg.Email)
-	service := services.NewSenderService(emailClient, logInstance)
+	templates, err := email.ParseTemplates(cfg.Email)
+	if err != nil {
+		return nil, err
+	}
+	service := services.NewSenderService(emailClient, templates, logInstance)
 
 	// Инициализация хранилища
 	store, err := initStorage(cfg.Database, logInstance)
@@ -44,33 +59,86 @@ func NewSenderApp(cfg *config.Config) (*SenderApp, error) {
 	// Инициализация сервиса уведомлений
 	notificationService := services.NewNotificationService(store)
 
-	return &SenderApp{
+	app := &SenderApp{
 		config:              cfg.Sender,
+		database:            cfg.Database,
 		logger:              logInstance,
 		rabbitClient:        rabbitClient,
 		senderService:       service,
 		notificationService: notificationService,
-	}, nil
+		storage:             store,
+	}
+	app.lifecycle = lifecycle.NewManager(cfg.Shutdown, logInstance)
+	app.lifecycle.Add(
+		lifecycle.Component{
+			Name:  "storage",
+			Start: app.startStorage,
+			Stop:  func(context.Context) error { return store.Close() },
+		},
+		lifecycle.Component{
+			Name:  "RabbitMQ client",
+			Start: func(context.Context) error { return rabbitClient.Connect() },
+			Stop:  func(context.Context) error { return rabbitClient.Close() },
+		},
+	)
+	if cfg.Sender.HealthAddress != "" {
+		healthService := services.NewWorkerHealthService(store, rabbitClient)
+		app.healthServer = grpc.NewHealthServer(cfg.Sender.HealthAddress, healthService, logInstance)
+		app.lifecycle.Add(lifecycle.Component{
+			Name:  "gRPC health server",
+			Run:   app.healthServer.Start,
+			Drain: app.healthServer.Drain,
+			Stop:  app.healthServer.Stop,
+		})
+	}
+	app.lifecycle.Add(
+		lifecycle.Component{
+			Name: "message processor",
+			Run:  app.runMessageProcessor,
+		},
+		lifecycle.Component{
+			Name: "config watcher",
+			Run:  app.configWatcher(configPath, cfg).Run,
+		},
+	)
+	return app, nil
 }
 
-func (a *SenderApp) Start(ctx context.Context) error {
+// configWatcher возвращает наблюдатель за файлом конфигурации, который применяет уровень логирования
+// и шаблоны писем без перезапуска.
+func (a *SenderApp) configWatcher(path string, cfg *config.Config) *reload.Watcher {
+	watcher := reload.NewWatcher(path, config.AppSender, cfg, a.logger)
+	watcher.OnReload(func(cfg *config.Config) error {
+		templates, err := email.ParseTemplates(cfg.Email)
+		if err != nil {
+			return err
+		}
+		if err := a.logger.SetLevel(cfg.Logger.Level); err != nil {
+			return err
+		}
+		a.senderService.SetTemplates(templates)
+		return nil
+	})
+	return watcher
+}
+
+// Run запускает рассылку и работает до отмены ctx или отказа одного из компонентов.
+func (a *SenderApp) Run(ctx context.Context) error {
 	a.logger.Info("Starting SenderApp")
+	return a.lifecycle.Run(ctx)
+}
 
-	// Подключаемся к RabbitMQ
-	if err := a.rabbitClient.Connect(); err != nil {
-		return err
+func (a *SenderApp) startStorage(ctx context.Context) error {
+	// Подключение к хранилищу
+	if err := a.storage.Connect(ctx); err != nil {
+		return fmt.Errorf("on connecting to storage, %w", err)
 	}
 
-	// Запускаем обработку сообщений из очереди
-	go func() {
-		if err := a.runMessageProcessor(ctx); err != nil {
-			a.logger.Error("Failed to process messages: %v", err)
-			// Handle errors as needed
-		}
-	}()
-
-	<-ctx.Done()
-	return a.Stop(ctx)
+	// Рассылка не применяет миграции сама и не работает со схемой старее ожидаемой
+	if err := checkSchema(a.database); err != nil {
+		return fmt.Errorf("on checking database schema, %w", err)
+	}
+	return nil
 }
 
 func (a *SenderApp) runMessageProcessor(ctx context.Context) error {
@@ -83,7 +151,8 @@ func (a *SenderApp) runMessageProcessor(ctx context.Context) error {
 		select {
 		case notification, ok := <-notificationChannel:
 			if !ok {
-				return nil // Channel closed, exit
+				// Канал закрывается, когда брокер обрывает подписку
+				return errors.New("notification channel closed")
 			}
 			a.handleNotification(ctx, notification)
 		case <-ctx.Done():
@@ -99,17 +168,10 @@ func (a *SenderApp) handleNotification(ctx context.Context, notification dto.Not
 	}
 
 	notification.Sent = dto.NotificationSent
+	// Версия в сообщении устарела: планировщик уже перевел уведомление в очередь после публикации
+	notification.Version = 0
 	err := a.notificationService.UpdateNotification(ctx, notification.ID, notification)
 	if err != nil {
 		a.logger.Errorf("error updating notification: %w", err)
 	}
 }
-
-func (a *SenderApp) Stop(_ context.Context) error {
-	a.logger.Info("Stopping SenderApp")
-	err := a.rabbitClient.Close()
-	if err != nil {
-		return fmt.Errorf("on close rabbit client: %w", err)
-	}
-	return nil
-}
@@ -0,0 +1,220 @@
+// Package certs готовит настройки TLS для серверов и клиентов API. Сертификаты сервера и бандл CA
+// клиентов перечитываются при изменении файлов, поэтому выпуск нового сертификата не требует
+// перезапуска приложения.
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+// debounce пауза после последнего изменения файлов: сертификат и ключ обычно заменяются по очереди.
+const debounce = 500 * time.Millisecond
+
+// material сертификат сервера и CA клиентов, загруженные из файлов.
+type material struct {
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	checksum    [sha256.Size]byte
+}
+
+// Reloader хранит текущие сертификаты сервера и подставляет их в каждое новое TLS-соединение.
+type Reloader struct {
+	config   config.TLSConfig
+	logger   logger.Logger
+	mu       sync.Mutex
+	material atomic.Pointer[material]
+}
+
+// NewReloader загружает сертификаты из файлов cfg. Ошибка загрузки прерывает запуск сервера.
+func NewReloader(cfg config.TLSConfig, logger logger.Logger) (*Reloader, error) {
+	r := &Reloader{config: cfg, logger: logger}
+	m, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.material.Store(m)
+	return r, nil
+}
+
+// ServerConfig возвращает настройки TLS сервера. nextProtos - протоколы ALPN сервера.
+func (r *Reloader) ServerConfig(nextProtos ...string) *tls.Config {
+	clientAuth := clientAuthType(r.config.ClientAuth)
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.Certificate(), nil
+		},
+		// Настройки собираются для каждого соединения, чтобы учитывать перечитанный бандл CA клиентов
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			m := r.material.Load()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   nextProtos,
+				Certificates: []tls.Certificate{*m.certificate},
+				ClientAuth:   clientAuth,
+				ClientCAs:    m.clientCAs,
+			}, nil
+		},
+	}
+}
+
+// Certificate возвращает текущий сертификат сервера.
+func (r *Reloader) Certificate() *tls.Certificate {
+	return r.material.Load().certificate
+}
+
+func clientAuthType(mode string) tls.ClientAuthType {
+	switch mode {
+	case "optional":
+		return tls.VerifyClientCertIfGiven
+	case "require":
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.NoClientCert
+	}
+}
+
+// files возвращает файлы, за изменением которых следит Reloader.
+func (r *Reloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+	return files
+}
+
+func (r *Reloader) load() (*material, error) {
+	hash := sha256.New()
+	contents := make(map[string][]byte, 3)
+	for _, file := range r.files() {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("on reading TLS file: %w", err)
+		}
+		contents[file] = content
+		hash.Write(content)
+	}
+
+	m := &material{}
+	hash.Sum(m.checksum[:0])
+	certificate, err := tls.X509KeyPair(contents[r.config.CertFile], contents[r.config.KeyFile])
+	if err != nil {
+		return nil, fmt.Errorf("on loading certificate %s: %w", r.config.CertFile, err)
+	}
+	m.certificate = &certificate
+	if r.config.ClientCAFile != "" {
+		m.clientCAs = x509.NewCertPool()
+		if !m.clientCAs.AppendCertsFromPEM(contents[r.config.ClientCAFile]) {
+			return nil, fmt.Errorf("no certificates found in %s", r.config.ClientCAFile)
+		}
+	}
+	return m, nil
+}
+
+// Reload перечитывает файлы сертификатов. При ошибке продолжают действовать прежние сертификаты.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, err := r.load()
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(m.checksum[:], r.material.Load().checksum[:]) {
+		return nil
+	}
+	r.material.Store(m)
+	r.logger.Infof("TLS certificate %s reloaded", r.config.CertFile)
+	return nil
+}
+
+// Run перечитывает сертификаты при изменении файлов до отмены ctx. Ошибки перечитывания записываются
+// в лог и не останавливают сервер.
+func (r *Reloader) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("on watching TLS files: %w", err)
+	}
+	defer watcher.Close()
+
+	// Следим за каталогами: файлы обычно заменяются атомарно, а в Kubernetes - через символьную ссылку
+	watched := make(map[string]bool)
+	for _, file := range r.files() {
+		dir := filepath.Dir(file)
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("on watching TLS files: %w", err)
+		}
+		watched[dir] = true
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-watcher.Events:
+			timer.Reset(debounce)
+		case err := <-watcher.Errors:
+			if err != nil {
+				r.logger.Errorf("on watching TLS files: %v", err)
+			}
+		case <-timer.C:
+			if err := r.Reload(); err != nil {
+				r.logger.Errorf("on reloading TLS certificate, keeping the current one: %v", err)
+			}
+		}
+	}
+}
+
+// ClientConfig возвращает настройки TLS клиента.
+func ClientConfig(cfg config.ClientTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("on reading CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, errors.New("client certificate requires both certificate and key files")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("on loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,237 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA удостоверяющий центр для выпуска сертификатов в тестах.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "calendar test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue выпускает сертификат для localhost и возвращает его и ключ в PEM.
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, content []byte) string {
+	t.Helper()
+
+	// Файл заменяется атомарно, как это делают утилиты выпуска сертификатов
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, content, 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+	return path
+}
+
+func newTestLogger(t *testing.T) logger.Logger {
+	t.Helper()
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+	return log
+}
+
+// serve принимает TLS-соединения и отвечает на каждое строкой "ok".
+func serve(t *testing.T, tlsConfig *tls.Config) string {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = conn.Write([]byte("ok"))
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// handshake подключается к серверу и возвращает серийный номер его сертификата.
+func handshake(address string, clientConfig *tls.Config) (int64, error) {
+	conn, err := tls.Dial("tcp", address, clientConfig)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	// Отказ в проверке сертификата клиента TLS 1.3 сообщает уже после рукопожатия
+	if _, err := io.ReadAll(conn); err != nil {
+		return 0, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	cfg := config.TLSConfig{
+		Enabled:  true,
+		CertFile: writeFile(t, filepath.Join(dir, "server.crt"), certPEM),
+		KeyFile:  writeFile(t, filepath.Join(dir, "server.key"), keyPEM),
+	}
+
+	reloader, err := NewReloader(cfg, newTestLogger(t))
+	require.NoError(t, err)
+	address := serve(t, reloader.ServerConfig())
+
+	clientConfig, err := ClientConfig(config.ClientTLSConfig{CAFile: writeFile(t, filepath.Join(dir, "ca.crt"), ca.pem)})
+	require.NoError(t, err)
+	serial, err := handshake(address, clientConfig)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), serial)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- reloader.Run(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	// Новый сертификат подхватывается без перезапуска сервера
+	certPEM, keyPEM = ca.issue(t, 11, x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.KeyFile, keyPEM)
+	writeFile(t, cfg.CertFile, certPEM)
+	assert.Eventually(t, func() bool {
+		serial, err := handshake(address, clientConfig)
+		return err == nil && serial == 11
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// Поврежденный файл не заменяет действующий сертификат
+	writeFile(t, cfg.CertFile, []byte("garbage"))
+	require.Error(t, reloader.Reload())
+	serial, err = handshake(address, clientConfig)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), serial)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	_, err = NewReloader(cfg, newTestLogger(t))
+	assert.Error(t, err)
+}
+
+func TestReloader_ClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := writeFile(t, filepath.Join(dir, "ca.crt"), ca.pem)
+	serverCert, serverKey := ca.issue(t, 1, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, 2, x509.ExtKeyUsageClientAuth)
+	otherCA := newTestCA(t)
+	otherCert, otherKey := otherCA.issue(t, 3, x509.ExtKeyUsageClientAuth)
+
+	clientTLS := func(t *testing.T, cert, key []byte) *tls.Config {
+		t.Helper()
+
+		cfg := config.ClientTLSConfig{CAFile: caFile}
+		if cert != nil {
+			cfg.CertFile = writeFile(t, filepath.Join(dir, "client.crt"), cert)
+			cfg.KeyFile = writeFile(t, filepath.Join(dir, "client.key"), key)
+		}
+		tlsConfig, err := ClientConfig(cfg)
+		require.NoError(t, err)
+		return tlsConfig
+	}
+
+	tests := []struct {
+		clientAuth string
+		cert, key  []byte
+		allowed    bool
+	}{
+		{clientAuth: "require", cert: clientCert, key: clientKey, allowed: true},
+		{clientAuth: "require", allowed: false},
+		{clientAuth: "require", cert: otherCert, key: otherKey, allowed: false},
+		{clientAuth: "optional", allowed: true},
+		{clientAuth: "optional", cert: otherCert, key: otherKey, allowed: false},
+		{clientAuth: "none", cert: otherCert, key: otherKey, allowed: true},
+	}
+	for _, tt := range tests {
+		reloader, err := NewReloader(config.TLSConfig{
+			Enabled:      true,
+			CertFile:     writeFile(t, filepath.Join(dir, "server.crt"), serverCert),
+			KeyFile:      writeFile(t, filepath.Join(dir, "server.key"), serverKey),
+			ClientAuth:   tt.clientAuth,
+			ClientCAFile: caFile,
+		}, newTestLogger(t))
+		require.NoError(t, err)
+		address := serve(t, reloader.ServerConfig())
+
+		_, err = handshake(address, clientTLS(t, tt.cert, tt.key))
+		if tt.allowed {
+			assert.NoError(t, err, tt.clientAuth)
+		} else {
+			assert.Error(t, err, tt.clientAuth)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Reloadable возвращает копию c с настройками из next, которые приложения применяют без перезапуска:
+// уровень логирования, интервал планировщика, ограничение частоты запросов и шаблоны писем.
+// Вторым значением возвращаются пути остальных изменившихся настроек: они вступят в силу только
+// после перезапуска.
+func (c *Config) Reloadable(next *Config) (*Config, []string) {
+	applied := *c
+	applied.Logger.Level = next.Logger.Level
+	applied.Scheduler.Interval = next.Scheduler.Interval
+	applied.RateLimit = next.RateLimit
+	applied.Email.SubjectTemplate = next.Email.SubjectTemplate
+	applied.Email.BodyTemplate = next.Email.BodyTemplate
+
+	return &applied, diff("", reflect.ValueOf(applied), reflect.ValueOf(*next))
+}
+
+// sectionKeys ключи разделов файла конфигурации, которые не совпадают с именами полей по lowerFirst.
+var sectionKeys = map[string]string{
+	"HTTPServer": "httpserver",
+	"GRPCServer": "grpcserver",
+	"RabbitMQ":   "rabbitmq",
+}
+
+// diff возвращает пути различающихся полей структур a и b в нотации файла конфигурации.
+func diff(prefix string, a, b reflect.Value) []string {
+	var changed []string
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		name := prefix + lowerFirst(field.Name)
+		if key, ok := sectionKeys[field.Name]; ok && prefix == "" {
+			name = key
+		}
+		if field.Type.Kind() == reflect.Struct {
+			changed = append(changed, diff(name+".", a.Field(i), b.Field(i))...)
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// lowerFirst переводит имя поля в имя ключа: HTTPServer - httpServer, SMTPPort - smtpPort.
+func lowerFirst(name string) string {
+	upper := 0
+	for upper < len(name) && name[upper] >= 'A' && name[upper] <= 'Z' {
+		upper++
+	}
+	switch {
+	case upper == len(name):
+		return strings.ToLower(name)
+	case upper > 1:
+		// Аббревиатура перед словом: последняя заглавная буква начинает слово
+		upper--
+	}
+	return strings.ToLower(name[:upper]) + name[upper:]
+}
@@ -0,0 +1,144 @@
+// Package reload перечитывает конфигурацию во время работы приложения: по сигналу SIGHUP и при изменении
+// файла конфигурации. Приложениям передаются только настройки, которые можно менять без перезапуска,
+// см. config.Config.Reloadable.
+package reload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+// debounce пауза после последнего события файловой системы: редакторы и ConfigMap в Kubernetes
+// меняют файл за несколько операций.
+const debounce = 200 * time.Millisecond
+
+// Handler применяет новые значения перезагружаемых настроек.
+type Handler func(cfg *config.Config) error
+
+// Watcher хранит примененную конфигурацию и перечитывает файл по запросу.
+type Watcher struct {
+	path     string
+	app      config.App
+	logger   logger.Logger
+	mu       sync.Mutex
+	current  *config.Config
+	checksum [sha256.Size]byte // Содержимое файла при последней попытке перезагрузки
+	handlers []Handler
+}
+
+// NewWatcher создает наблюдатель за файлом path, из которого загружена конфигурация current приложения app.
+func NewWatcher(path string, app config.App, current *config.Config, logger logger.Logger) *Watcher {
+	w := &Watcher{path: path, app: app, logger: logger, current: current}
+	if content, err := os.ReadFile(path); err == nil {
+		w.checksum = sha256.Sum256(content)
+	}
+	return w
+}
+
+// OnReload добавляет обработчик, который вызывается после каждой успешной перезагрузки.
+func (w *Watcher) OnReload(handler Handler) {
+	w.handlers = append(w.handlers, handler)
+}
+
+// Reload перечитывает и проверяет файл конфигурации и передает обработчикам перезагружаемые настройки.
+// При ошибке чтения или проверки продолжает действовать прежняя конфигурация.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reload(true)
+}
+
+// reload загружает конфигурацию. Если force не задан, файл с прежним содержимым не перечитывается,
+// в том числе если его уже не удалось применить: события в каталоге вызываются и другими файлами.
+func (w *Watcher) reload(force bool) error {
+	content, err := os.ReadFile(w.path)
+	if err != nil {
+		return fmt.Errorf("on reading config file: %w", err)
+	}
+	checksum := sha256.Sum256(content)
+	if !force && bytes.Equal(checksum[:], w.checksum[:]) {
+		return nil
+	}
+	w.checksum = checksum
+
+	next, err := config.LoadConfig(w.path)
+	if err != nil {
+		return err
+	}
+	if err := next.Validate(w.app); err != nil {
+		return fmt.Errorf("invalid config, keeping the current one: %w", err)
+	}
+
+	applied, restartRequired := w.current.Reloadable(next)
+	for _, handler := range w.handlers {
+		if err := handler(applied); err != nil {
+			return fmt.Errorf("on applying reloaded config: %w", err)
+		}
+	}
+	w.current = applied
+
+	w.logger.Info("config reloaded")
+	if len(restartRequired) > 0 {
+		w.logger.Infof("config changes require restart to take effect: %s", strings.Join(restartRequired, ", "))
+	}
+	return nil
+}
+
+// Run перезагружает конфигурацию по SIGHUP и при изменении файла до отмены ctx. Ошибки перезагрузки
+// записываются в лог и не останавливают приложение.
+func (w *Watcher) Run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// Следим за каталогом, а не за файлом: при атомарной замене файла наблюдение за ним теряется
+	var events <-chan fsnotify.Event
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		err = fsWatcher.Add(filepath.Dir(w.path))
+	}
+	if err != nil {
+		w.logger.Errorf("config file watching is disabled, use SIGHUP to reload: %v", err)
+	} else {
+		defer fsWatcher.Close()
+		events = fsWatcher.Events
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			w.logger.Info("SIGHUP received, reloading config")
+			if err := w.Reload(); err != nil {
+				w.logger.Errorf("on reloading config: %v", err)
+			}
+		case <-events:
+			timer.Reset(debounce)
+		case <-timer.C:
+			w.mu.Lock()
+			err := w.reload(false)
+			w.mu.Unlock()
+			if err != nil {
+				w.logger.Errorf("on reloading config: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseConfig = `
+database:
+  storage: memory
+logger:
+  level: %s
+  outputPaths: ["stdout"]
+httpserver:
+  address: %s
+`
+
+func newTestWatcher(t *testing.T) (*Watcher, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "info", "0.0.0.0:8080")
+	cfg, err := config.LoadConfig(path)
+	require.NoError(t, err)
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+	return NewWatcher(path, config.AppCalendar, cfg, log), path
+}
+
+func writeConfig(t *testing.T, path, level, address string) {
+	t.Helper()
+
+	content := []byte(fmt.Sprintf(baseConfig, level, address))
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	watcher, path := newTestWatcher(t)
+
+	var applied *config.Config
+	watcher.OnReload(func(cfg *config.Config) error {
+		applied = cfg
+		return nil
+	})
+
+	writeConfig(t, path, "debug", "0.0.0.0:8081")
+	require.NoError(t, watcher.Reload())
+	require.NotNil(t, applied)
+	assert.Equal(t, "debug", applied.Logger.Level)
+	// Адрес сервера меняется только после перезапуска
+	assert.Equal(t, "0.0.0.0:8080", applied.HTTPServer.Address)
+
+	// Ошибочная конфигурация не применяется
+	applied = nil
+	writeConfig(t, path, "verbose", "0.0.0.0:8081")
+	require.Error(t, watcher.Reload())
+	assert.Nil(t, applied)
+	assert.Equal(t, "debug", watcher.current.Logger.Level)
+}
+
+func TestWatcher_Run(t *testing.T) {
+	watcher, path := newTestWatcher(t)
+
+	var level atomic.Value
+	watcher.OnReload(func(cfg *config.Config) error {
+		level.Store(cfg.Logger.Level)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- watcher.Run(ctx) }()
+	// Наблюдение за каталогом начинается после запуска Run
+	time.Sleep(100 * time.Millisecond)
+
+	writeConfig(t, path, "warn", "0.0.0.0:8080")
+	assert.Eventually(t, func() bool { return level.Load() == "warn" }, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
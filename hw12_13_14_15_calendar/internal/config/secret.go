@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/secrets"
+	"github.com/spf13/viper"
+)
+
+// SecretsKeyEnv переменная окружения с ключом файла секретов в base64.
+const SecretsKeyEnv = "CALENDAR_SECRETS_KEY"
+
+const redacted = "[REDACTED]"
+
+// Secret значение настройки, которое не должно попадать в логи. При выводе через fmt и кодировании
+// в JSON заменяется на [REDACTED], само значение возвращает Value.
+type Secret string
+
+// Value возвращает значение секрета.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return fmt.Sprintf("%q", s.String())
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// secretKeys настройки-секреты. Для них кроме переменной окружения (DATABASE_PASSWORD) читается
+// переменная с путем к файлу значения (DATABASE_PASSWORD_FILE), как в секретах Docker и Kubernetes.
+var secretKeys = []string{
+	"database.password",
+	"database.replicaDSN",
+	"cache.password",
+	"rabbitmq.url",
+	"email.password",
+}
+
+// SecretsConfig файл секретов, зашифрованный ключом из переменной окружения CALENDAR_SECRETS_KEY.
+// Расшифрованный файл - YAML той же структуры, что и конфигурация, его значения заменяют значения
+// из файла конфигурации.
+type SecretsConfig struct {
+	File string // Путь к файлу, относительный путь отсчитывается от каталога файла конфигурации
+}
+
+// lookupEnv возвращает значение переменной окружения name. Если она не задана, значение читается
+// из файла, путь к которому задан в переменной name_FILE. Завершающий перевод строки отбрасывается.
+func lookupEnv(name string) (string, bool, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+	path, ok := os.LookupEnv(name + "_FILE")
+	if !ok || path == "" {
+		return "", false, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("on reading %s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// expandEnv подставляет в содержимое файла конфигурации переменные окружения с учетом name_FILE.
+func expandEnv(content string) (string, error) {
+	var errs []error
+	expanded := os.Expand(content, func(name string) string {
+		value, _, err := lookupEnv(name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return value
+	})
+	return expanded, errors.Join(errs...)
+}
+
+// applySecrets дополняет конфигурацию расшифрованным файлом секретов и значениями из файлов,
+// заданных переменными окружения *_FILE.
+func applySecrets(v *viper.Viper, configPath string) error {
+	if file := v.GetString("secrets.file"); file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(configPath), file)
+		}
+		plaintext, err := readSecretsFile(file)
+		if err != nil {
+			return err
+		}
+		if err := v.MergeConfig(strings.NewReader(string(plaintext))); err != nil {
+			return fmt.Errorf("failed to read secrets file: %w", err)
+		}
+	}
+
+	for _, key := range secretKeys {
+		env := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		// Обычная переменная окружения применяется viper сама
+		if _, ok := os.LookupEnv(env); ok {
+			continue
+		}
+		value, ok, err := lookupEnv(env)
+		if err != nil {
+			return err
+		}
+		if ok {
+			v.Set(key, value)
+		}
+	}
+	return nil
+}
+
+// SecretsKey возвращает ключ файла секретов из переменной окружения CALENDAR_SECRETS_KEY
+// или из файла, заданного в CALENDAR_SECRETS_KEY_FILE.
+func SecretsKey() ([]byte, error) {
+	encodedKey, ok, err := lookupEnv(SecretsKeyEnv)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s is not set", SecretsKeyEnv)
+	}
+	key, err := secrets.ParseKey(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", SecretsKeyEnv, err)
+	}
+	return key, nil
+}
+
+// readSecretsFile читает и расшифровывает файл секретов ключом из CALENDAR_SECRETS_KEY.
+func readSecretsFile(path string) ([]byte, error) {
+	key, err := SecretsKey()
+	if err != nil {
+		return nil, fmt.Errorf("on reading secrets file key: %w", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	plaintext, err := secrets.Decrypt(content, key)
+	if err != nil {
+		return nil, fmt.Errorf("secrets file %s: %w", path, err)
+	}
+	return plaintext, nil
+}
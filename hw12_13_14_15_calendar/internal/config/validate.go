@@ -0,0 +1,275 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"net/url"
+	"path"
+	"strconv"
+	"text/template"
+)
+
+// App приложение, для которого проверяется конфигурация. Файл конфигурации общий, но каждое
+// приложение использует только свои разделы, и проверяются только они.
+type App string
+
+const (
+	AppCalendar  App = "calendar"
+	AppScheduler App = "scheduler"
+	AppSender    App = "sender"
+)
+
+// FieldError ошибка значения настройки. Field - путь настройки в файле конфигурации.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// validator собирает ошибки всех настроек, чтобы их можно было исправить за один раз.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) fail(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) positive(field string, value int) {
+	if value <= 0 {
+		v.fail(field, "must be greater than 0, got %d", value)
+	}
+}
+
+func (v *validator) nonNegative(field string, value int) {
+	if value < 0 {
+		v.fail(field, "must not be negative, got %d", value)
+	}
+}
+
+func (v *validator) required(field, value string) {
+	if value == "" {
+		v.fail(field, "must not be empty")
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.fail(field, "must be one of %q, got %q", allowed, value)
+}
+
+func (v *validator) address(field, value string) {
+	if value == "" {
+		v.fail(field, "must not be empty")
+		return
+	}
+	_, port, err := net.SplitHostPort(value)
+	if err != nil {
+		v.fail(field, "must be host:port, got %q", value)
+		return
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		v.fail(field, "invalid port in %q", value)
+	}
+}
+
+func (v *validator) port(field string, value int) {
+	if value < 1 || value > 65535 {
+		v.fail(field, "must be a port number between 1 and 65535, got %d", value)
+	}
+}
+
+func (v *validator) template(field, value string) {
+	if _, err := template.New(field).Parse(value); err != nil {
+		v.fail(field, "invalid template: %s", err)
+	}
+}
+
+// Validate проверяет настройки, которые использует приложение app, и возвращает все найденные
+// ошибки, объединенные errors.Join. Каждая ошибка - *FieldError.
+func (c *Config) Validate(app App) error {
+	v := &validator{}
+
+	c.Logger.validate(v)
+	c.Shutdown.validate(v)
+	switch app {
+	case AppCalendar:
+		c.Database.validate(v)
+		c.HTTPServer.validate(v)
+		c.GRPCServer.validate(v)
+		c.Cache.validate(v)
+		c.RateLimit.validate(v)
+	case AppScheduler:
+		c.Database.validate(v)
+		c.RabbitMQ.validate(v)
+		v.positive("scheduler.interval", c.Scheduler.Interval)
+		if c.Scheduler.HealthAddress != "" {
+			v.address("scheduler.healthAddress", c.Scheduler.HealthAddress)
+		}
+	case AppSender:
+		c.Database.validate(v)
+		c.RabbitMQ.validate(v)
+		c.Email.validate(v)
+		if c.Sender.HealthAddress != "" {
+			v.address("sender.healthAddress", c.Sender.HealthAddress)
+		}
+	default:
+		v.fail("app", "unknown application %q", app)
+	}
+
+	return errors.Join(v.errs...)
+}
+
+func (c LoggerConfig) validate(v *validator) {
+	v.oneOf("logger.level", c.Level, "debug", "info", "warn", "error", "fatal")
+	v.oneOf("logger.encoding", c.Encoding, "json", "console")
+	if len(c.OutputPaths) == 0 {
+		v.fail("logger.outputPaths", "must not be empty")
+	}
+}
+
+func (c ShutdownConfig) validate(v *validator) {
+	v.positive("shutdown.timeout", c.Timeout)
+	v.nonNegative("shutdown.drainDelay", c.DrainDelay)
+}
+
+func (c DatabaseConfig) validate(v *validator) {
+	switch c.Storage {
+	case "sql":
+		v.required("database.host", c.Host)
+		v.port("database.port", c.Port)
+		v.required("database.name", c.Name)
+		v.required("database.user", c.User)
+		if c.SSLMode != "" {
+			v.oneOf("database.sslMode", c.SSLMode, "disable", "require", "verify-ca", "verify-full")
+		}
+		v.nonNegative("database.statementTimeout", c.StatementTimeout)
+		v.nonNegative("database.pool.maxOpenConns", c.Pool.MaxOpenConns)
+		v.nonNegative("database.pool.maxIdleConns", c.Pool.MaxIdleConns)
+		v.nonNegative("database.pool.connMaxLifetime", c.Pool.ConnMaxLifetime)
+		v.nonNegative("database.pool.connMaxIdleTime", c.Pool.ConnMaxIdleTime)
+		v.nonNegative("database.pool.connectRetries", c.Pool.ConnectRetries)
+		v.nonNegative("database.pool.connectRetryInterval", c.Pool.ConnectRetryInterval)
+	case "sqlite":
+		v.required("database.path", c.Path)
+	case "memory":
+		if c.Memory.Dir == "" {
+			return
+		}
+		v.oneOf("database.memory.fsync", c.Memory.Fsync, "always", "interval", "never")
+		if c.Memory.Fsync == "interval" {
+			v.positive("database.memory.fsyncInterval", c.Memory.FsyncInterval)
+		}
+		v.positive("database.memory.snapshotInterval", c.Memory.SnapshotInterval)
+	default:
+		v.oneOf("database.storage", c.Storage, "sql", "sqlite", "memory")
+	}
+}
+
+func (c HTTPServerConfig) validate(v *validator) {
+	v.address("httpserver.address", c.Address)
+	c.TLS.validate(v, "httpserver.tls")
+}
+
+func (c GRPCServerConfig) validate(v *validator) {
+	v.address("grpcserver.address", c.Address)
+	v.nonNegative("grpcserver.requestTimeout", c.RequestTimeout)
+	v.nonNegative("grpcserver.streamTimeout", c.StreamTimeout)
+	c.TLS.validate(v, "grpcserver.tls")
+}
+
+func (c TLSConfig) validate(v *validator, prefix string) {
+	if !c.Enabled {
+		return
+	}
+	v.required(prefix+".certFile", c.CertFile)
+	v.required(prefix+".keyFile", c.KeyFile)
+	v.oneOf(prefix+".clientAuth", c.ClientAuth, "none", "optional", "require")
+	if c.ClientAuth == "optional" || c.ClientAuth == "require" {
+		v.required(prefix+".clientCAFile", c.ClientCAFile)
+	}
+}
+
+func (c CacheConfig) validate(v *validator) {
+	switch c.Backend {
+	case "":
+		return
+	case "lru":
+		v.positive("cache.size", c.Size)
+	case "redis":
+		v.address("cache.address", c.Address)
+	default:
+		v.oneOf("cache.backend", c.Backend, "", "lru", "redis")
+		return
+	}
+	v.positive("cache.ttl", c.TTL)
+}
+
+func (c RateLimitConfig) validate(v *validator) {
+	if !c.Enabled {
+		return
+	}
+	if c.Rate <= 0 {
+		v.fail("rateLimit.rate", "must be greater than 0, got %g", c.Rate)
+	}
+	v.positive("rateLimit.burst", c.Burst)
+	for i, proxy := range c.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			v.fail(fmt.Sprintf("rateLimit.trustedProxies[%d]", i), "must be a CIDR prefix, got %q", proxy)
+		}
+	}
+	for i, route := range c.Routes {
+		field := fmt.Sprintf("rateLimit.routes[%d]", i)
+		if _, err := path.Match(route.Pattern, ""); err != nil || route.Pattern == "" {
+			v.fail(field+".pattern", "invalid pattern %q", route.Pattern)
+		}
+		if route.Rate < 0 {
+			v.fail(field+".rate", "must not be negative, got %g", route.Rate)
+		}
+		if route.Rate > 0 {
+			v.positive(field+".burst", route.Burst)
+		}
+	}
+}
+
+func (c RabbitMQConfig) validate(v *validator) {
+	u, err := url.Parse(c.URL.Value())
+	if err != nil || (u.Scheme != "amqp" && u.Scheme != "amqps") || u.Hostname() == "" {
+		// URL может содержать пароль, поэтому в сообщение он не попадает
+		v.fail("rabbitmq.url", "must be an amqp:// or amqps:// URL with a host")
+	}
+	v.required("rabbitmq.queueName", c.QueueName)
+}
+
+func (c EmailConfig) validate(v *validator) {
+	v.required("email.smtpServer", c.SMTPServer)
+	v.port("email.smtpPort", c.SMTPPort)
+	v.required("email.from", c.From)
+	v.template("email.subjectTemplate", c.SubjectTemplate)
+	v.template("email.bodyTemplate", c.BodyTemplate)
+}
+
+// Check загружает файл конфигурации path, проверяет настройки приложения app и печатает в w
+// результат проверки: OK или список ошибок по одной на строку.
+func Check(w io.Writer, path string, app App) error {
+	cfg, err := LoadConfig(path)
+	if err == nil {
+		err = cfg.Validate(app)
+	}
+	if err != nil {
+		fmt.Fprintf(w, "config %s is invalid:\n%s\n", path, err)
+		return err
+	}
+	fmt.Fprintf(w, "config %s is OK\n", path)
+	return nil
+}
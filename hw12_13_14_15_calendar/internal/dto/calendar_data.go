@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type CalendarData struct {
+	ID                     uuid.UUID `json:"id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	UserID                 uuid.UUID `json:"userId" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Name                   string    `json:"name" example:"Работа"`
+	Color                  string    `json:"color" example:"#3366ff"`
+	DefaultReminderMinutes int       `json:"defaultReminderMinutes" example:"15"`
+	Timezone               string    `json:"timezone" example:"Europe/Moscow"`
+}
+
+func ToStorageCalendar(data CalendarData) storage.Calendar {
+	return storage.Calendar{
+		ID:              data.ID,
+		UserID:          data.UserID,
+		Name:            data.Name,
+		Color:           data.Color,
+		DefaultReminder: time.Duration(data.DefaultReminderMinutes) * time.Minute,
+		Timezone:        data.Timezone,
+	}
+}
+
+func FromStorageCalendar(calendar storage.Calendar) CalendarData {
+	return CalendarData{
+		ID:                     calendar.ID,
+		UserID:                 calendar.UserID,
+		Name:                   calendar.Name,
+		Color:                  calendar.Color,
+		DefaultReminderMinutes: int(calendar.DefaultReminder / time.Minute),
+		Timezone:               calendar.Timezone,
+	}
+}
+
+func ToAPICalendar(calendar CalendarData) *api.Calendar {
+	return &api.Calendar{
+		Id:                     calendar.ID.String(),
+		UserId:                 calendar.UserID.String(),
+		Name:                   calendar.Name,
+		Color:                  calendar.Color,
+		DefaultReminderMinutes: int32(calendar.DefaultReminderMinutes), //nolint:gosec
+		Timezone:               calendar.Timezone,
+	}
+}
+
+// FromAPICalendar разбирает календарь API. Некорректный идентификатор возвращается как ошибка.
+func FromAPICalendar(calendar *api.Calendar) (CalendarData, error) {
+	id, err := uuid.Parse(calendar.GetId())
+	if err != nil {
+		return CalendarData{}, fmt.Errorf("invalid id: %w", err)
+	}
+	userID, err := uuid.Parse(calendar.GetUserId())
+	if err != nil {
+		return CalendarData{}, fmt.Errorf("invalid user_id: %w", err)
+	}
+	return CalendarData{
+		ID:                     id,
+		UserID:                 userID,
+		Name:                   calendar.GetName(),
+		Color:                  calendar.GetColor(),
+		DefaultReminderMinutes: int(calendar.GetDefaultReminderMinutes()),
+		Timezone:               calendar.GetTimezone(),
+	}, nil
+}
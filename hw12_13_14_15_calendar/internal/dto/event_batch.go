@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+)
+
+const (
+	EventOperationCreate = "create"
+	EventOperationUpdate = "update"
+	EventOperationDelete = "delete"
+)
+
+const (
+	// OperationOK операция выполнена и зафиксирована вместе с остальными.
+	OperationOK = "ok"
+	// OperationFailed операция завершилась ошибкой, из-за которой пакет не применен.
+	OperationFailed = "failed"
+	// OperationAborted операция не применена из-за ошибки другой операции пакета.
+	OperationAborted = "aborted"
+)
+
+type EventOperation struct {
+	Op string `json:"op" example:"create, update, delete"`
+	// ID обновляемого или удаляемого события
+	ID    uuid.UUID `json:"id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Event EventData `json:"event"`
+}
+
+type EventBatchRequest struct {
+	Operations []EventOperation `json:"operations"`
+}
+
+type EventOperationResult struct {
+	ID     uuid.UUID `json:"id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Status string    `json:"status" example:"ok, failed, aborted"`
+	Error  string    `json:"error,omitempty"`
+}
+
+type EventBatchResult struct {
+	// Applied показывает, что все операции пакета зафиксированы
+	Applied bool                   `json:"applied"`
+	Results []EventOperationResult `json:"results"`
+}
+
+func ToAPIEventBatchResult(result EventBatchResult) *api.BatchEventsResponse {
+	results := make([]*api.EventOperationResult, len(result.Results))
+	for i, item := range result.Results {
+		results[i] = &api.EventOperationResult{
+			Id:     optionalIDToAPI(item.ID),
+			Status: item.Status,
+			Error:  item.Error,
+		}
+	}
+	return &api.BatchEventsResponse{Applied: result.Applied, Results: results}
+}
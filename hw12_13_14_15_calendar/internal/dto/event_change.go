@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type EventChangeData struct {
+	Type  string    `json:"type" example:"created, updated, deleted"`
+	Event EventData `json:"event"`
+}
+
+func FromStorageEventChange(change storage.EventChange) EventChangeData {
+	return EventChangeData{
+		Type:  string(change.Type),
+		Event: FromStorageEvent(change.Event),
+	}
+}
+
+func ToAPIEventChange(change EventChangeData) *api.EventChange {
+	changeTypes := map[string]api.ChangeType{
+		string(storage.ChangeCreated): api.ChangeType_CHANGE_TYPE_CREATED,
+		string(storage.ChangeUpdated): api.ChangeType_CHANGE_TYPE_UPDATED,
+		string(storage.ChangeDeleted): api.ChangeType_CHANGE_TYPE_DELETED,
+	}
+	return &api.EventChange{
+		Type:  changeTypes[change.Type],
+		Event: ToAPIEvent(change.Event),
+	}
+}
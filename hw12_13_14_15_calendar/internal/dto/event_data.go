@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +17,10 @@ type EventData struct {
 	StartTime   time.Time `json:"startTime" example:"2024-07-02T00:00:00Z"`
 	EndTime     time.Time `json:"endTime" example:"2024-07-02T00:00:00Z"`
 	UserID      uuid.UUID `json:"userId" example:"123e4567-e89b-12d3-a456-426614174000"`
+	CalendarID  uuid.UUID `json:"calendarId,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Attendees   []string  `json:"attendees,omitempty" example:"alice@example.com"`
+	Version     int64     `json:"version,omitempty" example:"42"`
+	UpdatedAt   time.Time `json:"updatedAt,omitempty" example:"2024-07-02T00:00:00Z"`
 }
 
 func ToStorageEvent(data EventData) storage.Event {
@@ -26,6 +31,9 @@ func ToStorageEvent(data EventData) storage.Event {
 		StartTime:   data.StartTime,
 		EndTime:     data.EndTime,
 		UserID:      data.UserID,
+		CalendarID:  data.CalendarID,
+		Attendees:   data.Attendees,
+		Version:     data.Version,
 	}
 }
 
@@ -37,6 +45,10 @@ func FromStorageEvent(event storage.Event) EventData {
 		StartTime:   event.StartTime,
 		EndTime:     event.EndTime,
 		UserID:      event.UserID,
+		CalendarID:  event.CalendarID,
+		Attendees:   event.Attendees,
+		Version:     event.Version,
+		UpdatedAt:   event.UpdatedAt,
 	}
 }
 
@@ -48,16 +60,57 @@ func ToAPIEvent(event EventData) *api.Event {
 		StartTime:   timestamppb.New(event.StartTime),
 		EndTime:     timestamppb.New(event.EndTime),
 		UserId:      event.UserID.String(),
+		CalendarId:  optionalIDToAPI(event.CalendarID),
+		Attendees:   event.Attendees,
+		Version:     event.Version,
+		UpdatedAt:   timestamppb.New(event.UpdatedAt),
 	}
 }
 
-func FromAPIEvent(event *api.Event) EventData {
+// FromAPIEvent разбирает событие API. Некорректный идентификатор возвращается как ошибка.
+func FromAPIEvent(event *api.Event) (EventData, error) {
+	id, err := uuid.Parse(event.GetId())
+	if err != nil {
+		return EventData{}, fmt.Errorf("invalid id: %w", err)
+	}
+	userID, err := uuid.Parse(event.GetUserId())
+	if err != nil {
+		return EventData{}, fmt.Errorf("invalid user_id: %w", err)
+	}
+	calendarID, err := CalendarIDFromAPI(event.GetCalendarId())
+	if err != nil {
+		return EventData{}, err
+	}
 	return EventData{
-		ID:          uuid.MustParse(event.GetId()),
+		ID:          id,
 		Title:       event.GetTitle(),
 		Description: event.GetDescription(),
 		StartTime:   event.GetStartTime().AsTime(),
 		EndTime:     event.GetEndTime().AsTime(),
-		UserID:      uuid.MustParse(event.GetUserId()),
+		UserID:      userID,
+		CalendarID:  calendarID,
+		Attendees:   event.GetAttendees(),
+		Version:     event.GetVersion(),
+		UpdatedAt:   event.GetUpdatedAt().AsTime(),
+	}, nil
+}
+
+// CalendarIDFromAPI разбирает необязательный идентификатор календаря: пустая строка означает uuid.Nil.
+func CalendarIDFromAPI(id string) (uuid.UUID, error) {
+	if id == "" {
+		return uuid.Nil, nil
+	}
+	calendarID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid calendar_id: %w", err)
+	}
+	return calendarID, nil
+}
+
+// optionalIDToAPI возвращает пустую строку для uuid.Nil.
+func optionalIDToAPI(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
 	}
+	return id.String()
 }
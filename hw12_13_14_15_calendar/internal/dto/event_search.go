@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type EventSearchData struct {
+	Query      string
+	UserID     uuid.UUID
+	CalendarID uuid.UUID
+	Start      time.Time
+	End        time.Time
+	Attendee   string
+	Limit      int
+}
+
+func ToStorageEventFilter(data EventSearchData) storage.EventFilter {
+	return storage.EventFilter{
+		Query:      data.Query,
+		UserID:     data.UserID,
+		CalendarID: data.CalendarID,
+		Start:      data.Start,
+		End:        data.End,
+		Attendee:   data.Attendee,
+		Limit:      data.Limit,
+	}
+}
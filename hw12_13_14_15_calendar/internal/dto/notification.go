@@ -22,6 +22,7 @@ type NotificationData struct {
 	Time    time.Time `json:"time" example:"2024-07-02T00:00:00Z"`
 	Message string    `json:"message" example:"Notification message"`
 	Sent    string    `json:"sent" example:"wait, on-queue, sent"`
+	Version int64     `json:"version,omitempty" example:"1"`
 }
 
 func ToStorageNotification(data NotificationData) storage.Notification {
@@ -32,6 +33,7 @@ func ToStorageNotification(data NotificationData) storage.Notification {
 		Time:    data.Time,
 		Message: data.Message,
 		Sent:    data.Sent,
+		Version: data.Version,
 	}
 }
 
@@ -43,6 +45,7 @@ func FromStorageNotification(notification storage.Notification) NotificationData
 		Time:    notification.Time,
 		Message: notification.Message,
 		Sent:    notification.Sent,
+		Version: notification.Version,
 	}
 }
 
@@ -54,6 +57,7 @@ func ToAPINotification(notification NotificationData) *api.Notification {
 		Time:    timestamppb.New(notification.Time),
 		Message: notification.Message,
 		Sent:    notification.Sent,
+		Version: notification.Version,
 	}
 }
 
@@ -65,5 +69,6 @@ func FromAPINotification(notification *api.Notification) NotificationData {
 		Time:    notification.GetTime().AsTime(),
 		Message: notification.GetMessage(),
 		Sent:    notification.GetSent(),
+		Version: notification.GetVersion(),
 	}
 }
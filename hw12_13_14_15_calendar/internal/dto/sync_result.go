@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+)
+
+type SyncResult struct {
+	Events    []EventData `json:"events"`
+	Deleted   []uuid.UUID `json:"deleted" example:"123e4567-e89b-12d3-a456-426614174000"`
+	NextToken string      `json:"nextSyncToken" example:"djE6NDI"`
+	HasMore   bool        `json:"hasMore" example:"false"`
+}
+
+func ToAPISyncResult(result SyncResult) *api.SyncEventsResponse {
+	events := make([]*api.Event, len(result.Events))
+	for i, event := range result.Events {
+		events[i] = ToAPIEvent(event)
+	}
+	deleted := make([]string, len(result.Deleted))
+	for i, id := range result.Deleted {
+		deleted[i] = id.String()
+	}
+	return &api.SyncEventsResponse{
+		Events:        events,
+		DeletedIds:    deleted,
+		NextSyncToken: result.NextToken,
+		HasMore:       result.HasMore,
+	}
+}
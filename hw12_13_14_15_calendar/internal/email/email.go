@@ -24,7 +24,7 @@ func NewSMTPClient(config *config.EmailConfig) Client {
 }
 
 func (c *smtpClient) SendEmail(to string, subject string, body string) error {
-	auth := smtp.CRAMMD5Auth(c.config.Username, c.config.Password)
+	auth := smtp.CRAMMD5Auth(c.config.Username, c.config.Password.Value())
 	msg := []byte("From: " + c.config.From + "\r\n" +
 		"To: " + to + "\r\n" +
 		"Subject: " + subject + "\r\n" +
@@ -0,0 +1,45 @@
+package email
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+)
+
+// Templates шаблоны темы и текста письма с уведомлением.
+type Templates struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// ParseTemplates разбирает шаблоны из конфигурации. Данные шаблонов - dto.NotificationData.
+func ParseTemplates(cfg config.EmailConfig) (*Templates, error) {
+	subject, err := template.New("subject").Parse(cfg.SubjectTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("on parsing email subject template: %w", err)
+	}
+	body, err := template.New("body").Parse(cfg.BodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("on parsing email body template: %w", err)
+	}
+	return &Templates{subject: subject, body: body}, nil
+}
+
+// Render возвращает тему и текст письма для уведомления.
+func (t *Templates) Render(notification dto.NotificationData) (subject string, body string, err error) {
+	var sb strings.Builder
+	if err := t.subject.Execute(&sb, notification); err != nil {
+		return "", "", fmt.Errorf("on rendering email subject: %w", err)
+	}
+	// Перевод строки в теме разорвал бы заголовок письма
+	subject = strings.Join(strings.Fields(sb.String()), " ")
+
+	sb.Reset()
+	if err := t.body.Execute(&sb, notification); err != nil {
+		return "", "", fmt.Errorf("on rendering email body: %w", err)
+	}
+	return subject, sb.String(), nil
+}
@@ -0,0 +1,33 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates(t *testing.T) {
+	notification := dto.NotificationData{Message: "Встреча в 10:00"}
+
+	templates, err := ParseTemplates(config.EmailConfig{
+		SubjectTemplate: "Напоминание:\n{{.Message}}",
+		BodyTemplate:    "Здравствуйте!\n\n{{.Message}}",
+	})
+	require.NoError(t, err)
+
+	subject, body, err := templates.Render(notification)
+	require.NoError(t, err)
+	assert.Equal(t, "Напоминание: Встреча в 10:00", subject)
+	assert.Equal(t, "Здравствуйте!\n\nВстреча в 10:00", body)
+
+	_, err = ParseTemplates(config.EmailConfig{SubjectTemplate: "{{.ID", BodyTemplate: "{{.Message}}"})
+	assert.Error(t, err)
+
+	templates, err = ParseTemplates(config.EmailConfig{SubjectTemplate: "{{.Unknown}}", BodyTemplate: ""})
+	require.NoError(t, err)
+	_, _, err = templates.Render(notification)
+	assert.Error(t, err)
+}
@@ -0,0 +1,279 @@
+// Package ics кодирует события календаря в формат iCalendar (RFC 5545) и разбирает их обратно.
+// Поддерживается подмножество VEVENT, которое хранит календарь: UID, SUMMARY, DESCRIPTION, DTSTART,
+// DTEND и ATTENDEE.
+package ics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+)
+
+const (
+	prodID = "-//otus-go//calendar//RU"
+
+	utcLayout      = "20060102T150405Z"
+	localLayout    = "20060102T150405"
+	dateLayout     = "20060102"
+	maxLineOctets  = 75
+	mailtoPrefix   = "mailto:"
+	uidDomainDelim = "@"
+)
+
+var (
+	ErrNoCalendar    = errors.New("VCALENDAR not found")
+	ErrUnterminated  = errors.New("unterminated component")
+	ErrMissingStart  = errors.New("DTSTART is required")
+	ErrMalformedLine = errors.New("malformed content line")
+)
+
+// Encode записывает события в w одним VCALENDAR. Время выводится в UTC.
+func Encode(w io.Writer, events []dto.EventData) error {
+	bw := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format(utcLayout)
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:"+prodID)
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	for _, event := range events {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+escapeText(event.ID.String()))
+		writeLine(bw, "DTSTAMP:"+stamp)
+		writeLine(bw, "DTSTART:"+event.StartTime.UTC().Format(utcLayout))
+		writeLine(bw, "DTEND:"+event.EndTime.UTC().Format(utcLayout))
+		writeLine(bw, "SUMMARY:"+escapeText(event.Title))
+		if event.Description != "" {
+			writeLine(bw, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		for _, attendee := range event.Attendees {
+			writeLine(bw, "ATTENDEE:"+mailtoPrefix+attendee)
+		}
+		writeLine(bw, "END:VEVENT")
+	}
+	writeLine(bw, "END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+// Decode читает события из VCALENDAR. Неизвестные свойства и вложенные компоненты (VALARM, VTIMEZONE)
+// пропускаются. UID переносится в ID, если это UUID; иначе ID остается пустым.
+// Событие без DTEND длится сутки, если DTSTART задан датой, и нулевое время в остальных случаях.
+func Decode(r io.Reader) ([]dto.EventData, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events     []dto.EventData
+		current    *dto.EventData
+		hasEnd     bool
+		allDay     bool
+		inCalendar bool
+		seen       bool
+		depth      int
+	)
+	for n, line := range lines {
+		if line == "" {
+			continue
+		}
+		name, params, value, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d, %w", n+1, err)
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			inCalendar, seen = true, true
+			continue
+		case name == "END" && value == "VCALENDAR":
+			inCalendar = false
+			continue
+		case !inCalendar:
+			continue
+		case name == "BEGIN" && value == "VEVENT" && current == nil:
+			current, hasEnd, allDay = &dto.EventData{}, false, false
+			continue
+		case name == "BEGIN":
+			depth++
+			continue
+		case name == "END" && depth > 0:
+			depth--
+			continue
+		case name == "END" && value == "VEVENT" && current != nil:
+			if current.StartTime.IsZero() {
+				return nil, fmt.Errorf("line %d, %w", n+1, ErrMissingStart)
+			}
+			if !hasEnd {
+				current.EndTime = current.StartTime
+				if allDay {
+					current.EndTime = current.StartTime.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *current)
+			current = nil
+			continue
+		case current == nil || depth > 0:
+			continue
+		}
+
+		switch name {
+		case "UID":
+			uid := unescapeText(value)
+			if id, err := uuid.Parse(strings.SplitN(uid, uidDomainDelim, 2)[0]); err == nil {
+				current.ID = id
+			}
+		case "SUMMARY":
+			current.Title = unescapeText(value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(value)
+		case "DTSTART":
+			if current.StartTime, allDay, err = parseTime(value, params); err != nil {
+				return nil, fmt.Errorf("line %d, DTSTART, %w", n+1, err)
+			}
+		case "DTEND":
+			if current.EndTime, _, err = parseTime(value, params); err != nil {
+				return nil, fmt.Errorf("line %d, DTEND, %w", n+1, err)
+			}
+			hasEnd = true
+		case "ATTENDEE":
+			if len(value) >= len(mailtoPrefix) && strings.EqualFold(value[:len(mailtoPrefix)], mailtoPrefix) {
+				value = value[len(mailtoPrefix):]
+			}
+			current.Attendees = append(current.Attendees, value)
+		}
+	}
+	if current != nil || depth > 0 || inCalendar {
+		return nil, ErrUnterminated
+	}
+	if !seen {
+		return nil, ErrNoCalendar
+	}
+
+	return events, nil
+}
+
+// unfold читает строки содержимого и склеивает перенесенные: продолжение начинается с пробела или табуляции.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("on reading calendar, %w", err)
+	}
+	return lines, nil
+}
+
+// parseLine разбирает строку "NAME;PARAM=VALUE:value". Двоеточие внутри кавычек параметра не считается
+// разделителем.
+func parseLine(line string) (name string, params map[string]string, value string, err error) {
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return "", nil, "", ErrMalformedLine
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	name = strings.ToUpper(parts[0])
+	params = make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+	value = line[colon+1:]
+	if name == "BEGIN" || name == "END" {
+		value = strings.ToUpper(value)
+	}
+	return name, params, value, nil
+}
+
+// parseTime разбирает DATE или DATE-TIME: в UTC, с TZID или плавающее локальное время.
+func parseTime(value string, params map[string]string) (t time.Time, allDay bool, err error) {
+	if params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		t, err = time.ParseInLocation(dateLayout, value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse(utcLayout, value)
+		return t, false, err
+	}
+
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, false, err
+		}
+	}
+	t, err = time.ParseInLocation(localLayout, value, loc)
+	return t, false, err
+}
+
+// escapeText экранирует значение типа TEXT.
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// unescapeText снимает экранирование значения типа TEXT.
+func unescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// writeLine пишет строку с CRLF, перенося ее по 75 октетов без разрыва символов UTF-8.
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// Строка продолжения начинается с пробела, который тоже занимает октет.
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,115 @@
+package ics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	events := []dto.EventData{
+		{
+			ID:          uuid.New(),
+			Title:       "Встреча; план, итоги",
+			Description: "Первая строка\nвторая строка с \\ обратной косой чертой и " + strings.Repeat("длинным текстом ", 10),
+			StartTime:   time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC),
+			EndTime:     time.Date(2024, 7, 1, 11, 30, 0, 0, time.UTC),
+			Attendees:   []string{"alice@example.com", "bob@example.com"},
+		},
+		{
+			ID:        uuid.New(),
+			Title:     "Без описания",
+			StartTime: time.Date(2024, 7, 2, 8, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2024, 7, 2, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, events))
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets, line)
+	}
+
+	decoded, err := Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, decoded, len(events))
+	for i, event := range events {
+		assert.Equal(t, event.ID, decoded[i].ID)
+		assert.Equal(t, event.Title, decoded[i].Title)
+		assert.Equal(t, event.Description, decoded[i].Description)
+		assert.True(t, event.StartTime.Equal(decoded[i].StartTime))
+		assert.True(t, event.EndTime.Equal(decoded[i].EndTime))
+		assert.Equal(t, event.Attendees, decoded[i].Attendees)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Moscow",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:external-id@example.com",
+		"SUMMARY:Созвон",
+		"DTSTART;TZID=Europe/Moscow:20240701T100000",
+		"DTEND;TZID=Europe/Moscow:20240701T110000",
+		`ATTENDEE;CN="Alice: team lead":MAILTO:alice@example.com`,
+		"BEGIN:VALARM",
+		"SUMMARY:Напоминание",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Отпуск",
+		"DTSTART;VALUE=DATE:20240710",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\n")
+
+	events, err := Decode(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, uuid.Nil, events[0].ID)
+	assert.Equal(t, "Созвон", events[0].Title)
+	assert.True(t, time.Date(2024, 7, 1, 10, 0, 0, 0, moscow).Equal(events[0].StartTime))
+	assert.True(t, time.Date(2024, 7, 1, 11, 0, 0, 0, moscow).Equal(events[0].EndTime))
+	assert.Equal(t, []string{"alice@example.com"}, events[0].Attendees)
+
+	assert.Equal(t, "Отпуск", events[1].Title)
+	assert.Equal(t, 24*time.Hour, events[1].EndTime.Sub(events[1].StartTime))
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{name: "empty", input: "", err: ErrNoCalendar},
+		{name: "unterminated", input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20240701T100000Z\n", err: ErrUnterminated},
+		{
+			name:  "no start",
+			input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\nEND:VCALENDAR",
+			err:   ErrMissingStart,
+		},
+		{name: "malformed", input: "BEGIN:VCALENDAR\nnot a content line\nEND:VCALENDAR", err: ErrMalformedLine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.input))
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+// Component часть приложения с собственным жизненным циклом. Любая из функций может быть не задана.
+type Component struct {
+	Name string
+	// Start подготавливает компонент и возвращает управление, ошибка прерывает запуск приложения
+	Start func(ctx context.Context) error
+	// Run работает до остановки компонента. Завершение Run раньше остановки считается отказом
+	// и останавливает приложение
+	Run func(ctx context.Context) error
+	// Drain вызывается, когда приложение перестает быть готовым, до остановки компонентов
+	Drain func()
+	// Stop освобождает ресурсы компонента, ctx ограничен общим дедлайном остановки
+	Stop func(ctx context.Context) error
+}
+
+// Manager запускает компоненты в порядке добавления и останавливает их в обратном порядке.
+type Manager struct {
+	config     config.ShutdownConfig
+	logger     logger.Logger
+	components []Component
+	ready      atomic.Bool
+}
+
+func NewManager(cfg config.ShutdownConfig, logger logger.Logger) *Manager {
+	return &Manager{config: cfg, logger: logger}
+}
+
+func (m *Manager) Add(components ...Component) {
+	m.components = append(m.components, components...)
+}
+
+// Ready сообщает, что все компоненты запущены и приложение еще не начало останавливаться.
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// running запущенный компонент.
+type running struct {
+	Component
+	cancel   context.CancelFunc
+	stopping atomic.Bool
+	done     chan struct{}
+}
+
+// Run запускает компоненты и ждет отмены ctx или отказа одного из них, после чего останавливает
+// запущенные компоненты. Возвращает ошибку запуска или отказа вместе с ошибками остановки.
+func (m *Manager) Run(ctx context.Context) error {
+	failed := make(chan error, len(m.components))
+	started := make([]*running, 0, len(m.components))
+
+	var err error
+	for _, component := range m.components {
+		if component.Start != nil {
+			if err = component.Start(ctx); err != nil {
+				err = fmt.Errorf("on starting %s: %w", component.Name, err)
+				break
+			}
+		}
+		started = append(started, m.run(ctx, component, failed))
+	}
+
+	if err == nil {
+		m.ready.Store(true)
+		m.logger.Info("application is ready")
+
+		select {
+		case <-ctx.Done():
+			m.logger.Info("shutdown requested")
+		case err = <-failed:
+			m.logger.Errorf("shutting down after failure: %v", err)
+		}
+	}
+
+	return errors.Join(err, m.shutdown(started))
+}
+
+func (m *Manager) run(ctx context.Context, component Component, failed chan<- error) *running {
+	r := &running{Component: component, cancel: func() {}, done: make(chan struct{})}
+	if component.Run == nil {
+		close(r.done)
+		return r
+	}
+
+	// Компонент останавливается менеджером в своей очереди, а не вместе с ctx
+	var runCtx context.Context
+	runCtx, r.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		defer close(r.done)
+		err := component.Run(runCtx)
+		if r.stopping.Load() {
+			return
+		}
+		if err == nil {
+			err = errors.New("stopped unexpectedly")
+		}
+		failed <- fmt.Errorf("%s: %w", component.Name, err)
+	}()
+	return r
+}
+
+// shutdown снимает готовность, дает балансировщикам время заметить это и останавливает компоненты
+// в обратном порядке в пределах общего дедлайна.
+func (m *Manager) shutdown(started []*running) error {
+	if m.ready.Swap(false) {
+		for _, r := range started {
+			if r.Drain != nil {
+				r.Drain()
+			}
+		}
+		if delay := time.Duration(m.config.DrainDelay) * time.Second; delay > 0 {
+			m.logger.Infof("draining for %s", delay)
+			time.Sleep(delay)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(m.config.Timeout)*time.Second)
+	defer cancel()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		r := started[i]
+		r.stopping.Store(true)
+		r.cancel()
+		if r.Stop != nil {
+			if err := r.Stop(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("on stopping %s: %w", r.Name, err))
+			}
+		}
+
+		select {
+		case <-r.done:
+			m.logger.Infof("%s stopped", r.Name)
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("%s did not stop before shutdown deadline", r.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
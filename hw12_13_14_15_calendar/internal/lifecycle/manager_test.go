@@ -0,0 +1,123 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T, timeout int) *Manager {
+	t.Helper()
+
+	logInstance, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+	return NewManager(config.ShutdownConfig{Timeout: timeout}, logInstance)
+}
+
+// recorder записывает шаги жизненного цикла компонентов.
+type recorder struct {
+	steps chan string
+}
+
+func (r *recorder) component(name string) Component {
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			r.steps <- "start " + name
+			return nil
+		},
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		},
+		Drain: func() { r.steps <- "drain " + name },
+		Stop: func(context.Context) error {
+			r.steps <- "stop " + name
+			return nil
+		},
+	}
+}
+
+func (r *recorder) all() []string {
+	close(r.steps)
+	var steps []string
+	for step := range r.steps {
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func TestManager_OrderedShutdown(t *testing.T) {
+	manager := newTestManager(t, 1)
+	steps := &recorder{steps: make(chan string, 16)}
+	manager.Add(steps.component("storage"), steps.component("grpc"), steps.component("http"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- manager.Run(ctx) }()
+
+	require.Eventually(t, manager.Ready, time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	require.False(t, manager.Ready())
+
+	require.Equal(t, []string{
+		"start storage", "start grpc", "start http",
+		"drain storage", "drain grpc", "drain http",
+		"stop http", "stop grpc", "stop storage",
+	}, steps.all())
+}
+
+func TestManager_StartFailure(t *testing.T) {
+	manager := newTestManager(t, 1)
+	steps := &recorder{steps: make(chan string, 16)}
+	failing := steps.component("grpc")
+	failing.Start = func(context.Context) error { return errors.New("address in use") }
+	manager.Add(steps.component("storage"), failing, steps.component("http"))
+
+	err := manager.Run(context.Background())
+	require.ErrorContains(t, err, "on starting grpc: address in use")
+
+	// Готовность не выставлялась, поэтому drain не вызывается; запущенное хранилище останавливается
+	require.Equal(t, []string{"start storage", "stop storage"}, steps.all())
+}
+
+func TestManager_RunFailure(t *testing.T) {
+	manager := newTestManager(t, 1)
+	steps := &recorder{steps: make(chan string, 16)}
+	failing := steps.component("http")
+	failing.Run = func(context.Context) error { return errors.New("listener closed") }
+	manager.Add(steps.component("storage"), failing)
+
+	err := manager.Run(context.Background())
+	require.ErrorContains(t, err, "http: listener closed")
+	require.Contains(t, steps.all(), "stop storage")
+}
+
+func TestManager_ShutdownDeadline(t *testing.T) {
+	manager := newTestManager(t, 1)
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	manager.Add(Component{
+		Name: "stuck",
+		Run: func(context.Context) error {
+			<-release
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := manager.Run(ctx)
+	require.ErrorContains(t, err, "stuck did not stop before shutdown deadline")
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"fmt"
+
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -15,31 +17,38 @@ type Logger interface {
 	Errorf(template string, args ...interface{})
 	Fatal(args ...interface{})
 	Fatalf(template string, args ...interface{})
+	// SetLevel меняет уровень логирования на лету, например при перезагрузке конфигурации.
+	SetLevel(level string) error
 }
 
 type ZapLogger struct {
 	*zap.SugaredLogger
+	level zap.AtomicLevel
 }
 
-func New(cfg config.LoggerConfig) (Logger, error) {
-	var zapLevel zapcore.Level
-	switch cfg.Level {
+func parseLevel(level string) (zapcore.Level, error) {
+	switch level {
 	case "debug":
-		zapLevel = zap.DebugLevel
+		return zap.DebugLevel, nil
 	case "info":
-		zapLevel = zap.InfoLevel
+		return zap.InfoLevel, nil
 	case "warn":
-		zapLevel = zap.WarnLevel
+		return zap.WarnLevel, nil
 	case "error":
-		zapLevel = zap.ErrorLevel
+		return zap.ErrorLevel, nil
 	case "fatal":
-		zapLevel = zap.FatalLevel
+		return zap.FatalLevel, nil
 	default:
-		zapLevel = zap.InfoLevel
+		return zap.InfoLevel, fmt.Errorf("unknown log level %q", level)
 	}
+}
+
+func New(cfg config.LoggerConfig) (Logger, error) {
+	zapLevel, _ := parseLevel(cfg.Level)
+	level := zap.NewAtomicLevelAt(zapLevel)
 
 	zapConfig := zap.Config{
-		Level:            zap.NewAtomicLevelAt(zapLevel),
+		Level:            level,
 		Development:      false,
 		Encoding:         cfg.Encoding,
 		EncoderConfig:    zap.NewProductionEncoderConfig(),
@@ -52,5 +61,14 @@ func New(cfg config.LoggerConfig) (Logger, error) {
 		return nil, err
 	}
 
-	return &ZapLogger{logger.Sugar()}, nil
+	return &ZapLogger{SugaredLogger: logger.Sugar(), level: level}, nil
+}
+
+func (l *ZapLogger) SetLevel(level string) error {
+	zapLevel, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(zapLevel)
+	return nil
 }
@@ -3,6 +3,7 @@ package rabbitmq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
@@ -15,6 +16,7 @@ import (
 type Client interface {
 	Connect() error
 	Close() error
+	HealthCheck(ctx context.Context) error
 	SendNotification(notification dto.NotificationData) error
 	ReceiveNotifications(ctx context.Context) (<-chan dto.NotificationData, error)
 }
@@ -41,7 +43,7 @@ func NewClient(cfg config.RabbitMQConfig, log logger.Logger) (Client, error) {
 func (c *rabbitClient) Connect() error {
 	var err error
 
-	c.conn, err = amqp.Dial(c.cfg.URL)
+	c.conn, err = amqp.Dial(c.cfg.URL.Value())
 	if err != nil {
 		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
@@ -85,6 +87,14 @@ func (c *rabbitClient) Close() error {
 	return nil
 }
 
+// HealthCheck проверяет, что соединение с RabbitMQ установлено и не закрыто брокером.
+func (c *rabbitClient) HealthCheck(context.Context) error {
+	if c.conn == nil || c.conn.IsClosed() {
+		return errors.New("not connected to RabbitMQ")
+	}
+	return nil
+}
+
 func (c *rabbitClient) SendNotification(notification dto.NotificationData) error {
 	// Сериализация уведомления в JSON
 	messageBody, err := json.Marshal(notification)
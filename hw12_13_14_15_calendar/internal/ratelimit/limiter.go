@@ -0,0 +1,195 @@
+// Package ratelimit ограничивает частоту запросов клиентов к API по алгоритму token bucket.
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/netip"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+)
+
+// GatewayMetadata ключ метаданных, которым grpc-gateway отмечает вызовы, проксируемые в gRPC-сервер.
+const GatewayMetadata = "x-ratelimit-gateway"
+
+// route отдельное ограничение маршрутов, совпавших с шаблоном.
+type route struct {
+	pattern string
+	limit   Limit
+}
+
+// rules ограничения из конфигурации. Заменяются целиком при перезагрузке конфигурации.
+type rules struct {
+	enabled        bool
+	limit          Limit
+	routes         []route
+	trustedProxies []netip.Prefix
+	apiKeys        [][]byte
+}
+
+// Limiter выбирает ограничение для маршрута и расходует токены клиента в Store. У каждого клиента
+// общее ведро для маршрутов без отдельного ограничения и по ведру на каждое отдельное ограничение.
+type Limiter struct {
+	store Store
+	rules atomic.Pointer[rules]
+	// gatewayToken секрет процесса, которым grpc-gateway подтверждает свои вызовы gRPC-сервера
+	gatewayToken string
+}
+
+func New(cfg config.RateLimitConfig, store Store) (*Limiter, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("on generating gateway token: %w", err)
+	}
+	limiter := &Limiter{store: store, gatewayToken: hex.EncodeToString(token)}
+	if err := limiter.Update(cfg); err != nil {
+		return nil, err
+	}
+	return limiter, nil
+}
+
+// Update заменяет ограничения без перезапуска серверов. Накопленные ведра клиентов сохраняются.
+// При ошибке в конфигурации продолжают действовать прежние ограничения.
+func (l *Limiter) Update(cfg config.RateLimitConfig) error {
+	next := &rules{
+		enabled: cfg.Enabled,
+		limit:   Limit{Rate: cfg.Rate, Burst: cfg.Burst},
+	}
+	for _, r := range cfg.Routes {
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid rate limit route pattern %q: %w", r.Pattern, err)
+		}
+		next.routes = append(next.routes, route{pattern: r.Pattern, limit: Limit{Rate: r.Rate, Burst: r.Burst}})
+	}
+	for _, proxy := range cfg.TrustedProxies {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		next.trustedProxies = append(next.trustedProxies, prefix)
+	}
+	for _, key := range cfg.APIKeys {
+		if key != "" {
+			next.apiKeys = append(next.apiKeys, []byte(key.Value()))
+		}
+	}
+	l.rules.Store(next)
+	return nil
+}
+
+// Allow расходует токен клиента client для маршрута. Маршрут задается строкой "МЕТОД /путь" для HTTP
+// и полным именем метода для gRPC, scope разделяет ведра разных серверов. Маршруты с нулевой частотой
+// и все маршруты при выключенном ограничении не ограничиваются, для них возвращается разрешение
+// с нулевой емкостью.
+func (l *Limiter) Allow(ctx context.Context, scope, routeName, client string) (Result, error) {
+	rules := l.rules.Load()
+	if !rules.enabled {
+		return Result{Allowed: true}, nil
+	}
+	key := scope + "|" + client
+	limit := rules.limit
+	for _, r := range rules.routes {
+		if matched, _ := path.Match(r.pattern, routeName); matched {
+			key = scope + "|" + r.pattern + "|" + client
+			limit = r.limit
+			break
+		}
+	}
+	if limit.Rate <= 0 {
+		return Result{Allowed: true}, nil
+	}
+	return l.store.Take(ctx, key, limit)
+}
+
+// Client возвращает клиента, которому принадлежит ведро. Запрос с ключом API из конфигурации в заголовке
+// authorization вида "Bearer <ключ>" ограничивается по ключу, остальные, в том числе с неизвестным
+// ключом, - по адресу клиента из ClientIP: иначе клиент получал бы новое ведро с каждым новым ключом.
+// Ключ хранится в виде хеша, чтобы не попасть в хранилище ограничений и журнал.
+func (l *Limiter) Client(remoteAddr string, forwardedFor []string, authorization string) string {
+	scheme, token, found := strings.Cut(strings.TrimSpace(authorization), " ")
+	if token = strings.TrimSpace(token); found && strings.EqualFold(scheme, "Bearer") && l.knownAPIKey(token) {
+		sum := sha256.Sum256([]byte(token))
+		return "key:" + hex.EncodeToString(sum[:16])
+	}
+	return "ip:" + l.ClientIP(remoteAddr, forwardedFor)
+}
+
+// knownAPIKey сообщает, задан ли token среди ключей API. Сравнение не зависит по времени от совпадающей
+// части ключа.
+func (l *Limiter) knownAPIKey(token string) bool {
+	known := false
+	for _, key := range l.rules.Load().apiKeys {
+		if subtle.ConstantTimeCompare([]byte(token), key) == 1 {
+			known = true
+		}
+	}
+	return known
+}
+
+// GatewayToken возвращает секрет, которым grpc-gateway отмечает свои вызовы gRPC-сервера в метаданных
+// GatewayMetadata. Запрос к gateway уже ограничен HTTP-сервером, поэтому такой вызов не расходует
+// токены повторно. Секрет создается заново при каждом запуске и известен только процессу.
+func (l *Limiter) GatewayToken() string {
+	return l.gatewayToken
+}
+
+// FromGateway сообщает, есть ли среди значений метаданных GatewayMetadata секрет grpc-gateway.
+func (l *Limiter) FromGateway(values []string) bool {
+	for _, value := range values {
+		if subtle.ConstantTimeCompare([]byte(value), []byte(l.gatewayToken)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP возвращает адрес клиента. Если запрос пришел от доверенного прокси, адрес берется из
+// цепочки X-Forwarded-For: справа налево пропускаются доверенные прокси, первый чужой адрес
+// считается адресом клиента.
+func (l *Limiter) ClientIP(remoteAddr string, forwardedFor []string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	if !l.trusted(host) {
+		return host
+	}
+
+	var chain []string
+	for _, header := range forwardedFor {
+		for _, hop := range strings.Split(header, ",") {
+			chain = append(chain, strings.TrimSpace(hop))
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !l.trusted(chain[i]) {
+			return chain[i]
+		}
+	}
+	if len(chain) > 0 {
+		return chain[0]
+	}
+	return host
+}
+
+func (l *Limiter) trusted(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range l.rules.Load().trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
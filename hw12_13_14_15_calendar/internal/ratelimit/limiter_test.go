@@ -0,0 +1,209 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(t *testing.T, cfg config.RateLimitConfig) (*Limiter, *time.Time) {
+	t.Helper()
+
+	now := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limiter, err := New(cfg, store)
+	require.NoError(t, err)
+	return limiter, &now
+}
+
+func TestLimiter_TokenBucket(t *testing.T) {
+	ctx := context.Background()
+	limiter, now := newTestLimiter(t, config.RateLimitConfig{Enabled: true, Rate: 2, Burst: 3})
+
+	for i := 2; i >= 0; i-- {
+		result, err := limiter.Allow(ctx, "http", "GET /v1/events", "10.0.0.1")
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "http", "GET /v1/events", "10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, result.Reset)
+	assert.Equal(t, map[string]string{
+		"RateLimit-Limit":     "3",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "2",
+		"Retry-After":         "1",
+	}, result.Headers())
+
+	// Другие клиенты и серверы расходуют собственные ведра
+	result, err = limiter.Allow(ctx, "http", "GET /v1/events", "10.0.0.2")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = limiter.Allow(ctx, "grpc", "/api.EventService/ListEvents", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	*now = now.Add(500 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "http", "GET /v1/events", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// Ведро не наполняется сверх емкости
+	*now = now.Add(time.Hour)
+	result, err = limiter.Allow(ctx, "http", "GET /v1/events", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestLimiter_Routes(t *testing.T) {
+	ctx := context.Background()
+	limiter, _ := newTestLimiter(t, config.RateLimitConfig{
+		Enabled: true,
+		Rate:    10,
+		Burst:   10,
+		Routes: []config.RateLimitRoute{
+			{Pattern: "POST /v1/events", Rate: 1, Burst: 1},
+			{Pattern: "/api.EventService/*", Rate: 1, Burst: 2},
+			{Pattern: "GET /v1/events/*", Rate: 0},
+		},
+	})
+
+	result, err := limiter.Allow(ctx, "http", "POST /v1/events", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = limiter.Allow(ctx, "http", "POST /v1/events", "10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	// Остальные маршруты клиента не затронуты исчерпанным ограничением маршрута
+	result, err = limiter.Allow(ctx, "http", "GET /v1/calendars", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 10, result.Limit)
+
+	result, err = limiter.Allow(ctx, "grpc", "/api.EventService/CreateEvent", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Limit)
+
+	for i := 0; i < 20; i++ {
+		result, err = limiter.Allow(ctx, "http", "GET /v1/events/day", "10.0.0.1")
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		assert.Zero(t, result.Limit)
+	}
+
+	invalid := config.RateLimitConfig{Routes: []config.RateLimitRoute{{Pattern: "GET /v1/[events"}}}
+	_, err = New(invalid, NewMemoryStore())
+	assert.Error(t, err)
+}
+
+func TestLimiter_Update(t *testing.T) {
+	ctx := context.Background()
+	limiter, _ := newTestLimiter(t, config.RateLimitConfig{Enabled: true, Rate: 1, Burst: 1})
+
+	result, err := limiter.Allow(ctx, "http", "GET /v1/events", "10.0.0.1")
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	result, err = limiter.Allow(ctx, "http", "GET /v1/events", "10.0.0.1")
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	// Выключенное ограничение пропускает все запросы
+	require.NoError(t, limiter.Update(config.RateLimitConfig{Enabled: false, Rate: 1, Burst: 1}))
+	result, err = limiter.Allow(ctx, "http", "GET /v1/events", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Zero(t, result.Limit)
+
+	// Ошибочная конфигурация не заменяет действующую
+	invalid := config.RateLimitConfig{Enabled: true, TrustedProxies: []string{"localhost"}}
+	require.Error(t, limiter.Update(invalid))
+	result, err = limiter.Allow(ctx, "http", "GET /v1/events", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	require.NoError(t, limiter.Update(config.RateLimitConfig{Enabled: true, Rate: 1, Burst: 5}))
+	result, err = limiter.Allow(ctx, "http", "GET /v1/events", "10.0.0.2")
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Limit)
+}
+
+func TestLimiter_ClientIP(t *testing.T) {
+	limiter, _ := newTestLimiter(t, config.RateLimitConfig{TrustedProxies: []string{"127.0.0.0/8", "10.0.0.0/8"}})
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expected     string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.7:51000", expected: "203.0.113.7"},
+		{
+			name:         "forwarded header from untrusted client is ignored",
+			remoteAddr:   "203.0.113.7:51000",
+			forwardedFor: []string{"198.51.100.1"},
+			expected:     "203.0.113.7",
+		},
+		{
+			name:         "trusted proxy",
+			remoteAddr:   "127.0.0.1:51000",
+			forwardedFor: []string{"198.51.100.1"},
+			expected:     "198.51.100.1",
+		},
+		{
+			name:         "chain of proxies",
+			remoteAddr:   "127.0.0.1:51000",
+			forwardedFor: []string{"192.0.2.1, 198.51.100.1", "10.1.2.3"},
+			expected:     "198.51.100.1",
+		},
+		{name: "trusted proxy without header", remoteAddr: "[::ffff:127.0.0.1]:51000", expected: "::ffff:127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, limiter.ClientIP(tt.remoteAddr, tt.forwardedFor))
+		})
+	}
+}
+
+func TestLimiter_Client(t *testing.T) {
+	limiter, _ := newTestLimiter(t, config.RateLimitConfig{APIKeys: []config.Secret{"secret", "another"}})
+
+	byKey := limiter.Client("203.0.113.7:51000", nil, "Bearer secret")
+	assert.Equal(t, byKey, limiter.Client("198.51.100.1:51000", nil, "bearer  secret"))
+	assert.NotContains(t, byKey, "secret")
+	assert.NotEqual(t, byKey, limiter.Client("203.0.113.7:51000", nil, "Bearer another"))
+
+	// Неизвестный ключ не дает отдельного ведра: клиент определяется по адресу
+	assert.Equal(t, "ip:203.0.113.7", limiter.Client("203.0.113.7:51000", nil, "Bearer random"))
+	assert.Equal(t, "ip:203.0.113.7", limiter.Client("203.0.113.7:51000", nil, "Bearer secre"))
+
+	// Без ключа клиент определяется по адресу
+	assert.Equal(t, "ip:203.0.113.7", limiter.Client("203.0.113.7:51000", nil, ""))
+	assert.Equal(t, "ip:203.0.113.7", limiter.Client("203.0.113.7:51000", nil, "Basic dXNlcg=="))
+	assert.Equal(t, "ip:203.0.113.7", limiter.Client("203.0.113.7:51000", nil, "Bearer "))
+
+	// Ключ, удаленный из конфигурации, перестает действовать
+	require.NoError(t, limiter.Update(config.RateLimitConfig{}))
+	assert.Equal(t, "ip:203.0.113.7", limiter.Client("203.0.113.7:51000", nil, "Bearer secret"))
+}
+
+func TestLimiter_FromGateway(t *testing.T) {
+	limiter, _ := newTestLimiter(t, config.RateLimitConfig{})
+	other, _ := newTestLimiter(t, config.RateLimitConfig{})
+
+	assert.NotEmpty(t, limiter.GatewayToken())
+	assert.NotEqual(t, limiter.GatewayToken(), other.GatewayToken())
+	assert.True(t, limiter.FromGateway([]string{"forged", limiter.GatewayToken()}))
+	assert.False(t, limiter.FromGateway([]string{other.GatewayToken()}))
+	assert.False(t, limiter.FromGateway(nil))
+}
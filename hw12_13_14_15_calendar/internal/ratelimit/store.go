@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepInterval период удаления ведер, которые успели заполниться и не отличаются от новых.
+const sweepInterval = time.Minute
+
+// Limit параметры ведра токенов.
+type Limit struct {
+	Rate  float64 // Пополнение ведра, токенов в секунду
+	Burst int     // Емкость ведра
+}
+
+// Result решение по запросу и состояние ведра после него.
+type Result struct {
+	Allowed   bool
+	Limit     int // Емкость ведра
+	Remaining int // Целых токенов в ведре
+	// RetryAfter время до появления токена, если запрос отклонен
+	RetryAfter time.Duration
+	// Reset время до полного заполнения ведра
+	Reset time.Duration
+}
+
+// Store хранит ведра клиентов. MemoryStore подходит для одного экземпляра сервиса; чтобы экземпляры
+// делили ограничения, Store реализуется поверх общего хранилища.
+type Store interface {
+	// Take расходует токен из ведра key с параметрами limit, если он есть.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// MemoryStore хранит ведра в памяти процесса.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	burst := float64(max(limit.Burst, 1))
+	b, exists := s.buckets[key]
+	if !exists {
+		b = &bucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	result := Result{Limit: int(burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((burst - b.tokens) / limit.Rate)
+	return result, nil
+}
+
+// sweep удаляет заполнившиеся ведра не чаще раза в sweepInterval. Вызывается под s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.limit.Rate >= float64(max(b.limit.Burst, 1)) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
+
+// Headers возвращает заголовки RateLimit-* (draft-ietf-httpapi-ratelimit-headers) и Retry-After для
+// отклоненного запроса. Время округляется вверх до секунд.
+func (r Result) Headers() map[string]string {
+	headers := map[string]string{
+		"RateLimit-Limit":     strconv.Itoa(r.Limit),
+		"RateLimit-Remaining": strconv.Itoa(r.Remaining),
+		"RateLimit-Reset":     strconv.Itoa(ceilSeconds(r.Reset)),
+	}
+	if !r.Allowed {
+		headers["Retry-After"] = strconv.Itoa(ceilSeconds(r.RetryAfter))
+	}
+	return headers
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,101 @@
+// Package secrets шифрует файл с секретами конфигурации, чтобы его можно было хранить рядом
+// с конфигурацией. Шифрование AES-256-GCM, ключ передается приложению через переменную окружения.
+//
+// Формат файла - строка заголовка и base64 от nonce и шифртекста:
+//
+//	calendar-secrets:v1
+//	<base64>
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize длина ключа в байтах.
+const KeySize = 32
+
+const header = "calendar-secrets:v1"
+
+var (
+	ErrInvalidKey    = errors.New("secrets key must be base64 of 32 bytes")
+	ErrInvalidFormat = errors.New("not a calendar secrets file")
+	// ErrDecrypt файл зашифрован другим ключом или поврежден.
+	ErrDecrypt = errors.New("failed to decrypt secrets file: wrong key or corrupted file")
+)
+
+// GenerateKey возвращает новый случайный ключ в base64.
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("on generating key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseKey декодирует ключ из base64.
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt шифрует plaintext ключом key и возвращает содержимое файла секретов.
+func Encrypt(plaintext, key []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("on generating nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(header))
+
+	var out bytes.Buffer
+	out.WriteString(header + "\n")
+	out.WriteString(base64.StdEncoding.EncodeToString(sealed))
+	out.WriteString("\n")
+	return out.Bytes(), nil
+}
+
+// Decrypt расшифровывает содержимое файла секретов ключом key.
+func Decrypt(content, key []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	first, rest, _ := bytes.Cut(content, []byte("\n"))
+	if string(bytes.TrimSpace(first)) != header {
+		return nil, ErrInvalidFormat
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(rest), nil)))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidFormat
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(header))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
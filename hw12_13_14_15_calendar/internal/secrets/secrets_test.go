@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	encoded, err := GenerateKey()
+	require.NoError(t, err)
+	key, err := ParseKey(encoded + "\n")
+	require.NoError(t, err)
+
+	plaintext := []byte("database:\n  password: s3cret\n")
+	content, err := Encrypt(plaintext, key)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "s3cret")
+
+	decrypted, err := Decrypt(content, key)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	otherEncoded, err := GenerateKey()
+	require.NoError(t, err)
+	otherKey, err := ParseKey(otherEncoded)
+	require.NoError(t, err)
+	_, err = Decrypt(content, otherKey)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	// Подмена шифртекста обнаруживается
+	tampered := bytes.Clone(content)
+	i := len(header) + 10
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	_, err = Decrypt(tampered, key)
+	assert.Error(t, err)
+
+	_, err = Decrypt(plaintext, key)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	_, err = ParseKey("c2hvcnQ=")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// parseUUID разбирает обязательный идентификатор из запроса.
+func parseUUID(value, field string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid %s", field)
+	}
+	return id, nil
+}
+
+// parseOptionalUUID разбирает необязательный идентификатор из запроса: пустая строка означает uuid.Nil.
+func parseOptionalUUID(value, field string) (uuid.UUID, error) {
+	if value == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid %s", field)
+	}
+	return id, nil
+}
+
+// eventFromAPI разбирает событие из запроса, в котором идентификаторы могут быть не заданы.
+func eventFromAPI(event *api.Event) (dto.EventData, error) {
+	if event == nil {
+		return dto.EventData{}, nil
+	}
+	userID, err := parseOptionalUUID(event.GetUserId(), "user_id")
+	if err != nil {
+		return dto.EventData{}, err
+	}
+	calendarID, err := parseOptionalUUID(event.GetCalendarId(), "calendar_id")
+	if err != nil {
+		return dto.EventData{}, err
+	}
+	return dto.EventData{
+		Title:       event.GetTitle(),
+		Description: event.GetDescription(),
+		StartTime:   event.GetStartTime().AsTime(),
+		EndTime:     event.GetEndTime().AsTime(),
+		UserID:      userID,
+		CalendarID:  calendarID,
+		Attendees:   event.GetAttendees(),
+		Version:     event.GetVersion(),
+	}, nil
+}
@@ -0,0 +1,26 @@
+package grpc
+
+import (
+	"errors"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatusError переводит ошибки хранилища в коды gRPC. Остальные ошибки, в том числе уже
+// имеющие статус, возвращаются без изменений.
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrEventNotFound),
+		errors.Is(err, storage.ErrNotificationNotFound),
+		errors.Is(err, storage.ErrCalendarNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrVersionConflict):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, storage.ErrIdempotencyKeyReused):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return err
+	}
+}
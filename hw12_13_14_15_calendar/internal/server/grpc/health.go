@@ -0,0 +1,116 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/services"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthWatchInterval период проверки здоровья для подписчиков Watch.
+const healthWatchInterval = 5 * time.Second
+
+// healthHandler реализует grpc.health.v1.Health поверх HealthService. Все сервисы зависят от одних
+// и тех же хранилища и брокера, поэтому их состояние совпадает с общим состоянием сервера ("").
+type healthHandler struct {
+	healthpb.UnimplementedHealthServer
+	healthService services.HealthService
+	services      map[string]bool
+	logger        logger.Logger
+	watchInterval time.Duration
+	// shutdown закрывается при остановке сервера, чтобы подписки Watch не задерживали GracefulStop
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+func newHealthHandler(
+	healthService services.HealthService,
+	logger logger.Logger,
+	serviceNames ...string,
+) *healthHandler {
+	known := map[string]bool{"": true}
+	for _, name := range serviceNames {
+		known[name] = true
+	}
+	return &healthHandler{
+		healthService: healthService,
+		services:      known,
+		logger:        logger,
+		watchInterval: healthWatchInterval,
+		shutdown:      make(chan struct{}),
+	}
+}
+
+// Shutdown переводит сервер в NOT_SERVING и завершает подписки Watch.
+func (h *healthHandler) Shutdown() {
+	h.shutdownOnce.Do(func() { close(h.shutdown) })
+}
+
+func (h *healthHandler) Check(
+	ctx context.Context,
+	req *healthpb.HealthCheckRequest,
+) (*healthpb.HealthCheckResponse, error) {
+	if !h.services[req.GetService()] {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: h.status(ctx)}, nil
+}
+
+// Watch отправляет текущее состояние и затем каждое его изменение. Для неизвестного сервиса
+// отправляется SERVICE_UNKNOWN, как требует протокол.
+func (h *healthHandler) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	if !h.services[req.GetService()] {
+		if err := stream.Send(&healthpb.HealthCheckResponse{
+			Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN,
+		}); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-h.shutdown:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		}
+	}
+
+	ticker := time.NewTicker(h.watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		if current := h.status(ctx); current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-h.shutdown:
+			_ = stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING})
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthHandler) status(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	select {
+	case <-h.shutdown:
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	default:
+	}
+	if err := h.healthService.HealthCheck(ctx); err != nil {
+		h.logger.Errorf("gRPC health check failed: %v", err)
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,59 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/services"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthServer gRPC-сервер воркеров, на котором есть только grpc.health.v1.
+type HealthServer struct {
+	grpcServer *grpc.Server
+	health     *healthHandler
+	address    string
+	logger     logger.Logger
+}
+
+func NewHealthServer(address string, healthService services.HealthService, logger logger.Logger) *HealthServer {
+	grpcServer := grpc.NewServer(interceptors(config.GRPCServerConfig{}, nil, logger)...)
+	health := newHealthHandler(healthService, logger)
+	healthpb.RegisterHealthServer(grpcServer, health)
+
+	return &HealthServer{
+		grpcServer: grpcServer,
+		health:     health,
+		address:    address,
+		logger:     logger,
+	}
+}
+
+// Start принимает проверки здоровья до вызова Stop.
+func (s *HealthServer) Start(_ context.Context) error {
+	lis, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("on net.Listen: %w", err)
+	}
+
+	s.logger.Infof("gRPC health server started. Listening on %s", s.address)
+	return s.grpcServer.Serve(lis)
+}
+
+// Drain переводит воркер в NOT_SERVING на время остановки.
+func (s *HealthServer) Drain() {
+	s.health.Shutdown()
+}
+
+func (s *HealthServer) Stop(ctx context.Context) error {
+	s.health.Shutdown()
+	if err := gracefulStop(ctx, s.grpcServer); err != nil {
+		return err
+	}
+	s.logger.Info("gRPC health server stopped")
+	return nil
+}
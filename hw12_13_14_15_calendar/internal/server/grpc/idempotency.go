@@ -0,0 +1,20 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// IdempotencyKeyMetadata ключ метаданных вызова с ключом идемпотентности. Повтор CreateEvent с тем же
+// ключом возвращает уже созданное событие.
+const IdempotencyKeyMetadata = "idempotency-key"
+
+// idempotencyKey возвращает ключ идемпотентности из метаданных вызова, пустую строку если ключ не передан.
+func idempotencyKey(ctx context.Context) string {
+	values := metadata.ValueFromIncomingContext(ctx, IdempotencyKeyMetadata)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
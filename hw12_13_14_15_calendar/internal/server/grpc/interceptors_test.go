@@ -0,0 +1,173 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func newTestLogger(t *testing.T) logger.Logger {
+	t.Helper()
+
+	logInstance, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+	return logInstance
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	interceptor := RecoveryInterceptor(newTestLogger(t))
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.EventService/GetEvent"}
+
+	resp, err := interceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	require.Nil(t, resp)
+	require.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestDeadlineInterceptor(t *testing.T) {
+	interceptor := DeadlineInterceptor(time.Minute)
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.EventService/GetEvent"}
+
+	deadline := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		value, ok := ctx.Deadline()
+		require.True(t, ok)
+		return value, nil
+	}
+
+	// Без дедлайна клиента используется timeout
+	resp, err := interceptor(context.Background(), nil, info, deadline)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Minute), resp.(time.Time), time.Second)
+
+	// Дедлайн клиента сохраняется, даже если он дальше timeout
+	clientDeadline := time.Now().Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.Background(), clientDeadline)
+	defer cancel()
+	resp, err = interceptor(ctx, nil, info, deadline)
+	require.NoError(t, err)
+	require.Equal(t, clientDeadline, resp.(time.Time))
+}
+
+type healthServiceFunc func(ctx context.Context) error
+
+func (f healthServiceFunc) HealthCheck(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestHealthHandler(t *testing.T) {
+	var healthErr error
+	handler := newHealthHandler(
+		healthServiceFunc(func(context.Context) error { return healthErr }),
+		newTestLogger(t),
+		"api.EventService",
+	)
+
+	resp, err := handler.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "api.EventService"})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	healthErr = errors.New("storage is down")
+	resp, err = handler.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	limiter, err := ratelimit.New(config.RateLimitConfig{
+		Enabled: true,
+		Rate:    0.001,
+		Burst:   1,
+		APIKeys: []config.Secret{"secret"},
+	}, ratelimit.NewMemoryStore())
+	require.NoError(t, err)
+	interceptor := RateLimitInterceptor(limiter, newTestLogger(t))
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.EventService/GetEvent"}
+	ok := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+
+	client := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(203, 0, 113, 7)}})
+	_, err = interceptor(client, nil, info, ok)
+	require.NoError(t, err)
+	_, err = interceptor(client, nil, info, ok)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Неизвестный ключ не дает отдельного ведра, клиент с ключом API расходует собственное
+	withUnknownKey := metadata.NewIncomingContext(client, metadata.Pairs("authorization", "Bearer random"))
+	_, err = interceptor(withUnknownKey, nil, info, ok)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	withKey := metadata.NewIncomingContext(client, metadata.Pairs("authorization", "Bearer secret"))
+	_, err = interceptor(withKey, nil, info, ok)
+	require.NoError(t, err)
+	_, err = interceptor(withKey, nil, info, ok)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Вызовы grpc-gateway уже ограничены HTTP-сервером, а локальный адрес и X-Forwarded-For не дают доверия
+	local := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}})
+	gateway := metadata.NewIncomingContext(local, metadata.Pairs(ratelimit.GatewayMetadata, limiter.GatewayToken()))
+	for i := 0; i < 3; i++ {
+		_, err = interceptor(gateway, nil, info, ok)
+		require.NoError(t, err)
+	}
+	forged := metadata.NewIncomingContext(local, metadata.Pairs(
+		ratelimit.GatewayMetadata, "forged",
+		"x-forwarded-for", "198.51.100.1",
+	))
+	_, err = interceptor(forged, nil, info, ok)
+	require.NoError(t, err)
+	_, err = interceptor(forged, nil, info, ok)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Проверки здоровья не ограничиваются
+	healthInfo := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	_, err = interceptor(client, nil, healthInfo, ok)
+	require.NoError(t, err)
+}
+
+type panicStore struct{}
+
+func (panicStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	panic("store is broken")
+}
+
+func TestInterceptors_RecoverInterceptorPanic(t *testing.T) {
+	limiter, err := ratelimit.New(config.RateLimitConfig{Enabled: true, Rate: 1, Burst: 1}, panicStore{})
+	require.NoError(t, err)
+	server := grpc.NewServer(interceptors(config.GRPCServerConfig{}, limiter, newTestLogger(t))...)
+	api.RegisterVersionServiceServer(server, &Server{})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	// Паника в ограничении частоты вызовов завершает только вызов, сервер продолжает работать
+	client := api.NewVersionServiceClient(conn)
+	for i := 0; i < 2; i++ {
+		_, err = client.GetVersion(context.Background(), &api.GetVersionRequest{})
+		require.Equal(t, codes.Internal, status.Code(err))
+	}
+}
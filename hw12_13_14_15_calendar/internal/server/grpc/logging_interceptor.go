@@ -26,3 +26,23 @@ func LoggingInterceptor(logger logger.Logger) grpc.UnaryServerInterceptor {
 		return resp, err
 	}
 }
+
+// LoggingStreamInterceptor журналирует начало и завершение потоковых вызовов; сообщения потока не журналируются.
+func LoggingStreamInterceptor(logger logger.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		logger.Infof("gRPC stream: %s started", info.FullMethod)
+		err := handler(srv, stream)
+		if err != nil {
+			st, _ := status.FromError(err)
+			logger.Errorf("gRPC stream: %s, error: %v, code: %v", info.FullMethod, err, st.Code())
+		} else {
+			logger.Infof("gRPC stream: %s finished", info.FullMethod)
+		}
+		return err
+	}
+}
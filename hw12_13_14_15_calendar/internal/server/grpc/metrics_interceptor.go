@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	handledTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Количество завершенных gRPC-вызовов по методам и кодам ответа.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"})
+
+	handlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Длительность gRPC-вызовов по методам.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+)
+
+// MetricsInterceptor считает вызовы и их длительность по методам.
+func MetricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observe("unary", info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// MetricsStreamInterceptor аналог MetricsInterceptor для потоковых вызовов: длительность считается
+// до закрытия потока.
+func MetricsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		observe(streamType(info), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observe(callType, fullMethod string, start time.Time, err error) {
+	service, method := splitMethod(fullMethod)
+	handledTotal.WithLabelValues(callType, service, method, status.Code(err).String()).Inc()
+	handlingSeconds.WithLabelValues(callType, service, method).Observe(time.Since(start).Seconds())
+}
+
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	default:
+		return "server_stream"
+	}
+}
+
+// splitMethod разбирает полное имя метода вида /api.EventService/CreateEvent.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", "unknown"
+	}
+	return service, method
+}
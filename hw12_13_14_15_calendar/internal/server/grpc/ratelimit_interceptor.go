@@ -0,0 +1,121 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimitInterceptor ограничивает частоту вызовов клиента. Состояние ведра передается в заголовках
+// ответа ratelimit-*, превышение ограничения завершает вызов с ResourceExhausted. Проверки здоровья
+// и вызовы grpc-gateway, запрос к которому уже ограничен HTTP-сервером, не ограничиваются.
+// Если хранилище ограничений недоступно, вызов пропускается.
+func RateLimitInterceptor(limiter *ratelimit.Limiter, logger logger.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := allowCall(ctx, limiter, logger, info.FullMethod, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		}); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor аналог RateLimitInterceptor для потоковых вызовов: токен расходуется
+// при открытии потока.
+func RateLimitStreamInterceptor(limiter *ratelimit.Limiter, logger logger.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := allowCall(stream.Context(), limiter, logger, info.FullMethod, stream.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func allowCall(
+	ctx context.Context,
+	limiter *ratelimit.Limiter,
+	logger logger.Logger,
+	fullMethod string,
+	setHeader func(metadata.MD) error,
+) error {
+	if service, _ := splitMethod(fullMethod); service == healthpb.Health_ServiceDesc.ServiceName {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if limiter.FromGateway(md.Get(ratelimit.GatewayMetadata)) {
+		return nil
+	}
+
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	client := limiter.Client(remoteAddr, md.Get("x-forwarded-for"), strings.Join(md.Get("authorization"), ","))
+
+	result, err := limiter.Allow(ctx, "grpc", fullMethod, client)
+	if err != nil {
+		logger.Errorf("on checking rate limit for %s: %v", client, err)
+		return nil
+	}
+	if result.Limit > 0 {
+		if err := setHeader(metadata.New(result.Headers())); err != nil {
+			logger.Errorf("on setting rate limit headers: %v", err)
+		}
+	}
+	if !result.Allowed {
+		retryAfter := result.RetryAfter.Round(time.Millisecond)
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry in %s", retryAfter)
+	}
+	return nil
+}
+
+// GatewayDialOptions возвращает параметры подключения grpc-gateway к gRPC-серверу, которые отмечают
+// вызовы секретом limiter, чтобы RateLimitInterceptor не ограничивал их повторно.
+func GatewayDialOptions(limiter *ratelimit.Limiter) []grpc.DialOption {
+	mark := func(ctx context.Context) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, ratelimit.GatewayMetadata, limiter.GatewayToken())
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(
+			ctx context.Context,
+			method string,
+			req, reply interface{},
+			cc *grpc.ClientConn,
+			invoker grpc.UnaryInvoker,
+			opts ...grpc.CallOption,
+		) error {
+			return invoker(mark(ctx), method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(
+			ctx context.Context,
+			desc *grpc.StreamDesc,
+			cc *grpc.ClientConn,
+			method string,
+			streamer grpc.Streamer,
+			opts ...grpc.CallOption,
+		) (grpc.ClientStream, error) {
+			return streamer(mark(ctx), desc, cc, method, opts...)
+		}),
+	}
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryInterceptor перехватывает панику обработчика, чтобы она не завершила процесс:
+// клиент получает Internal, стек паники пишется в журнал.
+func RecoveryInterceptor(logger logger.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recoverPanic(logger, info.FullMethod, p)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamInterceptor аналог RecoveryInterceptor для потоковых вызовов.
+func RecoveryStreamInterceptor(logger logger.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recoverPanic(logger, info.FullMethod, p)
+			}
+		}()
+		return handler(srv, stream)
+	}
+}
+
+func recoverPanic(logger logger.Logger, method string, p interface{}) error {
+	logger.Errorf("gRPC method: %s, panic: %v\n%s", method, p, debug.Stack())
+	return status.Error(codes.Internal, "internal error")
+}
@@ -4,24 +4,36 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/certs"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/services"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
 	api.UnimplementedEventServiceServer
 	api.UnimplementedNotificationServiceServer
+	api.UnimplementedCalendarServiceServer
+	api.UnimplementedVersionServiceServer
 	grpcServer          *grpc.Server
+	certificates        *certs.Reloader
+	health              *healthHandler
 	config              config.GRPCServerConfig
 	eventService        services.EventService
 	notificationService services.NotificationService
+	calendarService     services.CalendarService
 	healthService       services.HealthService
 	logger              logger.Logger
 }
@@ -29,27 +41,47 @@ type Server struct {
 func New(
 	eventService services.EventService,
 	notificationService services.NotificationService,
+	calendarService services.CalendarService,
 	healthService services.HealthService,
 	logger logger.Logger,
 	config config.GRPCServerConfig,
+	limiter *ratelimit.Limiter,
 ) (*Server, error) {
-	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(LoggingInterceptor(logger)),
-	)
+	options := interceptors(config, limiter, logger)
+	var certificates *certs.Reloader
+	if config.TLS.Enabled {
+		var err error
+		certificates, err = certs.NewReloader(config.TLS, logger)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(certificates.ServerConfig("h2"))))
+	}
+	grpcServer := grpc.NewServer(options...)
 
 	server := &Server{
 		eventService:        eventService,
 		notificationService: notificationService,
+		calendarService:     calendarService,
 		healthService:       healthService,
 		logger:              logger,
 		config:              config,
 		grpcServer:          grpcServer,
+		certificates:        certificates,
+		health: newHealthHandler(
+			healthService,
+			logger,
+			api.EventService_ServiceDesc.ServiceName,
+			api.NotificationService_ServiceDesc.ServiceName,
+			api.CalendarService_ServiceDesc.ServiceName,
+		),
 	}
 
 	return server, nil
 }
 
-func (s *Server) Start(ctx context.Context) error {
+// Start обслуживает вызовы до вызова Stop.
+func (s *Server) Start(_ context.Context) error {
 	lis, err := net.Listen("tcp", s.config.Address)
 	if err != nil {
 		return fmt.Errorf("on net.Listen: %w", err)
@@ -58,67 +90,192 @@ func (s *Server) Start(ctx context.Context) error {
 	// Register gRPC services
 	api.RegisterEventServiceServer(s.grpcServer, s)
 	api.RegisterNotificationServiceServer(s.grpcServer, s)
+	api.RegisterCalendarServiceServer(s.grpcServer, s)
+	api.RegisterVersionServiceServer(s.grpcServer, s)
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
 
 	// Register reflection service on gRPC server.
 	reflection.Register(s.grpcServer)
 
-	go func() {
-		<-ctx.Done()
-		s.Stop(ctx)
-	}()
-
 	s.logger.Infof("gRPC server started. Listening on %s", s.config.Address)
 	return s.grpcServer.Serve(lis)
 }
 
-func (s *Server) Stop(_ context.Context) {
-	s.grpcServer.GracefulStop()
+// Certificates возвращает сертификаты TLS сервера или nil, если TLS выключен.
+func (s *Server) Certificates() *certs.Reloader {
+	return s.certificates
+}
+
+// interceptors собирает цепочку перехватчиков. Восстановление после паники снаружи, чтобы паника
+// в любом перехватчике, например в хранилище ограничений, не завершила процесс. Следом метрики,
+// чтобы учесть коды ошибок остальных перехватчиков. Ограничение частоты вызовов включается,
+// если задан limiter.
+func interceptors(cfg config.GRPCServerConfig, limiter *ratelimit.Limiter, logger logger.Logger) []grpc.ServerOption {
+	unary := []grpc.UnaryServerInterceptor{
+		RecoveryInterceptor(logger),
+		MetricsInterceptor(),
+		LoggingInterceptor(logger),
+	}
+	stream := []grpc.StreamServerInterceptor{
+		RecoveryStreamInterceptor(logger),
+		MetricsStreamInterceptor(),
+		LoggingStreamInterceptor(logger),
+	}
+	if limiter != nil {
+		unary = append(unary, RateLimitInterceptor(limiter, logger))
+		stream = append(stream, RateLimitStreamInterceptor(limiter, logger))
+	}
+	unary = append(unary, DeadlineInterceptor(time.Duration(cfg.RequestTimeout)*time.Second))
+	stream = append(stream, DeadlineStreamInterceptor(time.Duration(cfg.StreamTimeout)*time.Second))
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...)}
+}
+
+// Drain переводит grpc.health.v1 в NOT_SERVING, чтобы клиенты перестали направлять вызовы на сервер.
+func (s *Server) Drain() {
+	s.health.Shutdown()
+}
+
+// Stop дожидается завершения текущих вызовов, а по истечении ctx прерывает оставшиеся,
+// например подписки WatchEvents.
+func (s *Server) Stop(ctx context.Context) error {
+	s.health.Shutdown()
+	if err := gracefulStop(ctx, s.grpcServer); err != nil {
+		return err
+	}
 	s.logger.Info("gRPC server stopped gracefully")
+	return nil
+}
+
+func gracefulStop(ctx context.Context, server *grpc.Server) error {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		<-stopped
+		return fmt.Errorf("graceful stop interrupted: %w", ctx.Err())
+	}
 }
 
 func (s *Server) CreateEvent(ctx context.Context, req *api.CreateEventRequest) (*api.CreateEventResponse, error) {
+	userID, err := parseUUID(req.GetUserId(), "user_id")
+	if err != nil {
+		return nil, err
+	}
+	calendarID, err := parseOptionalUUID(req.GetCalendarId(), "calendar_id")
+	if err != nil {
+		return nil, err
+	}
+
 	event := dto.EventData{
 		Title:       req.GetTitle(),
 		Description: req.GetDescription(),
 		StartTime:   req.GetStartTime().AsTime(),
 		EndTime:     req.GetEndTime().AsTime(),
-		UserID:      uuid.MustParse(req.GetUserId()),
+		UserID:      userID,
+		CalendarID:  calendarID,
+		Attendees:   req.GetAttendees(),
 	}
-	id, err := s.eventService.CreateEvent(ctx, event)
+	id, err := s.eventService.CreateEventIdempotent(ctx, idempotencyKey(ctx), event)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 	return &api.CreateEventResponse{Id: id.String()}, nil
 }
 
 func (s *Server) UpdateEvent(ctx context.Context, req *api.UpdateEventRequest) (*api.UpdateEventResponse, error) {
+	id, err := parseUUID(req.GetId(), "id")
+	if err != nil {
+		return nil, err
+	}
+	if paths := req.GetUpdateMask().GetPaths(); len(paths) > 0 {
+		return s.patchEvent(ctx, id, req, paths)
+	}
+
+	userID, err := parseUUID(req.GetUserId(), "user_id")
+	if err != nil {
+		return nil, err
+	}
+	calendarID, err := parseOptionalUUID(req.GetCalendarId(), "calendar_id")
+	if err != nil {
+		return nil, err
+	}
 	event := dto.EventData{
 		Title:       req.GetTitle(),
 		Description: req.GetDescription(),
 		StartTime:   req.GetStartTime().AsTime(),
 		EndTime:     req.GetEndTime().AsTime(),
-		UserID:      uuid.MustParse(req.GetUserId()),
+		UserID:      userID,
+		CalendarID:  calendarID,
+		Attendees:   req.GetAttendees(),
+		Version:     req.GetExpectedVersion(),
 	}
-	err := s.eventService.UpdateEvent(ctx, uuid.MustParse(req.GetId()), event)
+	err = s.eventService.UpdateEvent(ctx, id, event)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.UpdateEventResponse{}, nil
+}
+
+// patchEvent обновляет только поля события из update_mask, остальные поля запроса игнорируются.
+func (s *Server) patchEvent(
+	ctx context.Context,
+	id uuid.UUID,
+	req *api.UpdateEventRequest,
+	paths []string,
+) (*api.UpdateEventResponse, error) {
+	userID, err := parseOptionalUUID(req.GetUserId(), "user_id")
 	if err != nil {
 		return nil, err
 	}
+	calendarID, err := parseOptionalUUID(req.GetCalendarId(), "calendar_id")
+	if err != nil {
+		return nil, err
+	}
+
+	patch := dto.EventData{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		StartTime:   req.GetStartTime().AsTime(),
+		EndTime:     req.GetEndTime().AsTime(),
+		UserID:      userID,
+		CalendarID:  calendarID,
+		Attendees:   req.GetAttendees(),
+		Version:     req.GetExpectedVersion(),
+	}
+	if err := s.eventService.PatchEvent(ctx, id, patch, paths); err != nil {
+		return nil, toStatusError(err)
+	}
 	return &api.UpdateEventResponse{}, nil
 }
 
 func (s *Server) DeleteEvent(ctx context.Context, req *api.DeleteEventRequest) (*api.DeleteEventResponse, error) {
-	err := s.eventService.DeleteEvent(ctx, uuid.MustParse(req.GetId()))
+	id, err := parseUUID(req.GetId(), "id")
 	if err != nil {
 		return nil, err
 	}
+	err = s.eventService.DeleteEvent(ctx, id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
 	return &api.DeleteEventResponse{}, nil
 }
 
 func (s *Server) GetEvent(ctx context.Context, req *api.GetEventRequest) (*api.GetEventResponse, error) {
-	event, err := s.eventService.GetEvent(ctx, uuid.MustParse(req.GetId()))
+	id, err := parseUUID(req.GetId(), "id")
 	if err != nil {
 		return nil, err
 	}
+	event, err := s.eventService.GetEvent(ctx, id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
 	return &api.GetEventResponse{Event: dto.ToAPIEvent(event)}, nil
 }
 
@@ -127,7 +284,7 @@ func (s *Server) ListEvents(ctx context.Context, req *api.ListEventsRequest) (*a
 	end := req.GetEndTime().AsTime()
 	events, err := s.eventService.ListEvents(ctx, start, end)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 	apiEvents := make([]*api.Event, len(events))
 	for i, event := range events {
@@ -143,15 +300,7 @@ func (s *Server) ListEventsForDate(
 	start := req.GetDate().AsTime()
 	end := start.AddDate(0, 0, 1) // Добавляем 1 день
 
-	events, err := s.eventService.ListEvents(ctx, start, end)
-	if err != nil {
-		return nil, err
-	}
-	apiEvents := make([]*api.Event, len(events))
-	for i, event := range events {
-		apiEvents[i] = dto.ToAPIEvent(event)
-	}
-	return &api.ListEventsResponse{Events: apiEvents}, nil
+	return s.listEventsByCalendars(ctx, req.GetCalendarIds(), start, end)
 }
 
 func (s *Server) ListEventsForWeek(
@@ -161,10 +310,85 @@ func (s *Server) ListEventsForWeek(
 	start := req.GetDate().AsTime()
 	end := start.AddDate(0, 0, 7) // Добавляем 7 дней
 
-	events, err := s.eventService.ListEvents(ctx, start, end)
+	return s.listEventsByCalendars(ctx, req.GetCalendarIds(), start, end)
+}
+
+func (s *Server) ListEventsForMonth(
+	ctx context.Context,
+	req *api.ListEventsForMonthRequest,
+) (*api.ListEventsResponse, error) {
+	start := req.GetDate().AsTime()
+	end := start.AddDate(0, 1, 0) // Добавляем 1 месяц
+
+	return s.listEventsByCalendars(ctx, req.GetCalendarIds(), start, end)
+}
+
+func (s *Server) WatchEvents(req *api.WatchEventsRequest, stream api.EventService_WatchEventsServer) error {
+	userID, err := parseUUID(req.GetUserId(), "user_id")
+	if err != nil {
+		return err
+	}
+	changes, err := s.eventService.WatchEvents(stream.Context(), userID)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	for change := range changes {
+		if err := stream.Send(dto.ToAPIEventChange(change)); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Context().Err(); err != nil {
+		return err
+	}
+	// Подписку закрыл брокер: клиент не успевал вычитывать изменения, лента потеряла соединение с базой
+	// или хранилище останавливается. Клиенту нужно досинхронизироваться через SyncEvents
+	return status.Error(codes.Unavailable, "change feed subscription closed")
+}
+
+func (s *Server) SyncEvents(ctx context.Context, req *api.SyncEventsRequest) (*api.SyncEventsResponse, error) {
+	userID, err := parseUUID(req.GetUserId(), "user_id")
 	if err != nil {
 		return nil, err
 	}
+
+	result, err := s.eventService.SyncEvents(ctx, userID, req.GetSyncToken(), int(req.GetLimit()))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return dto.ToAPISyncResult(result), nil
+}
+
+func (s *Server) SearchEvents(ctx context.Context, req *api.SearchEventsRequest) (*api.ListEventsResponse, error) {
+	userID, err := parseOptionalUUID(req.GetUserId(), "user_id")
+	if err != nil {
+		return nil, err
+	}
+	calendarID, err := parseOptionalUUID(req.GetCalendarId(), "calendar_id")
+	if err != nil {
+		return nil, err
+	}
+
+	search := dto.EventSearchData{
+		Query:      req.GetQuery(),
+		UserID:     userID,
+		CalendarID: calendarID,
+		Attendee:   req.GetAttendee(),
+		Limit:      int(req.GetLimit()),
+	}
+	if req.GetStartTime() != nil {
+		search.Start = req.GetStartTime().AsTime()
+	}
+	if req.GetEndTime() != nil {
+		search.End = req.GetEndTime().AsTime()
+	}
+
+	events, err := s.eventService.SearchEvents(ctx, search)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
 	apiEvents := make([]*api.Event, len(events))
 	for i, event := range events {
 		apiEvents[i] = dto.ToAPIEvent(event)
@@ -172,17 +396,53 @@ func (s *Server) ListEventsForWeek(
 	return &api.ListEventsResponse{Events: apiEvents}, nil
 }
 
-func (s *Server) ListEventsForMonth(
+func (s *Server) BatchEvents(ctx context.Context, req *api.BatchEventsRequest) (*api.BatchEventsResponse, error) {
+	operations := make([]dto.EventOperation, len(req.GetOperations()))
+	for i, operation := range req.GetOperations() {
+		id, err := parseOptionalUUID(operation.GetId(), "id")
+		if err != nil {
+			return nil, err
+		}
+		event, err := eventFromAPI(operation.GetEvent())
+		if err != nil {
+			return nil, err
+		}
+		operations[i] = dto.EventOperation{Op: operation.GetOp(), ID: id, Event: event}
+	}
+
+	result, err := s.eventService.BatchEvents(ctx, operations)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return dto.ToAPIEventBatchResult(result), nil
+}
+
+// listEventsByCalendars возвращает события за период из выбранных календарей либо все, если календари не указаны.
+func (s *Server) listEventsByCalendars(
 	ctx context.Context,
-	req *api.ListEventsForMonthRequest,
+	ids []string,
+	start,
+	end time.Time,
 ) (*api.ListEventsResponse, error) {
-	start := req.GetDate().AsTime()
-	end := start.AddDate(0, 1, 0) // Добавляем 1 месяц
-
-	events, err := s.eventService.ListEvents(ctx, start, end)
+	var (
+		events []dto.EventData
+		err    error
+	)
+	if len(ids) == 0 {
+		events, err = s.eventService.ListEvents(ctx, start, end)
+	} else {
+		calendarIDs := make([]uuid.UUID, len(ids))
+		for i, id := range ids {
+			if calendarIDs[i], err = parseUUID(id, "calendar_ids"); err != nil {
+				return nil, err
+			}
+		}
+		events, err = s.eventService.ListEventsByCalendars(ctx, calendarIDs, start, end)
+	}
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
+
 	apiEvents := make([]*api.Event, len(events))
 	for i, event := range events {
 		apiEvents[i] = dto.ToAPIEvent(event)
@@ -194,16 +454,25 @@ func (s *Server) CreateNotification(
 	ctx context.Context,
 	req *api.CreateNotificationRequest,
 ) (*api.CreateNotificationResponse, error) {
+	eventID, err := parseUUID(req.GetEventId(), "event_id")
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseUUID(req.GetUserId(), "user_id")
+	if err != nil {
+		return nil, err
+	}
+
 	notification := dto.NotificationData{
-		EventID: uuid.MustParse(req.GetEventId()),
-		UserID:  uuid.MustParse(req.GetUserId()),
+		EventID: eventID,
+		UserID:  userID,
 		Time:    req.GetTime().AsTime(),
 		Message: req.GetMessage(),
 		Sent:    req.GetSent(),
 	}
 	id, err := s.notificationService.CreateNotification(ctx, notification)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 	return &api.CreateNotificationResponse{Id: id.String()}, nil
 }
@@ -212,17 +481,65 @@ func (s *Server) UpdateNotification(
 	ctx context.Context,
 	req *api.UpdateNotificationRequest,
 ) (*api.UpdateNotificationResponse, error) {
+	id, err := parseUUID(req.GetId(), "id")
+	if err != nil {
+		return nil, err
+	}
+	if paths := req.GetUpdateMask().GetPaths(); len(paths) > 0 {
+		return s.patchNotification(ctx, id, req, paths)
+	}
+
+	eventID, err := parseUUID(req.GetEventId(), "event_id")
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseUUID(req.GetUserId(), "user_id")
+	if err != nil {
+		return nil, err
+	}
 	notification := dto.NotificationData{
-		EventID: uuid.MustParse(req.GetEventId()),
-		UserID:  uuid.MustParse(req.GetUserId()),
+		EventID: eventID,
+		UserID:  userID,
 		Time:    req.GetTime().AsTime(),
 		Message: req.GetMessage(),
 		Sent:    req.GetSent(),
+		Version: req.GetExpectedVersion(),
 	}
-	err := s.notificationService.UpdateNotification(ctx, uuid.MustParse(req.GetId()), notification)
+	err = s.notificationService.UpdateNotification(ctx, id, notification)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.UpdateNotificationResponse{}, nil
+}
+
+// patchNotification обновляет только поля уведомления из update_mask, остальные поля запроса игнорируются.
+func (s *Server) patchNotification(
+	ctx context.Context,
+	id uuid.UUID,
+	req *api.UpdateNotificationRequest,
+	paths []string,
+) (*api.UpdateNotificationResponse, error) {
+	eventID, err := parseOptionalUUID(req.GetEventId(), "event_id")
 	if err != nil {
 		return nil, err
 	}
+	userID, err := parseOptionalUUID(req.GetUserId(), "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	patch := dto.NotificationData{
+		EventID: eventID,
+		UserID:  userID,
+		Time:    req.GetTime().AsTime(),
+		Message: req.GetMessage(),
+		Sent:    req.GetSent(),
+		Version: req.GetExpectedVersion(),
+	}
+	err = s.notificationService.PatchNotification(ctx, id, patch, paths)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
 	return &api.UpdateNotificationResponse{}, nil
 }
 
@@ -230,10 +547,14 @@ func (s *Server) DeleteNotification(
 	ctx context.Context,
 	req *api.DeleteNotificationRequest,
 ) (*api.DeleteNotificationResponse, error) {
-	err := s.notificationService.DeleteNotification(ctx, uuid.MustParse(req.GetId()))
+	id, err := parseUUID(req.GetId(), "id")
 	if err != nil {
 		return nil, err
 	}
+	err = s.notificationService.DeleteNotification(ctx, id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
 	return &api.DeleteNotificationResponse{}, nil
 }
 
@@ -241,10 +562,14 @@ func (s *Server) GetNotification(
 	ctx context.Context,
 	req *api.GetNotificationRequest,
 ) (*api.GetNotificationResponse, error) {
-	notification, err := s.notificationService.GetNotification(ctx, uuid.MustParse(req.GetId()))
+	id, err := parseUUID(req.GetId(), "id")
 	if err != nil {
 		return nil, err
 	}
+	notification, err := s.notificationService.GetNotification(ctx, id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
 	return &api.GetNotificationResponse{Notification: dto.ToAPINotification(notification)}, nil
 }
 
@@ -256,7 +581,7 @@ func (s *Server) ListNotifications(
 	end := req.GetEndTime().AsTime()
 	notifications, err := s.notificationService.ListNotifications(ctx, start, end)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 	apiNotifications := make([]*api.Notification, len(notifications))
 	for i, notification := range notifications {
@@ -264,3 +589,99 @@ func (s *Server) ListNotifications(
 	}
 	return &api.ListNotificationsResponse{Notifications: apiNotifications}, nil
 }
+
+func (s *Server) CreateCalendar(
+	ctx context.Context,
+	req *api.CreateCalendarRequest,
+) (*api.CreateCalendarResponse, error) {
+	userID, err := parseUUID(req.GetUserId(), "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := dto.CalendarData{
+		UserID:                 userID,
+		Name:                   req.GetName(),
+		Color:                  req.GetColor(),
+		DefaultReminderMinutes: int(req.GetDefaultReminderMinutes()),
+		Timezone:               req.GetTimezone(),
+	}
+	id, err := s.calendarService.CreateCalendar(ctx, calendar)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.CreateCalendarResponse{Id: id.String()}, nil
+}
+
+func (s *Server) UpdateCalendar(
+	ctx context.Context,
+	req *api.UpdateCalendarRequest,
+) (*api.UpdateCalendarResponse, error) {
+	id, err := parseUUID(req.GetId(), "id")
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseUUID(req.GetUserId(), "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := dto.CalendarData{
+		UserID:                 userID,
+		Name:                   req.GetName(),
+		Color:                  req.GetColor(),
+		DefaultReminderMinutes: int(req.GetDefaultReminderMinutes()),
+		Timezone:               req.GetTimezone(),
+	}
+	err = s.calendarService.UpdateCalendar(ctx, id, calendar)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.UpdateCalendarResponse{}, nil
+}
+
+func (s *Server) DeleteCalendar(
+	ctx context.Context,
+	req *api.DeleteCalendarRequest,
+) (*api.DeleteCalendarResponse, error) {
+	id, err := parseUUID(req.GetId(), "id")
+	if err != nil {
+		return nil, err
+	}
+	err = s.calendarService.DeleteCalendar(ctx, id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.DeleteCalendarResponse{}, nil
+}
+
+func (s *Server) GetCalendar(ctx context.Context, req *api.GetCalendarRequest) (*api.GetCalendarResponse, error) {
+	id, err := parseUUID(req.GetId(), "id")
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := s.calendarService.GetCalendar(ctx, id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.GetCalendarResponse{Calendar: dto.ToAPICalendar(calendar)}, nil
+}
+
+func (s *Server) ListCalendars(
+	ctx context.Context,
+	req *api.ListCalendarsRequest,
+) (*api.ListCalendarsResponse, error) {
+	userID, err := parseUUID(req.GetUserId(), "user_id")
+	if err != nil {
+		return nil, err
+	}
+	calendars, err := s.calendarService.ListCalendars(ctx, userID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	apiCalendars := make([]*api.Calendar, len(calendars))
+	for i, calendar := range calendars {
+		apiCalendars[i] = dto.ToAPICalendar(calendar)
+	}
+	return &api.ListCalendarsResponse{Calendars: apiCalendars}, nil
+}
@@ -13,9 +13,14 @@ import (
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/services"
 	memorystorage "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/version"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -30,6 +35,7 @@ func TestGRPCServer(t *testing.T) { //nolint:funlen
 	store := memorystorage.New()
 	eventService := services.NewEventService(store)
 	notificationService := services.NewNotificationService(store)
+	calendarService := services.NewCalendarService(store)
 	healthService := services.NewHealthService(store)
 
 	logInstance, err := logger.New(config.LoggerConfig{
@@ -43,9 +49,11 @@ func TestGRPCServer(t *testing.T) { //nolint:funlen
 	grpcServer, err := New(
 		eventService,
 		notificationService,
+		calendarService,
 		healthService,
 		logInstance,
 		config.GRPCServerConfig{Address: addr},
+		nil,
 	)
 	require.NoError(t, err)
 
@@ -73,6 +81,7 @@ func TestGRPCServer(t *testing.T) { //nolint:funlen
 
 	eventClient := api.NewEventServiceClient(conn)
 	notificationClient := api.NewNotificationServiceClient(conn)
+	calendarClient := api.NewCalendarServiceClient(conn)
 
 	t.Run("Events", func(t *testing.T) {
 		t.Run("CreateEvent", func(t *testing.T) {
@@ -278,5 +287,282 @@ func TestGRPCServer(t *testing.T) { //nolint:funlen
 		})
 	})
 
-	grpcServer.Stop(context.Background())
+	t.Run("WatchEvents", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		userID := uuid.New().String()
+		stream, err := eventClient.WatchEvents(ctx, &api.WatchEventsRequest{UserId: userID})
+		require.NoError(t, err)
+
+		// Подписка регистрируется на сервере асинхронно, поэтому создаем события, пока одно из них не придет в поток
+		go func() {
+			ticker := time.NewTicker(100 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					_, _ = eventClient.CreateEvent(ctx, &api.CreateEventRequest{
+						Title:     "Watched Event",
+						StartTime: timestamppb.Now(),
+						EndTime:   timestamppb.New(time.Now().Add(time.Hour)),
+						UserId:    userID,
+					})
+				}
+			}
+		}()
+
+		change, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, api.ChangeType_CHANGE_TYPE_CREATED, change.GetType())
+		require.Equal(t, "Watched Event", change.GetEvent().GetTitle())
+		require.Equal(t, userID, change.GetEvent().GetUserId())
+	})
+
+	t.Run("VersionConflict", func(t *testing.T) {
+		createReq := &api.CreateEventRequest{
+			Title:     "Versioned Event",
+			StartTime: timestamppb.Now(),
+			EndTime:   timestamppb.New(time.Now().Add(time.Hour)),
+			UserId:    uuid.New().String(),
+		}
+		createResp, err := eventClient.CreateEvent(context.Background(), createReq)
+		require.NoError(t, err)
+
+		getResp, err := eventClient.GetEvent(context.Background(), &api.GetEventRequest{Id: createResp.Id})
+		require.NoError(t, err)
+
+		updateReq := &api.UpdateEventRequest{
+			Id:              createResp.Id,
+			Title:           "Updated Event",
+			StartTime:       createReq.StartTime,
+			EndTime:         createReq.EndTime,
+			UserId:          createReq.UserId,
+			ExpectedVersion: getResp.GetEvent().GetVersion(),
+		}
+		_, err = eventClient.UpdateEvent(context.Background(), updateReq)
+		require.NoError(t, err)
+
+		_, err = eventClient.UpdateEvent(context.Background(), updateReq)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		updateReq.Id = uuid.New().String()
+		_, err = eventClient.UpdateEvent(context.Background(), updateReq)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("UpdateEventWithMask", func(t *testing.T) {
+		createReq := &api.CreateEventRequest{
+			Title:       "Masked Event",
+			Description: "Kept Description",
+			StartTime:   timestamppb.Now(),
+			EndTime:     timestamppb.New(time.Now().Add(time.Hour)),
+			UserId:      uuid.New().String(),
+		}
+		createResp, err := eventClient.CreateEvent(context.Background(), createReq)
+		require.NoError(t, err)
+
+		_, err = eventClient.UpdateEvent(context.Background(), &api.UpdateEventRequest{
+			Id:         createResp.Id,
+			Title:      "Renamed Event",
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+		})
+		require.NoError(t, err)
+
+		getResp, err := eventClient.GetEvent(context.Background(), &api.GetEventRequest{Id: createResp.Id})
+		require.NoError(t, err)
+		require.Equal(t, "Renamed Event", getResp.GetEvent().GetTitle())
+		require.Equal(t, "Kept Description", getResp.GetEvent().GetDescription())
+		require.Equal(t, createReq.UserId, getResp.GetEvent().GetUserId())
+
+		_, err = eventClient.UpdateEvent(context.Background(), &api.UpdateEventRequest{
+			Id:         createResp.Id,
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"unknown"}},
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("SearchEvents", func(t *testing.T) {
+		userID := uuid.New().String()
+		createResp, err := eventClient.CreateEvent(context.Background(), &api.CreateEventRequest{
+			Title:     "Architecture sync",
+			StartTime: timestamppb.Now(),
+			EndTime:   timestamppb.New(time.Now().Add(time.Hour)),
+			UserId:    userID,
+			Attendees: []string{"carol@example.com"},
+		})
+		require.NoError(t, err)
+
+		searchResp, err := eventClient.SearchEvents(context.Background(), &api.SearchEventsRequest{
+			Query:    "architecture",
+			UserId:   userID,
+			Attendee: "carol@example.com",
+		})
+		require.NoError(t, err)
+		require.Len(t, searchResp.GetEvents(), 1)
+		require.Equal(t, createResp.Id, searchResp.GetEvents()[0].GetId())
+		require.Equal(t, []string{"carol@example.com"}, searchResp.GetEvents()[0].GetAttendees())
+	})
+
+	t.Run("BatchEvents", func(t *testing.T) {
+		userID := uuid.New().String()
+		newEvent := &api.Event{
+			Title:     "Imported Event",
+			StartTime: timestamppb.Now(),
+			EndTime:   timestamppb.New(time.Now().Add(time.Hour)),
+			UserId:    userID,
+		}
+
+		batchResp, err := eventClient.BatchEvents(context.Background(), &api.BatchEventsRequest{
+			Operations: []*api.EventOperation{
+				{Op: dto.EventOperationCreate, Event: newEvent},
+				{Op: dto.EventOperationCreate, Event: newEvent},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, batchResp.GetApplied())
+		require.Len(t, batchResp.GetResults(), 2)
+
+		createdID := batchResp.GetResults()[0].GetId()
+		batchResp, err = eventClient.BatchEvents(context.Background(), &api.BatchEventsRequest{
+			Operations: []*api.EventOperation{
+				{Op: dto.EventOperationDelete, Id: createdID},
+				{Op: "merge", Event: newEvent},
+			},
+		})
+		require.NoError(t, err)
+		require.False(t, batchResp.GetApplied())
+		require.Equal(t, dto.OperationFailed, batchResp.GetResults()[1].GetStatus())
+
+		_, err = eventClient.GetEvent(context.Background(), &api.GetEventRequest{Id: createdID})
+		require.NoError(t, err)
+	})
+
+	t.Run("SyncEvents", func(t *testing.T) {
+		userID := uuid.New().String()
+		createResp, err := eventClient.CreateEvent(context.Background(), &api.CreateEventRequest{
+			Title:     "Synced Event",
+			StartTime: timestamppb.Now(),
+			EndTime:   timestamppb.New(time.Now().Add(time.Hour)),
+			UserId:    userID,
+		})
+		require.NoError(t, err)
+
+		syncResp, err := eventClient.SyncEvents(context.Background(), &api.SyncEventsRequest{UserId: userID})
+		require.NoError(t, err)
+		require.Len(t, syncResp.GetEvents(), 1)
+		require.Equal(t, createResp.Id, syncResp.GetEvents()[0].GetId())
+		require.NotZero(t, syncResp.GetEvents()[0].GetVersion())
+		require.NotEmpty(t, syncResp.GetNextSyncToken())
+
+		_, err = eventClient.DeleteEvent(context.Background(), &api.DeleteEventRequest{Id: createResp.Id})
+		require.NoError(t, err)
+
+		syncResp, err = eventClient.SyncEvents(context.Background(), &api.SyncEventsRequest{
+			UserId:    userID,
+			SyncToken: syncResp.GetNextSyncToken(),
+		})
+		require.NoError(t, err)
+		require.Empty(t, syncResp.GetEvents())
+		require.Equal(t, []string{createResp.Id}, syncResp.GetDeletedIds())
+
+		_, err = eventClient.SyncEvents(context.Background(), &api.SyncEventsRequest{
+			UserId:    userID,
+			SyncToken: "broken",
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Calendars", func(t *testing.T) {
+		userID := uuid.New().String()
+
+		createResp, err := calendarClient.CreateCalendar(context.Background(), &api.CreateCalendarRequest{
+			UserId:                 userID,
+			Name:                   "Work",
+			Color:                  "#3366ff",
+			DefaultReminderMinutes: 15,
+			Timezone:               "Europe/Moscow",
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, createResp.Id)
+
+		getResp, err := calendarClient.GetCalendar(context.Background(), &api.GetCalendarRequest{Id: createResp.Id})
+		require.NoError(t, err)
+		require.Equal(t, "Work", getResp.GetCalendar().GetName())
+
+		startTime := time.Now().Truncate(24 * time.Hour)
+		_, err = eventClient.CreateEvent(context.Background(), &api.CreateEventRequest{
+			Title:      "Work Event",
+			StartTime:  timestamppb.New(startTime.Add(time.Hour)),
+			EndTime:    timestamppb.New(startTime.Add(2 * time.Hour)),
+			UserId:     userID,
+			CalendarId: createResp.Id,
+		})
+		require.NoError(t, err)
+
+		listResp, err := eventClient.ListEventsForDate(context.Background(), &api.ListEventsForDateRequest{
+			Date:        timestamppb.New(startTime),
+			CalendarIds: []string{createResp.Id},
+		})
+		require.NoError(t, err)
+		require.Len(t, listResp.GetEvents(), 1)
+		require.Equal(t, createResp.Id, listResp.GetEvents()[0].GetCalendarId())
+
+		calendarsResp, err := calendarClient.ListCalendars(
+			context.Background(),
+			&api.ListCalendarsRequest{UserId: userID},
+		)
+		require.NoError(t, err)
+		require.Len(t, calendarsResp.GetCalendars(), 1)
+
+		_, err = calendarClient.DeleteCalendar(context.Background(), &api.DeleteCalendarRequest{Id: createResp.Id})
+		require.NoError(t, err)
+	})
+
+	t.Run("Health", func(t *testing.T) {
+		healthClient := healthpb.NewHealthClient(conn)
+		for _, service := range []string{"", "api.EventService"} {
+			resp, err := healthClient.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			require.NoError(t, err)
+			require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+		}
+
+		_, err := healthClient.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "api.Unknown"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("InvalidID", func(t *testing.T) {
+		_, err := eventClient.GetEvent(context.Background(), &api.GetEventRequest{Id: "42"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, "invalid id", status.Convert(err).Message())
+
+		_, err = eventClient.CreateEvent(context.Background(), &api.CreateEventRequest{
+			Title:      "Event",
+			StartTime:  timestamppb.Now(),
+			EndTime:    timestamppb.New(time.Now().Add(time.Hour)),
+			UserId:     uuid.NewString(),
+			CalendarId: "not-a-uuid",
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, "invalid calendar_id", status.Convert(err).Message())
+
+		_, err = eventClient.UpdateEvent(context.Background(), &api.UpdateEventRequest{
+			Id:         uuid.NewString(),
+			UserId:     uuid.NewString(),
+			CalendarId: "not-a-uuid",
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		resp, err := api.NewVersionServiceClient(conn).GetVersion(context.Background(), &api.GetVersionRequest{})
+		require.NoError(t, err)
+		require.Equal(t, version.Get().Release, resp.GetRelease())
+		require.Equal(t, version.Get().GitHash, resp.GetGitHash())
+		require.NotEmpty(t, resp.GetGoVersion())
+	})
+
+	require.NoError(t, grpcServer.Stop(context.Background()))
 }
@@ -0,0 +1,19 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/version"
+)
+
+// GetVersion возвращает сведения о сборке сервера.
+func (s *Server) GetVersion(context.Context, *api.GetVersionRequest) (*api.GetVersionResponse, error) {
+	info := version.Get()
+	return &api.GetVersionResponse{
+		Release:   info.Release,
+		BuildDate: info.BuildDate,
+		GitHash:   info.GitHash,
+		GoVersion: info.GoVersion,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+)
+
+// createCalendarHandler создает новый календарь пользователя.
+func (s *Server) createCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	var calendarRequest dto.CalendarData
+
+	if err := json.NewDecoder(r.Body).Decode(&calendarRequest); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.calendarService.CreateCalendar(r.Context(), calendarRequest)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	s.writeData(w, r, http.StatusOK, map[string]interface{}{"id": id})
+}
+
+// updateCalendarHandler обновляет существующий календарь.
+func (s *Server) updateCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	var calendarRequest dto.CalendarData
+
+	if err := json.NewDecoder(r.Body).Decode(&calendarRequest); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	err = s.calendarService.UpdateCalendar(r.Context(), id, calendarRequest)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	s.writeData(w, r, http.StatusNoContent, nil)
+}
+
+// deleteCalendarHandler удаляет календарь вместе с его событиями.
+func (s *Server) deleteCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	err = s.calendarService.DeleteCalendar(r.Context(), id)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	s.writeData(w, r, http.StatusNoContent, nil)
+}
+
+// getCalendarHandler получает календарь по ID.
+func (s *Server) getCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	calendar, err := s.calendarService.GetCalendar(r.Context(), id)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	s.writeData(w, r, http.StatusOK, calendar)
+}
+
+// listCalendarsHandler получает список календарей пользователя.
+func (s *Server) listCalendarsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.URL.Query().Get("userId"))
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "Invalid userId format")
+		return
+	}
+
+	calendars, err := s.calendarService.ListCalendars(r.Context(), userID)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	s.writeData(w, r, http.StatusOK, calendars)
+}
@@ -0,0 +1,25 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+)
+
+const legacyAPIKey contextKey = "legacyAPI"
+
+// DeprecationMiddleware помечает запросы к устаревшим путям без версии. Ответ получает заголовок Deprecation
+// и ссылку на тот же путь в v1, а формат ответа остается прежним (Response).
+func DeprecationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "</v1"+r.URL.Path+`>; rel="successor-version"`)
+		ctx := context.WithValue(r.Context(), legacyAPIKey, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// isLegacyRequest сообщает, пришел ли запрос на устаревший путь без версии.
+func isLegacyRequest(r *http.Request) bool {
+	legacy, _ := r.Context().Value(legacyAPIKey).(bool)
+	return legacy
+}
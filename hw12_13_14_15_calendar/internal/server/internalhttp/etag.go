@@ -0,0 +1,57 @@
+package internalhttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errInvalidIfMatch = errors.New("invalid If-Match header: expected a single strong ETag")
+
+// formatETag возвращает строгий ETag для версии записи.
+func formatETag(version int64) string {
+	return fmt.Sprintf("%q", strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch возвращает ожидаемую версию из заголовка If-Match.
+// Отсутствующий заголовок и "*" означают обновление без проверки версии, ok в этом случае false.
+func parseIfMatch(r *http.Request) (version int64, ok bool, err error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, false, nil
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil || !strings.HasPrefix(value, `"`) {
+		return 0, false, errInvalidIfMatch
+	}
+	version, err = strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false, errInvalidIfMatch
+	}
+	return version, true, nil
+}
+
+// errorStatus подбирает HTTP-статус для ошибки сервиса.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrEventNotFound),
+		errors.Is(err, storage.ErrNotificationNotFound),
+		errors.Is(err, storage.ErrCalendarNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, storage.ErrIdempotencyKeyReused):
+		return http.StatusConflict
+	case status.Code(err) == codes.InvalidArgument:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
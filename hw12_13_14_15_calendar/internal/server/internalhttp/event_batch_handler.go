@@ -0,0 +1,30 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+)
+
+// batchEventsHandler применяет операции над событиями в одной транзакции; если пакет не применен, отвечает 422.
+func (s *Server) batchEventsHandler(w http.ResponseWriter, r *http.Request) {
+	var batchRequest dto.EventBatchRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&batchRequest); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := s.eventService.BatchEvents(r.Context(), batchRequest.Operations)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	code := http.StatusOK
+	if !result.Applied {
+		code = http.StatusUnprocessableEntity
+	}
+	s.writeData(w, r, code, result)
+}
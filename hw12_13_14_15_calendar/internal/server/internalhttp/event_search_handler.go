@@ -0,0 +1,65 @@
+package internalhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+)
+
+// searchEventsHandler ищет события по словам запроса и необязательным фильтрам.
+func (s *Server) searchEventsHandler(w http.ResponseWriter, r *http.Request) {
+	search, err := parseEventSearch(r)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := s.eventService.SearchEvents(r.Context(), search)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	s.writeData(w, r, http.StatusOK, events)
+}
+
+// parseEventSearch разбирает необязательные параметры поиска событий.
+func parseEventSearch(r *http.Request) (dto.EventSearchData, error) {
+	query := r.URL.Query()
+	search := dto.EventSearchData{
+		Query:    query.Get("q"),
+		Attendee: query.Get("attendee"),
+	}
+
+	var err error
+	if value := query.Get("userId"); value != "" {
+		if search.UserID, err = uuid.Parse(value); err != nil {
+			return dto.EventSearchData{}, fmt.Errorf("invalid userId %q: %w", value, err)
+		}
+	}
+	if value := query.Get("calendarId"); value != "" {
+		if search.CalendarID, err = uuid.Parse(value); err != nil {
+			return dto.EventSearchData{}, fmt.Errorf("invalid calendarId %q: %w", value, err)
+		}
+	}
+	if value := query.Get("startTime"); value != "" {
+		if search.Start, err = time.Parse(time.RFC3339, value); err != nil {
+			return dto.EventSearchData{}, fmt.Errorf("invalid startTime %q: %w", value, err)
+		}
+	}
+	if value := query.Get("endTime"); value != "" {
+		if search.End, err = time.Parse(time.RFC3339, value); err != nil {
+			return dto.EventSearchData{}, fmt.Errorf("invalid endTime %q: %w", value, err)
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if search.Limit, err = strconv.Atoi(value); err != nil {
+			return dto.EventSearchData{}, fmt.Errorf("invalid limit %q: %w", value, err)
+		}
+	}
+	return search, nil
+}
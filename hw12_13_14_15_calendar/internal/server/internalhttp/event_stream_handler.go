@@ -0,0 +1,75 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// streamKeepAliveInterval период отправки комментариев, не дающих прокси закрыть простаивающее соединение.
+const streamKeepAliveInterval = 15 * time.Second
+
+// streamEventsHandler отдает поток изменений событий пользователя в формате server-sent events.
+func (s *Server) streamEventsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.URL.Query().Get("userId"))
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "Invalid userId format")
+		return
+	}
+
+	changes, err := s.eventService.WatchEvents(r.Context(), userID)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	// Поток живет дольше WriteTimeout сервера, поэтому снимаем дедлайн записи для этого соединения
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		s.logger.Errorf("on reset write deadline for event stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		s.logger.Errorf("on flush event stream: %v", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(change)
+			if err != nil {
+				s.logger.Errorf("on marshal event change: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", change.Type, data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.shutdown:
+			return
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package internalhttp
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// syncEventsHandler возвращает изменения событий пользователя после syncToken.
+func (s *Server) syncEventsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	userID, err := uuid.Parse(query.Get("userId"))
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "Invalid userId format")
+		return
+	}
+
+	var limit int
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			s.writeError(w, r, http.StatusBadRequest, "Invalid limit format")
+			return
+		}
+	}
+
+	result, err := s.eventService.SyncEvents(r.Context(), userID, query.Get("syncToken"), limit)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	s.writeData(w, r, http.StatusOK, result)
+}
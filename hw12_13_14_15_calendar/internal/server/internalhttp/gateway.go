@@ -0,0 +1,115 @@
+package internalhttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+	grpcserver "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/server/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// newGateway строит REST API по HTTP-аннотациям proto-описаний: запросы транслируются в вызовы
+// gRPC-сервера через conn, ошибки отдаются в формате problem+json, как у обработчиков internalhttp.
+func (s *Server) newGateway(conn *grpc.ClientConn) (http.Handler, error) {
+	gateway := runtime.NewServeMux(
+		// Пустые поля и списки попадают в ответ, чтобы его форма не зависела от данных
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{EmitUnpopulated: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithErrorHandler(s.gatewayErrorHandler),
+		runtime.WithRoutingErrorHandler(s.gatewayRoutingErrorHandler),
+	)
+
+	register := map[string]func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error{
+		"EventService":        api.RegisterEventServiceHandler,
+		"NotificationService": api.RegisterNotificationServiceHandler,
+		"CalendarService":     api.RegisterCalendarServiceHandler,
+	}
+	for name, handler := range register {
+		if err := handler(context.Background(), gateway, conn); err != nil {
+			return nil, fmt.Errorf("on registering %s gateway: %w", name, err)
+		}
+	}
+	return gateway, nil
+}
+
+// gatewayHeaderMatcher дополняет заголовки, которые grpc-gateway передает в метаданные вызова,
+// ключом идемпотентности. Отметка вызовов gateway из запроса клиента не передается.
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if textproto.CanonicalMIMEHeaderKey(key) == idempotencyKeyHeader {
+		return grpcserver.IdempotencyKeyMetadata, true
+	}
+	name, ok := runtime.DefaultHeaderMatcher(key)
+	if strings.EqualFold(name, ratelimit.GatewayMetadata) {
+		return "", false
+	}
+	return name, ok
+}
+
+// gatewayErrorHandler отдает ошибку gRPC-вызова со статусом, который вернул бы обработчик internalhttp.
+func (s *Server) gatewayErrorHandler(
+	_ context.Context,
+	_ *runtime.ServeMux,
+	_ runtime.Marshaler,
+	w http.ResponseWriter,
+	r *http.Request,
+	err error,
+) {
+	st := status.Convert(err)
+	httpStatus := runtime.HTTPStatusFromCode(st.Code())
+	// Конфликт версий gRPC-сервер возвращает как FailedPrecondition
+	if st.Code() == codes.FailedPrecondition {
+		httpStatus = http.StatusPreconditionFailed
+	}
+	s.writeError(w, r, httpStatus, st.Message())
+}
+
+func (s *Server) gatewayRoutingErrorHandler(
+	_ context.Context,
+	_ *runtime.ServeMux,
+	_ runtime.Marshaler,
+	w http.ResponseWriter,
+	r *http.Request,
+	httpStatus int,
+) {
+	switch httpStatus {
+	case http.StatusNotFound:
+		s.writeError(w, r, httpStatus, "no route for "+r.URL.Path)
+	case http.StatusMethodNotAllowed:
+		s.writeError(w, r, httpStatus, "method "+r.Method+" is not allowed for "+r.URL.Path)
+	default:
+		s.writeError(w, r, httpStatus, http.StatusText(httpStatus))
+	}
+}
+
+// gatewayStreamHandler готовит соединение к долгоживущему потоку: снимает дедлайн записи и
+// завершает поток при остановке сервера.
+func (s *Server) gatewayStreamHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			s.logger.Errorf("on reset write deadline for event stream: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		go func() {
+			select {
+			case <-s.shutdown:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
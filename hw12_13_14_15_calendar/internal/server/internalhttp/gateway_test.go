@@ -0,0 +1,259 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+	grpcserver "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/server/grpc"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/services"
+	memorystorage "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newTestGateway запускает gRPC-сервер и возвращает HTTP-сервер, обслуживающий /v1 через grpc-gateway.
+// Если задан limiter, оба сервера ограничивают частоту запросов, как в приложении.
+func newTestGateway(t *testing.T, limiter *ratelimit.Limiter) http.Handler {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	logInstance, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+
+	store := memorystorage.New()
+	eventService := services.NewEventService(store)
+	notificationService := services.NewNotificationService(store)
+	calendarService := services.NewCalendarService(store)
+	healthService := services.NewHealthService(store)
+
+	grpcServer, err := grpcserver.New(
+		eventService,
+		notificationService,
+		calendarService,
+		healthService,
+		logInstance,
+		config.GRPCServerConfig{Address: addr},
+		limiter,
+	)
+	require.NoError(t, err)
+	go func() { _ = grpcServer.Start(context.Background()) }()
+	t.Cleanup(func() { _ = grpcServer.Stop(context.Background()) })
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if limiter != nil {
+		opts = append(opts, grpcserver.GatewayDialOptions(limiter)...)
+	}
+	conn, err := grpc.Dial(addr, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	server, err := New(
+		config.HTTPServerConfig{Gateway: true},
+		logInstance,
+		eventService,
+		notificationService,
+		calendarService,
+		healthService,
+		conn,
+		limiter,
+	)
+	require.NoError(t, err)
+	handler := server.httpServer.Handler
+
+	// Ждем, пока gRPC-сервер начнет принимать соединения
+	require.Eventually(t, func() bool {
+		recorder := serve(handler, http.MethodGet, "/v1/calendars?userId="+uuid.NewString(), "")
+		return recorder.Code == http.StatusOK
+	}, 5*time.Second, 50*time.Millisecond)
+	return handler
+}
+
+func TestServer_Gateway(t *testing.T) {
+	handler := newTestGateway(t, nil)
+	userID := uuid.New()
+
+	body := `{"title":"Planning","startTime":"2024-07-01T10:00:00Z","endTime":"2024-07-01T11:00:00Z",` +
+		`"userId":"` + userID.String() + `"}`
+	recorder := serve(handler, http.MethodPost, "/v1/events", body)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+
+	// response_body отдает событие без обертки GetEventResponse
+	recorder = serve(handler, http.MethodGet, "/v1/events/"+created.ID, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &event))
+	assert.Equal(t, "Planning", event["title"])
+	assert.Equal(t, userID.String(), event["userId"])
+	assert.Equal(t, "1", event["version"])
+
+	recorder = serve(handler, http.MethodPatch, "/v1/events/"+created.ID, `{"title":"Retro","updateMask":"title"}`)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	// /v1/events/day не должен совпасть с /v1/events/{id}
+	recorder = serve(handler, http.MethodGet, "/v1/events/day?date=2024-07-01T00:00:00Z", "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var events []map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &events))
+	require.Len(t, events, 1)
+	assert.Equal(t, "Retro", events[0]["title"])
+
+	recorder = serve(handler, http.MethodGet, "/v1/events/day?date=2024-07-02T00:00:00Z", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `[]`, recorder.Body.String())
+
+	recorder = serve(handler, http.MethodDelete, "/v1/events/"+created.ID, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	recorder = serve(handler, http.MethodGet, "/v1/events/"+created.ID, "")
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	problem := decodeProblem(t, recorder)
+	assert.Equal(t, CodeNotFound, problem.Code)
+	assert.Equal(t, "event not found", problem.Detail)
+	assert.Equal(t, "/v1/events/"+created.ID, problem.Instance)
+	assert.NotEmpty(t, problem.RequestID)
+
+	// Пути без версии по-прежнему обслуживают обработчики internalhttp
+	recorder = serve(handler, http.MethodGet, "/events/"+created.ID, "")
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "true", recorder.Header().Get("Deprecation"))
+}
+
+func TestServer_GatewayProblems(t *testing.T) {
+	handler := newTestGateway(t, nil)
+
+	body := `{"title":"Planning","startTime":"2024-07-01T10:00:00Z","endTime":"2024-07-01T11:00:00Z",` +
+		`"userId":"` + uuid.NewString() + `"}`
+	recorder := serve(handler, http.MethodPost, "/v1/events", body)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var created struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+		detail string
+	}{
+		{
+			name:   "invalid path parameter",
+			method: http.MethodGet,
+			target: "/v1/events/42",
+			status: http.StatusBadRequest,
+			code:   CodeValidationFailed,
+			detail: "invalid id",
+		},
+		{
+			name:   "invalid body",
+			method: http.MethodPost,
+			target: "/v1/events",
+			body:   `{"title":1}`,
+			status: http.StatusBadRequest,
+			code:   CodeValidationFailed,
+		},
+		{
+			name:   "service validation",
+			method: http.MethodPost,
+			target: "/v1/events",
+			body:   `{"startTime":"2024-07-01T11:00:00Z","endTime":"2024-07-01T10:00:00Z","userId":"` + uuid.NewString() + `"}`,
+			status: http.StatusBadRequest,
+			code:   CodeValidationFailed,
+		},
+		{
+			name:   "version conflict",
+			method: http.MethodPatch,
+			target: "/v1/events/" + created.ID,
+			body:   `{"title":"Retro","updateMask":"title","expectedVersion":"5"}`,
+			status: http.StatusPreconditionFailed,
+			code:   CodeVersionConflict,
+		},
+		{
+			name:   "unknown route",
+			method: http.MethodGet,
+			target: "/v1/unknown",
+			status: http.StatusNotFound,
+			code:   CodeNotFound,
+		},
+		{
+			name:   "method not allowed",
+			method: http.MethodPost,
+			target: "/v1/events/day",
+			status: http.StatusMethodNotAllowed,
+			code:   CodeMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serve(handler, tt.method, tt.target, tt.body)
+			require.Equal(t, tt.status, recorder.Code, recorder.Body.String())
+
+			problem := decodeProblem(t, recorder)
+			assert.Equal(t, tt.status, problem.Status)
+			assert.Equal(t, tt.code, problem.Code)
+			if tt.detail != "" {
+				assert.Equal(t, tt.detail, problem.Detail)
+			}
+		})
+	}
+}
+
+func TestServer_GatewayRateLimit(t *testing.T) {
+	// Ограничение gRPC строже HTTP: если бы вызов gateway расходовал и его, второй запрос был бы отклонен
+	limiter, err := ratelimit.New(config.RateLimitConfig{
+		Enabled: true,
+		Rate:    0.001,
+		Burst:   100,
+		APIKeys: []config.Secret{"secret"},
+		Routes: []config.RateLimitRoute{
+			{Pattern: "GET /v1/calendars", Rate: 0.001, Burst: 3},
+			{Pattern: "/api.CalendarService/*", Rate: 0.001, Burst: 1},
+		},
+	}, ratelimit.NewMemoryStore())
+	require.NoError(t, err)
+	handler := newTestGateway(t, limiter)
+
+	list := func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/v1/calendars?userId="+uuid.NewString(), nil)
+		request.Header.Set("Authorization", "Bearer secret")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	for remaining := 2; remaining >= 0; remaining-- {
+		recorder := list()
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		assert.Equal(t, strconv.Itoa(remaining), recorder.Header().Get("RateLimit-Remaining"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, list().Code)
+}
@@ -0,0 +1,101 @@
+package internalhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+)
+
+func init() {
+	// Формат uuid не входит в набор kin-openapi по умолчанию, проверяем его так же, как обработчики
+	openapi3.DefineStringFormatCallback("uuid", func(value string) error {
+		_, err := uuid.Parse(value)
+		return err
+	})
+	openapi3filter.RegisterBodyDecoder(mergePatchContentType, openapi3filter.RegisteredBodyDecoder("application/json"))
+}
+
+// newContractRouter загружает встроенный контракт api/openapi.yaml и строит по нему маршрутизатор
+// для проверки запросов.
+func newContractRouter() (routers.Router, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(api.OpenAPISpec)
+	if err != nil {
+		return nil, fmt.Errorf("on loading OpenAPI spec: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("on validating OpenAPI spec: %w", err)
+	}
+	return gorillamux.NewRouter(doc)
+}
+
+// openAPIValidationMiddleware отклоняет запросы, которые не соответствуют контракту, с кодом validation_failed.
+// Запросы к путям, которых нет в контракте, передаются дальше без проверки.
+func (s *Server) openAPIValidationMiddleware(contract routers.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := contract.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    &openapi3filter.Options{MultiError: true},
+			})
+			if err != nil {
+				problem := NewProblem(http.StatusBadRequest, "request does not match the API contract")
+				problem.Errors = validationErrors(err, "request")
+				s.writeProblem(w, r, problem)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validationErrors раскладывает ошибку проверки запроса на отдельные нарушения с указанием места:
+// параметра или поля тела запроса.
+func validationErrors(err error, location string) []string {
+	switch e := err.(type) { //nolint:errorlint
+	case openapi3.MultiError:
+		var messages []string
+		for _, item := range e {
+			messages = append(messages, validationErrors(item, location)...)
+		}
+		return messages
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			location = fmt.Sprintf("%s parameter %q", e.Parameter.In, e.Parameter.Name)
+		case e.RequestBody != nil:
+			location = "request body"
+		}
+		if e.Err == nil {
+			return []string{location + ": " + e.Reason}
+		}
+		return validationErrors(e.Err, location)
+	case *openapi3.SchemaError:
+		if pointer := e.JSONPointer(); len(pointer) > 0 {
+			location += " /" + strings.Join(pointer, "/")
+		}
+		// Ошибки составных схем (allOf) содержат нарушения отдельных схем в Origin
+		switch e.Origin.(type) { //nolint:errorlint
+		case openapi3.MultiError, *openapi3.SchemaError:
+			return validationErrors(e.Origin, location)
+		}
+		return []string{location + ": " + e.Reason}
+	default:
+		return []string{location + ": " + err.Error()}
+	}
+}
@@ -0,0 +1,139 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+)
+
+// Поля JSON, которые можно передать в merge patch, и соответствующие им поля сервисов.
+// Ключи id и version не являются изменяемыми полями: version используется как ожидаемая версия.
+var (
+	eventPatchFields = map[string]string{
+		"title":       "title",
+		"description": "description",
+		"startTime":   "start_time",
+		"endTime":     "end_time",
+		"userId":      "user_id",
+		"calendarId":  "calendar_id",
+		"attendees":   "attendees",
+	}
+	notificationPatchFields = map[string]string{
+		"eventId": "event_id",
+		"userId":  "user_id",
+		"time":    "time",
+		"message": "message",
+		"sent":    "sent",
+	}
+)
+
+// mergePatchContentType тип тела запроса по RFC 7396.
+const mergePatchContentType = "application/merge-patch+json"
+
+// decodeMergePatch разбирает тело JSON Merge Patch в target и возвращает список изменяемых полей.
+// Значение null сбрасывает поле в нулевое значение.
+func decodeMergePatch(r *http.Request, target interface{}, fields map[string]string) ([]string, int, error) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (contentType != mergePatchContentType && contentType != "application/json") {
+		return nil, http.StatusUnsupportedMediaType, fmt.Errorf("content type must be %s", mergePatchContentType)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	patchFields := make([]string, 0, len(raw))
+	for key := range raw {
+		if key == "id" || key == "version" {
+			continue
+		}
+		field, ok := fields[key]
+		if !ok {
+			return nil, http.StatusBadRequest, fmt.Errorf("field %q cannot be patched", key)
+		}
+		patchFields = append(patchFields, field)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return patchFields, http.StatusOK, nil
+}
+
+// patchEventHandler обновляет только переданные поля события (JSON Merge Patch, RFC 7396).
+func (s *Server) patchEventHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	var patch dto.EventData
+	fields, code, err := decodeMergePatch(r, &patch, eventPatchFields)
+	if err != nil {
+		s.writeError(w, r, code, err.Error())
+		return
+	}
+
+	version, ok, err := parseIfMatch(r)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ok {
+		patch.Version = version
+	}
+
+	err = s.eventService.PatchEvent(r.Context(), id, patch, fields)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	s.writeData(w, r, http.StatusNoContent, nil)
+}
+
+// patchNotificationHandler обновляет только переданные поля уведомления (JSON Merge Patch, RFC 7396).
+func (s *Server) patchNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	var patch dto.NotificationData
+	fields, code, err := decodeMergePatch(r, &patch, notificationPatchFields)
+	if err != nil {
+		s.writeError(w, r, code, err.Error())
+		return
+	}
+
+	version, ok, err := parseIfMatch(r)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ok {
+		patch.Version = version
+	}
+
+	err = s.notificationService.PatchNotification(r.Context(), id, patch, fields)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	s.writeData(w, r, http.StatusNoContent, nil)
+}
@@ -0,0 +1,70 @@
+package internalhttp
+
+import (
+	"net/http"
+)
+
+// problemContentType тип ответа с ошибкой по RFC 7807.
+const problemContentType = "application/problem+json"
+
+// Машинно-читаемые коды ошибок API, поле code в Problem.
+const (
+	CodeValidationFailed     = "validation_failed"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeVersionConflict      = "version_conflict"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeRateLimited          = "rate_limited"
+	CodeInternalError        = "internal_error"
+	CodeServiceUnavailable   = "service_unavailable"
+)
+
+// Problem ошибка API в формате RFC 7807 с расширениями code, requestId и errors.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// RequestID совпадает с requestId в журнале запросов
+	RequestID string `json:"requestId"`
+	// Errors перечисляет нарушения контракта, найденные при проверке запроса
+	Errors []string `json:"errors,omitempty"`
+}
+
+// NewProblem возвращает ошибку для HTTP-статуса; код ошибки определяется статусом.
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   problemCode(status),
+	}
+}
+
+// problemCode подбирает машинно-читаемый код для HTTP-статуса.
+func problemCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeValidationFailed
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusPreconditionFailed:
+		return CodeVersionConflict
+	case http.StatusConflict:
+		return CodeIdempotencyKeyReused
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	default:
+		return CodeInternalError
+	}
+}
@@ -0,0 +1,35 @@
+package internalhttp
+
+import (
+	"net/http"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+)
+
+// rateLimitMiddleware ограничивает частоту запросов клиента к API. Состояние ведра передается в заголовках
+// RateLimit-*, превышение ограничения отклоняется с кодом 429 и заголовком Retry-After. Если хранилище
+// ограничений недоступно, запрос пропускается. Клиент определяется по токену Bearer или по адресу.
+func (s *Server) rateLimitMiddleware(limiter *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := limiter.Client(r.RemoteAddr, r.Header.Values("X-Forwarded-For"), r.Header.Get("Authorization"))
+			result, err := limiter.Allow(r.Context(), "http", r.Method+" "+r.URL.Path, client)
+			if err != nil {
+				s.logger.Errorf("on checking rate limit for %s: %v", client, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if result.Limit > 0 {
+				for name, value := range result.Headers() {
+					w.Header().Set(name, value)
+				}
+			}
+			if !result.Allowed {
+				s.writeError(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -19,3 +19,10 @@ func RequestIDMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// requestIDFromContext возвращает request ID запроса. Запросы, не совпавшие ни с одним маршрутом,
+// проходят мимо middleware, для них возвращается пустая строка.
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
@@ -1,5 +1,14 @@
 package internalhttp
 
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"google.golang.org/grpc/status"
+)
+
+// Response формат ответов устаревших путей без версии.
 type Response struct {
 	Data      interface{} `json:"data,omitempty"`
 	Errors    []string    `json:"errors,omitempty"`
@@ -14,3 +23,64 @@ func NewResponse(data interface{}, errors []string, status int) Response {
 		Status: status,
 	}
 }
+
+// writeData отправляет успешный ответ: на путях v1 данные без обертки, на устаревших путях - в Response.
+func (s *Server) writeData(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	if isLegacyRequest(r) {
+		response := NewResponse(data, nil, status)
+		response.RequestID = requestIDFromContext(r.Context())
+		s.writeJSON(w, "application/json", status, response)
+		return
+	}
+	// Контракт описывает списки массивами, поэтому пустой список отдается как [], а не null
+	if value := reflect.ValueOf(data); value.Kind() == reflect.Slice && value.IsNil() {
+		data = reflect.MakeSlice(value.Type(), 0, 0).Interface()
+	}
+	s.writeJSON(w, "application/json", status, data)
+}
+
+// writeProblem отправляет ошибку: на путях v1 в формате application/problem+json, на устаревших путях - в Response.
+func (s *Server) writeProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	problem.RequestID = requestIDFromContext(r.Context())
+	if isLegacyRequest(r) {
+		response := NewResponse(nil, append([]string{problem.Detail}, problem.Errors...), problem.Status)
+		response.RequestID = problem.RequestID
+		s.writeJSON(w, "application/json", problem.Status, response)
+		return
+	}
+	problem.Instance = r.URL.Path
+	s.writeJSON(w, problemContentType, problem.Status, problem)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	s.writeProblem(w, r, NewProblem(status, detail))
+}
+
+// writeServiceError отправляет ошибку сервиса со статусом, подобранным errorStatus.
+func (s *Server) writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	s.writeError(w, r, errorStatus(err), status.Convert(err).Message())
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, contentType string, status int, body interface{}) {
+	// Ответ 204 не может содержать тело
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(body)
+	if err != nil {
+		s.logger.Errorf("on marshal response %v: %v", body, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+
+	_, err = w.Write(jsonResponse)
+	if err != nil {
+		s.logger.Errorf("on writeJSON: %v", err)
+		return
+	}
+}
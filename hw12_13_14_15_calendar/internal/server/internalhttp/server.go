@@ -3,42 +3,68 @@ package internalhttp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	// _ "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api" нужен для инициализации документации Swagger.
-	_ "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/api"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/certs"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/services"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"google.golang.org/grpc"
 )
 
-// @title API Календаря
-// @version 1.0
-// @description Это простой API для управления событиями календаря.
-// @host localhost:8080
-// @BasePath /
+const (
+	// openAPIPath путь, по которому отдается контракт API.
+	openAPIPath = "/v1/openapi.yaml"
+	// idempotencyKeyHeader заголовок с ключом идемпотентности запроса создания.
+	idempotencyKeyHeader = "Idempotency-Key"
+)
 
 type Server struct {
 	httpServer          *http.Server
 	eventService        services.EventService
 	notificationService services.NotificationService
+	calendarService     services.CalendarService
 	healthService       services.HealthService
 	logger              logger.Logger
+	// certificates сертификаты TLS, nil - сервер принимает соединения без TLS
+	certificates *certs.Reloader
+	// shutdown закрывается при остановке сервера, чтобы завершить долгоживущие потоки событий
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+	// ready признак готовности приложения для /readyz
+	ready func() bool
 }
 
+// New создает HTTP-сервер. Если gatewayConn задан, API /v1 обслуживает grpc-gateway через это соединение
+// с gRPC-сервером, иначе - обработчики internalhttp. Пути без версии всегда обслуживают обработчики internalhttp.
+// Если задан limiter, частота запросов к API ограничивается; пробы, метрики и контракт не ограничиваются.
+// Если в cfg включен TLS, сервер принимает только TLS-соединения.
 func New(
 	cfg config.HTTPServerConfig,
 	logger logger.Logger,
 	eventService services.EventService,
 	notificationService services.NotificationService,
+	calendarService services.CalendarService,
 	healthService services.HealthService,
-) *Server {
+	gatewayConn *grpc.ClientConn,
+	limiter *ratelimit.Limiter,
+) (*Server, error) {
+	contract, err := newContractRouter()
+	if err != nil {
+		return nil, err
+	}
+
 	router := mux.NewRouter()
 	server := &Server{
 		httpServer: &http.Server{
@@ -50,60 +76,160 @@ func New(
 		},
 		eventService:        eventService,
 		notificationService: notificationService,
+		calendarService:     calendarService,
 		logger:              logger,
 		healthService:       healthService,
+		shutdown:            make(chan struct{}),
+		ready:               func() bool { return true },
+	}
+	if cfg.TLS.Enabled {
+		server.certificates, err = certs.NewReloader(cfg.TLS, logger)
+		if err != nil {
+			return nil, err
+		}
+		server.httpServer.TLSConfig = server.certificates.ServerConfig("h2", "http/1.1")
 	}
+	server.httpServer.RegisterOnShutdown(func() {
+		server.shutdownOnce.Do(func() { close(server.shutdown) })
+	})
 
-	// Роутинг для событий (events)
-	router.HandleFunc("/events/day", server.listEventsForDateHandler).Methods("GET")
-	router.HandleFunc("/events/week", server.listEventsForWeekHandler).Methods("GET")
-	router.HandleFunc("/events/month", server.listEventsForMonthHandler).Methods("GET")
-	router.HandleFunc("/events/{id}", server.updateEventHandler).Methods("PUT")
-	router.HandleFunc("/events/{id}", server.deleteEventHandler).Methods("DELETE")
-	router.HandleFunc("/events/{id}", server.getEventHandler).Methods("GET")
-	router.HandleFunc("/events", server.createEventHandler).Methods("POST")
-	router.HandleFunc("/events", server.listEventsHandler).Methods("GET")
+	// Контракт API и Swagger UI для него
+	router.HandleFunc(openAPIPath, openAPISpecHandler).Methods("GET")
+	router.PathPrefix("/swagger/").Handler(httpSwagger.Handler(httpSwagger.URL(openAPIPath)))
 
-	// Роутинг для уведомлений (notifications)
-	router.HandleFunc("/notifications", server.createNotificationHandler).Methods("POST")
-	router.HandleFunc("/notifications/{id}", server.updateNotificationHandler).Methods("PUT")
-	router.HandleFunc("/notifications/{id}", server.deleteNotificationHandler).Methods("DELETE")
-	router.HandleFunc("/notifications/{id}", server.getNotificationHandler).Methods("GET")
-	router.HandleFunc("/notifications", server.listNotificationsHandler).Methods("GET")
+	// Метрики Prometheus, в том числе gRPC-сервера
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
 
-	// Роутинг для healthcheck
-	router.HandleFunc("/health", server.healthCheckHandler).Methods("GET")
+	// Пробы для оркестратора: процесс жив и приложение готово принимать запросы
+	router.HandleFunc("/livez", server.livenessHandler).Methods("GET")
+	router.HandleFunc("/readyz", server.readinessHandler).Methods("GET")
 
-	// Маршрут для Swagger
-	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	// Сведения о сборке
+	router.HandleFunc("/version", server.versionHandler).Methods("GET")
+
+	// Маршруты API регистрируются в подмаршрутизаторе, чтобы ограничение частоты запросов не касалось служебных
+	api := router.NewRoute().Subrouter()
+	if limiter != nil {
+		api.Use(server.rateLimitMiddleware(limiter))
+	}
+
+	if gatewayConn != nil {
+		// Актуальная версия API, сгенерированная по proto-описаниям. Healthcheck в них не описан
+		gateway, err := server.newGateway(gatewayConn)
+		if err != nil {
+			return nil, err
+		}
+		router.HandleFunc("/v1/health", server.healthCheckHandler).Methods("GET")
+		api.Handle("/v1/events/stream", server.gatewayStreamHandler(gateway)).Methods("GET")
+		api.PathPrefix("/v1/").Handler(gateway)
+	} else {
+		// Актуальная версия API, запросы проверяются по контракту. Подмаршрутизатор без PathPrefix:
+		// с ним mux отвечает 404 вместо 405 на запрос с неподходящим методом
+		v1 := api.NewRoute().Subrouter()
+		v1.Use(server.openAPIValidationMiddleware(contract))
+		server.registerRoutes(v1, "/v1")
+	}
+
+	// Пути без версии оставлены для старых клиентов
+	legacy := api.NewRoute().Subrouter()
+	legacy.Use(DeprecationMiddleware)
+	server.registerRoutes(legacy, "")
+
+	// Запросы, не совпавшие ни с одним маршрутом, тоже получают ответ в формате problem+json
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.writeError(w, r, http.StatusNotFound, "no route for "+r.URL.Path)
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.writeError(w, r, http.StatusMethodNotAllowed, "method "+r.Method+" is not allowed for "+r.URL.Path)
+	})
 
 	// Добавляем middleware
 	router.Use(RequestIDMiddleware)
 	router.Use(LoggingMiddleware(logger))
 
-	return server
+	return server, nil
 }
 
-func (s *Server) Start(ctx context.Context) error {
-	s.logger.Info("запуск http сервера")
-	go func() {
-		<-ctx.Done()
-		err := s.Stop(ctx)
-		if err != nil {
-			s.logger.Errorf("ошибка остановки internalhttp сервера: %v", err)
-		}
-	}()
-	return s.httpServer.ListenAndServe()
+// registerRoutes регистрирует маршруты API с префиксом prefix. Маршруты /events/* регистрируются
+// раньше /events/{id}, чтобы не совпасть с ним.
+func (s *Server) registerRoutes(router *mux.Router, prefix string) {
+	// Роутинг для событий (events)
+	router.HandleFunc(prefix+"/events/day", s.listEventsForDateHandler).Methods("GET")
+	router.HandleFunc(prefix+"/events/week", s.listEventsForWeekHandler).Methods("GET")
+	router.HandleFunc(prefix+"/events/month", s.listEventsForMonthHandler).Methods("GET")
+	router.HandleFunc(prefix+"/events/stream", s.streamEventsHandler).Methods("GET")
+	router.HandleFunc(prefix+"/events/sync", s.syncEventsHandler).Methods("GET")
+	router.HandleFunc(prefix+"/events/search", s.searchEventsHandler).Methods("GET")
+	router.HandleFunc(prefix+"/events/batch", s.batchEventsHandler).Methods("POST")
+	router.HandleFunc(prefix+"/events/{id}", s.updateEventHandler).Methods("PUT")
+	router.HandleFunc(prefix+"/events/{id}", s.patchEventHandler).Methods("PATCH")
+	router.HandleFunc(prefix+"/events/{id}", s.deleteEventHandler).Methods("DELETE")
+	router.HandleFunc(prefix+"/events/{id}", s.getEventHandler).Methods("GET")
+	router.HandleFunc(prefix+"/events", s.createEventHandler).Methods("POST")
+	router.HandleFunc(prefix+"/events", s.listEventsHandler).Methods("GET")
+
+	// Роутинг для уведомлений (notifications)
+	router.HandleFunc(prefix+"/notifications", s.createNotificationHandler).Methods("POST")
+	router.HandleFunc(prefix+"/notifications/{id}", s.updateNotificationHandler).Methods("PUT")
+	router.HandleFunc(prefix+"/notifications/{id}", s.patchNotificationHandler).Methods("PATCH")
+	router.HandleFunc(prefix+"/notifications/{id}", s.deleteNotificationHandler).Methods("DELETE")
+	router.HandleFunc(prefix+"/notifications/{id}", s.getNotificationHandler).Methods("GET")
+	router.HandleFunc(prefix+"/notifications", s.listNotificationsHandler).Methods("GET")
+
+	// Роутинг для календарей (calendars)
+	router.HandleFunc(prefix+"/calendars", s.createCalendarHandler).Methods("POST")
+	router.HandleFunc(prefix+"/calendars/{id}", s.updateCalendarHandler).Methods("PUT")
+	router.HandleFunc(prefix+"/calendars/{id}", s.deleteCalendarHandler).Methods("DELETE")
+	router.HandleFunc(prefix+"/calendars/{id}", s.getCalendarHandler).Methods("GET")
+	router.HandleFunc(prefix+"/calendars", s.listCalendarsHandler).Methods("GET")
+
+	// Роутинг для healthcheck
+	router.HandleFunc(prefix+"/health", s.healthCheckHandler).Methods("GET")
+}
+
+// openAPISpecHandler отдает встроенный контракт API.
+func openAPISpecHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(api.OpenAPISpec)
+}
+
+// Start обслуживает запросы до вызова Stop.
+func (s *Server) Start(_ context.Context) error {
+	var err error
+	if s.certificates != nil {
+		s.logger.Info("запуск https сервера")
+		// Сертификат берется из TLSConfig
+		err = s.httpServer.ListenAndServeTLS("", "")
+	} else {
+		s.logger.Info("запуск http сервера")
+		err = s.httpServer.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
+// Stop дожидается завершения текущих запросов, а по истечении ctx закрывает оставшиеся соединения.
 func (s *Server) Stop(ctx context.Context) error {
 	err := s.httpServer.Shutdown(ctx)
 	if err != nil {
+		_ = s.httpServer.Close()
 		return fmt.Errorf("ошибка остановки http.Server: %w", err)
 	}
 	return nil
 }
 
+// Certificates возвращает сертификаты TLS сервера или nil, если TLS выключен.
+func (s *Server) Certificates() *certs.Reloader {
+	return s.certificates
+}
+
+// SetReadiness задает признак готовности приложения для /readyz. По умолчанию сервер готов всегда.
+func (s *Server) SetReadiness(ready func() bool) {
+	s.ready = ready
+}
+
 func parseStartAndEndTime(r *http.Request) (time.Time, time.Time, error) {
 	startTime := r.URL.Query().Get("startTime")
 	endTime := r.URL.Query().Get("endTime")
@@ -121,393 +247,280 @@ func parseStartAndEndTime(r *http.Request) (time.Time, time.Time, error) {
 	return start, end, nil
 }
 
-// ErrorResponseWrapper используется для документации swagger.
-type ErrorResponseWrapper struct {
-	Errors    []string `json:"errors,omitempty"`
-	Status    int      `json:"status"`
-	RequestID string   `json:"requestId"`
+// parseCalendarIDs разбирает необязательный список календарей из повторяющегося параметра calendarId.
+func parseCalendarIDs(r *http.Request) ([]uuid.UUID, error) {
+	values := r.URL.Query()["calendarId"]
+	calendarIDs := make([]uuid.UUID, 0, len(values))
+	for _, value := range values {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendarId %q: %w", value, err)
+		}
+		calendarIDs = append(calendarIDs, id)
+	}
+	return calendarIDs, nil
 }
 
-// EventListResponseWrapper используется для документации swagger.
-type EventListResponseWrapper struct {
-	Data      []dto.EventData `json:"data"`
-	Errors    []string        `json:"errors,omitempty"`
-	Status    int             `json:"status"`
-	RequestID string          `json:"requestId"`
+// listEventsByCalendars возвращает события за период из выбранных календарей либо все, если календари не указаны.
+func (s *Server) listEventsByCalendars(
+	r *http.Request,
+	calendarIDs []uuid.UUID,
+	start,
+	end time.Time,
+) ([]dto.EventData, error) {
+	if len(calendarIDs) == 0 {
+		return s.eventService.ListEvents(r.Context(), start, end)
+	}
+	return s.eventService.ListEventsByCalendars(r.Context(), calendarIDs, start, end)
 }
 
-// EventResponseWrapper используется для документации swagger.
-type EventResponseWrapper struct {
-	Data      dto.EventData `json:"data"`
-	Errors    []string      `json:"errors,omitempty"`
-	Status    int           `json:"status"`
-	RequestID string        `json:"requestId"`
-}
+// healthCheckHandler проверяет состояние сервиса.
+func (s *Server) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
 
-// NotificationListResponseWrapper используется для документации swagger.
-type NotificationListResponseWrapper struct {
-	Data      []dto.NotificationData `json:"data"`
-	Errors    []string               `json:"errors,omitempty"`
-	Status    int                    `json:"status"`
-	RequestID string                 `json:"requestId"`
+	if err := s.healthService.HealthCheck(ctx); err != nil {
+		s.writeError(w, r, http.StatusServiceUnavailable, "Сервис недоступен")
+		return
+	}
+
+	s.writeData(w, r, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-// NotificationResponseWrapper используется для документации swagger.
-type NotificationResponseWrapper struct {
-	Data      dto.NotificationData `json:"data"`
-	Errors    []string             `json:"errors,omitempty"`
-	Status    int                  `json:"status"`
-	RequestID string               `json:"requestId"`
+// livenessHandler отвечает, пока процесс способен обрабатывать запросы; зависимости не проверяются,
+// чтобы их отказ не приводил к перезапуску процесса.
+func (s *Server) livenessHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeData(w, r, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-// @Summary Проверка состояния здоровья
-// @Description Проверяет состояние сервиса
-// @Tags health
-// @Accept json
-// @Produce json
-// @Success 200 {object} Response
-// @Failure 503 {object} ErrorResponseWrapper
-// @Router /health [get].
-func (s *Server) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
+// readinessHandler отвечает 503, пока приложение запускается или останавливается и пока недоступно хранилище.
+func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.ready() {
+		s.writeError(w, r, http.StatusServiceUnavailable, "application is not ready")
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
 	defer cancel()
-
-	err := s.healthService.HealthCheck(ctx)
-	var response Response
-	if err != nil {
-		response = NewResponse(nil, []string{"Сервис недоступен"}, http.StatusServiceUnavailable)
-	} else {
-		response = NewResponse(map[string]string{"status": "ok"}, nil, http.StatusOK)
+	if err := s.healthService.HealthCheck(ctx); err != nil {
+		s.writeError(w, r, http.StatusServiceUnavailable, "dependencies are unavailable")
+		return
 	}
 
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-// @Summary Создать событие
-// @Description Создает новое событие
-// @Tags events
-// @Accept json
-// @Produce json
-// @Param event body dto.EventData true "Запрос на создание события"
-// @Success 200 {object} Response
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /events [post].
+// createEventHandler создает новое событие.
 func (s *Server) createEventHandler(w http.ResponseWriter, r *http.Request) {
 	var eventRequest dto.EventData
 
 	if err := json.NewDecoder(r.Body).Decode(&eventRequest); err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	id, err := s.eventService.CreateEvent(r.Context(), eventRequest)
+	id, err := s.eventService.CreateEventIdempotent(r.Context(), r.Header.Get(idempotencyKeyHeader), eventRequest)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(map[string]interface{}{"id": id}, nil, http.StatusOK)
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusOK, map[string]interface{}{"id": id})
 }
 
-// @Summary Обновить событие
-// @Description Обновляет существующее событие
-// @Tags events
-// @Accept json
-// @Produce json
-// @Param id path string true "ID события"
-// @Param event body dto.EventData true "Запрос на обновление события"
-// @Success 204 {object} Response
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /events/{id} [put].
+// updateEventHandler обновляет существующее событие.
 func (s *Server) updateEventHandler(w http.ResponseWriter, r *http.Request) {
 	var eventRequest dto.EventData
 
 	if err := json.NewDecoder(r.Body).Decode(&eventRequest); err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, ok, err := parseIfMatch(r)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	if ok {
+		eventRequest.Version = version
+	}
 
 	err = s.eventService.UpdateEvent(r.Context(), id, eventRequest)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(nil, nil, http.StatusNoContent)
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusNoContent, nil)
 }
 
-// @Summary Удалить событие
-// @Description Удаляет существующее событие
-// @Tags events
-// @Accept json
-// @Produce json
-// @Param id path string true "ID события" example(123e4567-e89b-12d3-a456-426614174000)
-// @Success 204 {object} Response
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /events/{id} [delete].
+// deleteEventHandler удаляет существующее событие.
 func (s *Server) deleteEventHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	err = s.eventService.DeleteEvent(r.Context(), id)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(nil, nil, http.StatusNoContent)
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusNoContent, nil)
 }
 
-// @Summary Получить событие
-// @Description Получает событие по ID
-// @Tags events
-// @Accept json
-// @Produce json
-// @Param id path string true "ID события" example(123e4567-e89b-12d3-a456-426614174000)
-// @Success 200 {object} EventResponseWrapper
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /events/{id} [get].
+// getEventHandler получает событие по ID.
 func (s *Server) getEventHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	event, err := s.eventService.GetEvent(r.Context(), id)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(event, nil, http.StatusOK)
-	s.writeJSONResponse(w, r, response)
+	w.Header().Set("ETag", formatETag(event.Version))
+	s.writeData(w, r, http.StatusOK, event)
 }
 
-// @Summary Список событий
-// @Description Получает список событий между указанными датами
-// @Tags events
-// @Accept json
-// @Produce json
-// @Param startTime query string true "Время начала" example(2024-07-01T00:00:00Z)
-// @Param endTime query string true "Время окончания" example(2024-07-31T23:59:59Z)
-// @Success 200 {object} EventListResponseWrapper
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /events [get].
+// listEventsHandler получает список событий между указанными датами.
 func (s *Server) listEventsHandler(w http.ResponseWriter, r *http.Request) {
 	start, end, err := parseStartAndEndTime(r)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	events, err := s.eventService.ListEvents(r.Context(), start, end)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(events, nil, http.StatusOK)
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusOK, events)
 }
 
-// @Summary Список событий на указанный день
-// @Description Получает список событий на указанный день
-// @Tags events
-// @Accept json
-// @Produce json
-// @Param date query string true "Дата" format(date) example(2024-07-24)
-// @Success 200 {object} EventListResponseWrapper
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /events/day [get].
+// listEventsForDateHandler получает список событий на указанный день.
 func (s *Server) listEventsForDateHandler(w http.ResponseWriter, r *http.Request) {
 	date := r.URL.Query().Get("date")
 
 	start, err := time.Parse(time.DateOnly, date)
 	if err != nil {
-		response := NewResponse(nil, []string{"Некорректная дата"}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, "Некорректная дата")
 		return
 	}
 
 	end := start.AddDate(0, 0, 1) // Добавляем 1 день к начальной дате для получения конца недели
 
-	events, err := s.eventService.ListEvents(r.Context(), start, end)
+	calendarIDs, err := parseCalendarIDs(r)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := s.listEventsByCalendars(r, calendarIDs, start, end)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(events, nil, http.StatusOK)
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusOK, events)
 }
 
-// @Summary Список событий на указанную неделю
-// @Description Получает список событий на указанную неделю
-// @Tags events
-// @Accept json
-// @Produce json
-// @Param date query string true "Дата начала недели" format(date) example(2024-07-22)
-// @Success 200 {object} EventListResponseWrapper
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /events/week [get].
+// listEventsForWeekHandler получает список событий на указанную неделю.
 func (s *Server) listEventsForWeekHandler(w http.ResponseWriter, r *http.Request) {
 	date := r.URL.Query().Get("date")
 
 	start, err := time.Parse(time.DateOnly, date)
 	if err != nil {
-		response := NewResponse(nil, []string{"Некорректная дата"}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, "Некорректная дата")
 		return
 	}
 
 	end := start.AddDate(0, 0, 7) // Добавляем 7 дней к начальной дате для получения конца недели
 
-	events, err := s.eventService.ListEvents(r.Context(), start, end)
+	calendarIDs, err := parseCalendarIDs(r)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := s.listEventsByCalendars(r, calendarIDs, start, end)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(events, nil, http.StatusOK)
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusOK, events)
 }
 
-// @Summary Список событий на указанный месяц
-// @Description Получает список событий на указанный месяц
-// @Tags events
-// @Accept json
-// @Produce json
-// @Param date query string true "Дата начала месяца" format(date) example(2024-07-01)
-// @Success 200 {object} EventListResponseWrapper
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /events/month [get].
+// listEventsForMonthHandler получает список событий на указанный месяц.
 func (s *Server) listEventsForMonthHandler(w http.ResponseWriter, r *http.Request) {
 	date := r.URL.Query().Get("date")
 
 	start, err := time.Parse(time.DateOnly, date)
 	if err != nil {
-		response := NewResponse(nil, []string{"Некорректная дата"}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, "Некорректная дата")
 		return
 	}
 
 	end := start.AddDate(0, 1, 0) // Добавляем 1 месяц к начальной дате
 
-	events, err := s.eventService.ListEvents(r.Context(), start, end)
+	calendarIDs, err := parseCalendarIDs(r)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	response := NewResponse(events, nil, http.StatusOK)
-	s.writeJSONResponse(w, r, response)
-}
-
-func (s *Server) writeJSONResponse(w http.ResponseWriter, r *http.Request, response Response) {
-	requestID := r.Context().Value(requestIDKey).(string)
-	response.RequestID = requestID
-
-	jsonResponse, err := json.Marshal(response)
+	events, err := s.listEventsByCalendars(r, calendarIDs, start, end)
 	if err != nil {
-		s.logger.Errorf("on marshal response %v: %v", response, err)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(response.Status)
-
-	_, err = w.Write(jsonResponse)
-	if err != nil {
-		s.logger.Errorf("on writeJSONResponse: %v", err)
-		return
-	}
+	s.writeData(w, r, http.StatusOK, events)
 }
 
-// @Summary Создать уведомление
-// @Description Создает новое уведомление
-// @Tags notifications
-// @Accept json
-// @Produce json
-// @Param notification body dto.NotificationData true "Запрос на создание уведомления"
-// @Success 200 {object} Response
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /notifications [post].
+// createNotificationHandler создает новое уведомление.
 func (s *Server) createNotificationHandler(w http.ResponseWriter, r *http.Request) {
 	var notificationRequest dto.NotificationData
 
 	if err := json.NewDecoder(r.Body).Decode(&notificationRequest); err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	id, err := s.notificationService.CreateNotification(r.Context(), notificationRequest)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(map[string]interface{}{"id": id}, nil, http.StatusOK)
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusOK, map[string]interface{}{"id": id})
 }
 
-// @Summary Обновить уведомление
-// @Description Обновляет существующее уведомление
-// @Tags notifications
-// @Accept json
-// @Produce json
-// @Param id path string true "ID уведомления"
-// @Param notification body dto.NotificationData true "Запрос на обновление уведомления"
-// @Success 204 {object} Response
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /notifications/{id} [put].
+// updateNotificationHandler обновляет существующее уведомление.
 func (s *Server) updateNotificationHandler(w http.ResponseWriter, r *http.Request) {
 	var notificationRequest dto.NotificationData
 
 	if err := json.NewDecoder(r.Body).Decode(&notificationRequest); err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -515,112 +528,82 @@ func (s *Server) updateNotificationHandler(w http.ResponseWriter, r *http.Reques
 	idStr := vars["id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		response := NewResponse(nil, []string{"Invalid ID format"}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, "Invalid ID format")
 		return
 	}
 
 	notificationRequest.ID = id
 
+	version, ok, err := parseIfMatch(r)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ok {
+		notificationRequest.Version = version
+	}
+
 	err = s.notificationService.UpdateNotification(r.Context(), id, notificationRequest)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(nil, nil, http.StatusNoContent)
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusNoContent, nil)
 }
 
-// @Summary Удалить уведомление
-// @Description Удаляет существующее уведомление
-// @Tags notifications
-// @Accept json
-// @Produce json
-// @Param id path string true "ID уведомления" example(123e4567-e89b-12d3-a456-426614174000)
-// @Success 204 {object} Response
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /notifications/{id} [delete].
+// deleteNotificationHandler удаляет существующее уведомление.
 func (s *Server) deleteNotificationHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		response := NewResponse(nil, []string{"Invalid ID format"}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, "Invalid ID format")
 		return
 	}
 
 	err = s.notificationService.DeleteNotification(r.Context(), id)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(nil, nil, http.StatusNoContent)
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusNoContent, nil)
 }
 
-// @Summary Получить уведомление
-// @Description Получает уведомление по ID
-// @Tags notifications
-// @Accept json
-// @Produce json
-// @Param id path string true "ID уведомления" example(123e4567-e89b-12d3-a456-426614174000)
-// @Success 200 {object} NotificationResponseWrapper
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /notifications/{id} [get].
+// getNotificationHandler получает уведомление по ID.
 func (s *Server) getNotificationHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		response := NewResponse(nil, []string{"Invalid ID format"}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, "Invalid ID format")
 		return
 	}
 
 	notification, err := s.notificationService.GetNotification(r.Context(), id)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(notification, nil, http.StatusOK)
-	s.writeJSONResponse(w, r, response)
+	w.Header().Set("ETag", formatETag(notification.Version))
+	s.writeData(w, r, http.StatusOK, notification)
 }
 
-// @Summary Список уведомлений
-// @Description Получает список уведомлений между указанными датами
-// @Tags notifications
-// @Accept json
-// @Produce json
-// @Param start_time query string true "Время начала" example(2024-07-01T00:00:00Z)
-// @Param end_time query string true "Время окончания" example(2024-07-31T23:59:59Z)
-// @Success 200 {object} NotificationListResponseWrapper
-// @Failure 400 {object} ErrorResponseWrapper
-// @Failure 500 {object} ErrorResponseWrapper
-// @Router /notifications [get].
+// listNotificationsHandler получает список уведомлений между указанными датами.
 func (s *Server) listNotificationsHandler(w http.ResponseWriter, r *http.Request) {
 	start, end, err := parseStartAndEndTime(r)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusBadRequest)
-		s.writeJSONResponse(w, r, response)
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	notifications, err := s.notificationService.ListNotifications(r.Context(), start, end)
 	if err != nil {
-		response := NewResponse(nil, []string{err.Error()}, http.StatusInternalServerError)
-		s.writeJSONResponse(w, r, response)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
-	response := NewResponse(notifications, nil, http.StatusOK)
-	s.writeJSONResponse(w, r, response)
+	s.writeData(w, r, http.StatusOK, notifications)
 }
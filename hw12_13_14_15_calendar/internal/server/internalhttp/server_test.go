@@ -0,0 +1,378 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/services"
+	memorystorage "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) http.Handler {
+	t.Helper()
+
+	logInstance, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+
+	store := memorystorage.New()
+	server, err := New(
+		config.HTTPServerConfig{},
+		logInstance,
+		services.NewEventService(store),
+		services.NewNotificationService(store),
+		services.NewCalendarService(store),
+		services.NewHealthService(store),
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+	return server.httpServer.Handler
+}
+
+func serve(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func decodeProblem(t *testing.T, recorder *httptest.ResponseRecorder) Problem {
+	t.Helper()
+
+	assert.Equal(t, problemContentType, recorder.Header().Get("Content-Type"))
+	var problem Problem
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+	return problem
+}
+
+func TestServer_V1(t *testing.T) {
+	handler := newTestServer(t)
+	userID := uuid.New()
+
+	body := `{"title":"Planning","startTime":"2024-07-01T10:00:00Z","endTime":"2024-07-01T11:00:00Z",` +
+		`"userId":"` + userID.String() + `"}`
+	recorder := serve(handler, http.MethodPost, "/v1/events", body)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Empty(t, recorder.Header().Get("Deprecation"))
+
+	var created struct {
+		ID uuid.UUID `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+
+	// Успешные ответы v1 не оборачиваются в Response
+	recorder = serve(handler, http.MethodGet, "/v1/events/"+created.ID.String(), "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var event dto.EventData
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &event))
+	assert.Equal(t, "Planning", event.Title)
+	assert.Equal(t, `"1"`, recorder.Header().Get("ETag"))
+
+	body = `{"title":"Retro"}`
+	patch := httptest.NewRequest(http.MethodPatch, "/v1/events/"+created.ID.String(), strings.NewReader(body))
+	patch.Header.Set("Content-Type", mergePatchContentType)
+	patch.Header.Set("If-Match", `"1"`)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, patch)
+	require.Equal(t, http.StatusNoContent, recorder.Code, recorder.Body.String())
+
+	recorder = serve(handler, http.MethodGet, "/v1/events/day?date=2024-07-02", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `[]`, recorder.Body.String())
+
+	recorder = serve(handler, http.MethodDelete, "/v1/events/"+created.ID.String(), "")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+
+	recorder = serve(handler, http.MethodGet, "/v1/events/"+created.ID.String(), "")
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	problem := decodeProblem(t, recorder)
+	assert.Equal(t, CodeNotFound, problem.Code)
+	assert.Equal(t, "event not found", problem.Detail)
+	assert.Equal(t, "/v1/events/"+created.ID.String(), problem.Instance)
+	assert.NotEmpty(t, problem.RequestID)
+}
+
+func TestServer_V1Problems(t *testing.T) {
+	handler := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+		errors []string
+	}{
+		{
+			name:   "missing query parameter",
+			method: http.MethodGet,
+			target: "/v1/events?startTime=2024-07-01T00:00:00Z",
+			status: http.StatusBadRequest,
+			code:   CodeValidationFailed,
+			errors: []string{`query parameter "endTime": value is required but missing`},
+		},
+		{
+			name:   "invalid path parameter",
+			method: http.MethodGet,
+			target: "/v1/events/42",
+			status: http.StatusBadRequest,
+			code:   CodeValidationFailed,
+		},
+		{
+			name:   "invalid body",
+			method: http.MethodPost,
+			target: "/v1/events",
+			body:   `{"title":1,"startTime":"2024-07-01T10:00:00Z","endTime":"2024-07-01T11:00:00Z"}`,
+			status: http.StatusBadRequest,
+			code:   CodeValidationFailed,
+			errors: []string{`request body /title: value must be a string`},
+		},
+		{
+			name:   "missing required field",
+			method: http.MethodPost,
+			target: "/v1/events",
+			body:   `{"startTime":"2024-07-01T10:00:00Z"}`,
+			status: http.StatusBadRequest,
+			code:   CodeValidationFailed,
+			errors: []string{`request body /endTime: property "endTime" is missing`},
+		},
+		{
+			name:   "service validation",
+			method: http.MethodPost,
+			target: "/v1/events",
+			body:   `{"startTime":"2024-07-01T11:00:00Z","endTime":"2024-07-01T10:00:00Z"}`,
+			status: http.StatusBadRequest,
+			code:   CodeValidationFailed,
+		},
+		{
+			name:   "unknown route",
+			method: http.MethodGet,
+			target: "/v1/unknown",
+			status: http.StatusNotFound,
+			code:   CodeNotFound,
+		},
+		{
+			name:   "method not allowed",
+			method: http.MethodPost,
+			target: "/v1/events/day",
+			status: http.StatusMethodNotAllowed,
+			code:   CodeMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serve(handler, tt.method, tt.target, tt.body)
+			require.Equal(t, tt.status, recorder.Code, recorder.Body.String())
+
+			problem := decodeProblem(t, recorder)
+			assert.Equal(t, tt.status, problem.Status)
+			assert.Equal(t, tt.code, problem.Code)
+			assert.Equal(t, http.StatusText(tt.status), problem.Title)
+			if tt.errors != nil {
+				assert.ElementsMatch(t, tt.errors, problem.Errors)
+			}
+		})
+	}
+}
+
+func TestServer_LegacyAliases(t *testing.T) {
+	handler := newTestServer(t)
+
+	body := `{"title":"Planning","startTime":"2024-07-01T10:00:00Z","endTime":"2024-07-01T11:00:00Z",` +
+		`"userId":"` + uuid.NewString() + `"}`
+	recorder := serve(handler, http.MethodPost, "/events", body)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "true", recorder.Header().Get("Deprecation"))
+	assert.Equal(t, `</v1/events>; rel="successor-version"`, recorder.Header().Get("Link"))
+
+	var response Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusOK, response.Status)
+	assert.NotEmpty(t, response.RequestID)
+	assert.Contains(t, response.Data, "id")
+
+	// Ошибки устаревших путей сохраняют прежний формат
+	recorder = serve(handler, http.MethodGet, "/events/"+uuid.NewString(), "")
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	response = Response{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, []string{"event not found"}, response.Errors)
+	assert.Equal(t, http.StatusNotFound, response.Status)
+}
+
+func TestServer_OpenAPISpec(t *testing.T) {
+	handler := newTestServer(t)
+
+	recorder := serve(handler, http.MethodGet, openAPIPath, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/yaml", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), "openapi: 3.0.3")
+}
+
+func TestServer_Probes(t *testing.T) {
+	handler := newTestServer(t)
+
+	recorder := serve(handler, http.MethodGet, "/livez", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serve(handler, http.MethodGet, "/readyz", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+}
+
+func TestServer_Version(t *testing.T) {
+	handler := newTestServer(t)
+
+	recorder := serve(handler, http.MethodGet, "/version", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var info version.Info
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &info))
+	assert.Equal(t, version.Get(), info)
+
+	recorder = serve(handler, http.MethodGet, "/metrics", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `calendar_build_info{build_date="`+info.BuildDate+`"`)
+}
+
+func TestServer_ReadinessDuringDrain(t *testing.T) {
+	logInstance, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+
+	store := memorystorage.New()
+	server, err := New(
+		config.HTTPServerConfig{},
+		logInstance,
+		services.NewEventService(store),
+		services.NewNotificationService(store),
+		services.NewCalendarService(store),
+		services.NewHealthService(store),
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+	server.SetReadiness(func() bool { return false })
+	handler := server.httpServer.Handler
+
+	// Пока приложение останавливается, процесс жив, но запросы на него направлять нельзя
+	recorder := serve(handler, http.MethodGet, "/livez", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serve(handler, http.MethodGet, "/readyz", "")
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	problem := decodeProblem(t, recorder)
+	assert.Equal(t, CodeServiceUnavailable, problem.Code)
+}
+
+func TestServer_IdempotencyKey(t *testing.T) {
+	handlers := map[string]func(t *testing.T) http.Handler{
+		"handlers": newTestServer,
+		"gateway":  func(t *testing.T) http.Handler { return newTestGateway(t, nil) },
+	}
+	for name, newHandler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			handler := newHandler(t)
+			key := uuid.NewString()
+			body := `{"title":"Planning","startTime":"2024-07-01T10:00:00Z","endTime":"2024-07-01T11:00:00Z",` +
+				`"userId":"` + uuid.NewString() + `"}`
+
+			create := func(body string) *httptest.ResponseRecorder {
+				request := httptest.NewRequest(http.MethodPost, "/v1/events", strings.NewReader(body))
+				request.Header.Set("Content-Type", "application/json")
+				request.Header.Set(idempotencyKeyHeader, key)
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, request)
+				return recorder
+			}
+
+			first := create(body)
+			require.Equal(t, http.StatusOK, first.Code, first.Body.String())
+			retried := create(body)
+			require.Equal(t, http.StatusOK, retried.Code, retried.Body.String())
+			assert.JSONEq(t, first.Body.String(), retried.Body.String())
+
+			recorder := create(strings.Replace(body, "Planning", "Retro", 1))
+			require.Equal(t, http.StatusConflict, recorder.Code, recorder.Body.String())
+			assert.Equal(t, CodeIdempotencyKeyReused, decodeProblem(t, recorder).Code)
+		})
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	logInstance, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+
+	limiter, err := ratelimit.New(
+		config.RateLimitConfig{Enabled: true, Rate: 0.001, Burst: 2},
+		ratelimit.NewMemoryStore(),
+	)
+	require.NoError(t, err)
+	store := memorystorage.New()
+	server, err := New(
+		config.HTTPServerConfig{},
+		logInstance,
+		services.NewEventService(store),
+		services.NewNotificationService(store),
+		services.NewCalendarService(store),
+		services.NewHealthService(store),
+		nil,
+		limiter,
+	)
+	require.NoError(t, err)
+	handler := server.httpServer.Handler
+
+	target := "/v1/calendars?userId=" + uuid.NewString()
+	for remaining := 1; remaining >= 0; remaining-- {
+		recorder := serve(handler, http.MethodGet, target, "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "2", recorder.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, strconv.Itoa(remaining), recorder.Header().Get("RateLimit-Remaining"))
+	}
+
+	// Ведро общее для путей v1 и устаревших путей
+	recorder := serve(handler, http.MethodGet, "/calendars?userId="+uuid.NewString(), "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	recorder = serve(handler, http.MethodGet, target, "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, CodeRateLimited, decodeProblem(t, recorder).Code)
+
+	// Пробы не ограничиваются
+	recorder = serve(handler, http.MethodGet, "/readyz", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("RateLimit-Limit"))
+}
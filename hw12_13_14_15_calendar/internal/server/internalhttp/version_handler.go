@@ -0,0 +1,27 @@
+package internalhttp
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/version"
+)
+
+// Метрика calendar_build_info публикует сведения о сборке метками со значением 1, как принято для *_build_info:
+// их можно присоединить к другим рядам в запросах PromQL.
+var _ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+	Name: "calendar_build_info",
+	Help: "Build information of the calendar service.",
+	ConstLabels: prometheus.Labels{
+		"release":    version.Get().Release,
+		"build_date": version.Get().BuildDate,
+		"git_hash":   version.Get().GitHash,
+		"go_version": version.Get().GoVersion,
+	},
+}, func() float64 { return 1 })
+
+// versionHandler отдает сведения о сборке.
+func (s *Server) versionHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeData(w, r, http.StatusOK, version.Get())
+}
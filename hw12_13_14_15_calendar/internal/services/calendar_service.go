@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type CalendarService interface {
+	CreateCalendar(ctx context.Context, calendar dto.CalendarData) (uuid.UUID, error)
+	UpdateCalendar(ctx context.Context, id uuid.UUID, calendar dto.CalendarData) error
+	DeleteCalendar(ctx context.Context, id uuid.UUID) error
+	GetCalendar(ctx context.Context, id uuid.UUID) (dto.CalendarData, error)
+	ListCalendars(ctx context.Context, userID uuid.UUID) ([]dto.CalendarData, error)
+}
+
+type CalendarServiceImpl struct {
+	repo storage.CalendarRepository
+}
+
+func NewCalendarService(store storage.Storage) CalendarService {
+	return &CalendarServiceImpl{repo: store.CalendarRepository()}
+}
+
+func (s *CalendarServiceImpl) CreateCalendar(ctx context.Context, calendar dto.CalendarData) (uuid.UUID, error) {
+	if calendar.Timezone == "" {
+		calendar.Timezone = "UTC"
+	}
+	if err := validateCalendar(calendar); err != nil {
+		return uuid.Nil, err
+	}
+	return s.repo.CreateCalendar(ctx, dto.ToStorageCalendar(calendar))
+}
+
+func (s *CalendarServiceImpl) UpdateCalendar(ctx context.Context, id uuid.UUID, calendar dto.CalendarData) error {
+	if calendar.Timezone == "" {
+		calendar.Timezone = "UTC"
+	}
+	if err := validateCalendar(calendar); err != nil {
+		return err
+	}
+	return s.repo.UpdateCalendar(ctx, id, dto.ToStorageCalendar(calendar))
+}
+
+func (s *CalendarServiceImpl) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteCalendar(ctx, id)
+}
+
+func (s *CalendarServiceImpl) GetCalendar(ctx context.Context, id uuid.UUID) (dto.CalendarData, error) {
+	calendar, err := s.repo.GetCalendar(ctx, id)
+	if err != nil {
+		return dto.CalendarData{}, err
+	}
+	return dto.FromStorageCalendar(calendar), nil
+}
+
+func (s *CalendarServiceImpl) ListCalendars(ctx context.Context, userID uuid.UUID) ([]dto.CalendarData, error) {
+	storageCalendars, err := s.repo.ListCalendars(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	calendars := make([]dto.CalendarData, len(storageCalendars))
+	for i, calendar := range storageCalendars {
+		calendars[i] = dto.FromStorageCalendar(calendar)
+	}
+	return calendars, nil
+}
+
+func validateCalendar(calendar dto.CalendarData) error {
+	if calendar.UserID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "calendar must belong to a user")
+	}
+	if calendar.Name == "" {
+		return status.Error(codes.InvalidArgument, "calendar name must not be empty")
+	}
+	if calendar.Color != "" && !colorPattern.MatchString(calendar.Color) {
+		return status.Error(codes.InvalidArgument, "calendar color must be in #RRGGBB format")
+	}
+	if calendar.DefaultReminderMinutes < 0 {
+		return status.Error(codes.InvalidArgument, "default reminder must not be negative")
+	}
+	if _, err := time.LoadLocation(calendar.Timezone); err != nil {
+		return status.Errorf(codes.InvalidArgument, "unknown timezone %q", calendar.Timezone)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarService(t *testing.T) {
+	ctx := context.Background()
+	store := memorystorage.New()
+	service := NewCalendarService(store)
+	eventService := NewEventService(store)
+
+	userID := uuid.New()
+	calendar := dto.CalendarData{
+		UserID:                 userID,
+		Name:                   "Work",
+		Color:                  "#3366ff",
+		DefaultReminderMinutes: 15,
+		Timezone:               "Europe/Moscow",
+	}
+
+	t.Run("CreateCalendar", func(t *testing.T) {
+		id, err := service.CreateCalendar(ctx, calendar)
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, id)
+
+		stored, err := service.GetCalendar(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, calendar.Name, stored.Name)
+		assert.Equal(t, calendar.DefaultReminderMinutes, stored.DefaultReminderMinutes)
+	})
+
+	t.Run("DefaultTimezone", func(t *testing.T) {
+		withoutTimezone := calendar
+		withoutTimezone.Timezone = ""
+
+		id, err := service.CreateCalendar(ctx, withoutTimezone)
+		require.NoError(t, err)
+
+		stored, err := service.GetCalendar(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "UTC", stored.Timezone)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		invalid := []dto.CalendarData{
+			{UserID: userID, Name: "", Timezone: "UTC"},
+			{UserID: uuid.Nil, Name: "Work", Timezone: "UTC"},
+			{UserID: userID, Name: "Work", Color: "blue", Timezone: "UTC"},
+			{UserID: userID, Name: "Work", Timezone: "Mars/Olympus"},
+			{UserID: userID, Name: "Work", DefaultReminderMinutes: -1},
+		}
+		for _, calendar := range invalid {
+			_, err := service.CreateCalendar(ctx, calendar)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("EventsAcrossCalendars", func(t *testing.T) {
+		workID, err := service.CreateCalendar(ctx, dto.CalendarData{UserID: userID, Name: "Work"})
+		require.NoError(t, err)
+		personalID, err := service.CreateCalendar(ctx, dto.CalendarData{UserID: userID, Name: "Personal"})
+		require.NoError(t, err)
+
+		start := time.Now()
+		for _, calendarID := range []uuid.UUID{workID, personalID} {
+			_, err := eventService.CreateEvent(ctx, dto.EventData{
+				Title:      "Event",
+				StartTime:  start.Add(time.Hour),
+				EndTime:    start.Add(2 * time.Hour),
+				UserID:     userID,
+				CalendarID: calendarID,
+			})
+			require.NoError(t, err)
+		}
+
+		events, err := eventService.ListEventsByCalendars(ctx, []uuid.UUID{workID}, start, start.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, workID, events[0].CalendarID)
+
+		events, err = eventService.ListEventsByCalendars(
+			ctx,
+			[]uuid.UUID{workID, personalID},
+			start,
+			start.Add(24*time.Hour),
+		)
+		require.NoError(t, err)
+		assert.Len(t, events, 2)
+	})
+
+	t.Run("EventInForeignCalendar", func(t *testing.T) {
+		calendarID, err := service.CreateCalendar(ctx, dto.CalendarData{UserID: userID, Name: "Work"})
+		require.NoError(t, err)
+
+		_, err = eventService.CreateEvent(ctx, dto.EventData{
+			Title:      "Event",
+			StartTime:  time.Now(),
+			EndTime:    time.Now().Add(time.Hour),
+			UserID:     uuid.New(),
+			CalendarID: calendarID,
+		})
+		assert.Error(t, err)
+
+		_, err = eventService.CreateEvent(ctx, dto.EventData{
+			Title:      "Event",
+			StartTime:  time.Now(),
+			EndTime:    time.Now().Add(time.Hour),
+			UserID:     userID,
+			CalendarID: uuid.New(),
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("ListCalendars", func(t *testing.T) {
+		calendars, err := service.ListCalendars(ctx, userID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, calendars)
+		for _, calendar := range calendars {
+			assert.Equal(t, userID, calendar.UserID)
+		}
+	})
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/mail"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,18 +16,59 @@ import (
 
 type EventService interface {
 	CreateEvent(ctx context.Context, event dto.EventData) (uuid.UUID, error)
+	CreateEventIdempotent(ctx context.Context, key string, event dto.EventData) (uuid.UUID, error)
 	UpdateEvent(ctx context.Context, id uuid.UUID, event dto.EventData) error
+	PatchEvent(ctx context.Context, id uuid.UUID, patch dto.EventData, fields []string) error
 	DeleteEvent(ctx context.Context, id uuid.UUID) error
 	GetEvent(ctx context.Context, id uuid.UUID) (dto.EventData, error)
 	ListEvents(ctx context.Context, start, end time.Time) ([]dto.EventData, error)
+	ListEventsByCalendars(ctx context.Context, calendarIDs []uuid.UUID, start, end time.Time) ([]dto.EventData, error)
+	WatchEvents(ctx context.Context, userID uuid.UUID) (<-chan dto.EventChangeData, error)
+	SyncEvents(ctx context.Context, userID uuid.UUID, token string, limit int) (dto.SyncResult, error)
+	SearchEvents(ctx context.Context, search dto.EventSearchData) ([]dto.EventData, error)
+	BatchEvents(ctx context.Context, operations []dto.EventOperation) (dto.EventBatchResult, error)
 }
 
+const (
+	defaultSyncLimit   = 100
+	maxSyncLimit       = 1000
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+	maxBatchSize       = 500
+	// patchAttempts ограничивает число повторов частичного обновления без ожидаемой версии,
+	// если событие успели изменить между чтением и записью.
+	patchAttempts = 3
+)
+
+// eventPatchFields описывает поля события, доступные для частичного обновления.
+// Имена совпадают с путями google.protobuf.FieldMask в UpdateEventRequest.
+var eventPatchFields = map[string]func(dst *storage.Event, src dto.EventData){
+	"title":       func(dst *storage.Event, src dto.EventData) { dst.Title = src.Title },
+	"description": func(dst *storage.Event, src dto.EventData) { dst.Description = src.Description },
+	"start_time":  func(dst *storage.Event, src dto.EventData) { dst.StartTime = src.StartTime },
+	"end_time":    func(dst *storage.Event, src dto.EventData) { dst.EndTime = src.EndTime },
+	"user_id":     func(dst *storage.Event, src dto.EventData) { dst.UserID = src.UserID },
+	"calendar_id": func(dst *storage.Event, src dto.EventData) { dst.CalendarID = src.CalendarID },
+	"attendees":   func(dst *storage.Event, src dto.EventData) { dst.Attendees = src.Attendees },
+}
+
+// errBatchAborted откатывает транзакцию пакета после ошибки одной из операций.
+var errBatchAborted = errors.New("batch aborted")
+
 type EventServiceImpl struct {
-	repo storage.EventRepository
+	store        storage.Storage
+	repo         storage.EventRepository
+	calendarRepo storage.CalendarRepository
+	changes      storage.ChangeFeed
 }
 
 func NewEventService(store storage.Storage) EventService {
-	return &EventServiceImpl{repo: store.EventRepository()}
+	return &EventServiceImpl{
+		store:        store,
+		repo:         store.EventRepository(),
+		calendarRepo: store.CalendarRepository(),
+		changes:      store.ChangeFeed(),
+	}
 }
 
 func (s *EventServiceImpl) CreateEvent(ctx context.Context, event dto.EventData) (uuid.UUID, error) {
@@ -38,16 +82,147 @@ func (s *EventServiceImpl) CreateEvent(ctx context.Context, event dto.EventData)
 		return uuid.Nil, status.Error(codes.InvalidArgument, "the beginning of events must be before the end")
 	}
 
-	return s.repo.CreateEvent(ctx, storageEvent)
+	if err := validateAttendees(storageEvent.Attendees); err != nil {
+		return uuid.Nil, err
+	}
+
+	// Событие и напоминание по умолчанию из его календаря создаются вместе
+	var id uuid.UUID
+	err := s.store.WithTx(ctx, func(tx storage.Storage) error {
+		txService := s.withStorage(tx)
+		calendar, err := txService.checkCalendar(ctx, storageEvent)
+		if err != nil {
+			return err
+		}
+
+		id, err = txService.repo.CreateEvent(ctx, storageEvent)
+		if err != nil {
+			return err
+		}
+		storageEvent.ID = id
+		return txService.createDefaultReminder(ctx, storageEvent, calendar)
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// CreateEventIdempotent создает событие один раз для ключа идемпотентности: повтор с тем же ключом и теми же
+// данными возвращает идентификатор уже созданного события, с другими данными - storage.ErrIdempotencyKeyReused.
+// Пустой ключ означает обычное создание.
+func (s *EventServiceImpl) CreateEventIdempotent(
+	ctx context.Context,
+	key string,
+	event dto.EventData,
+) (uuid.UUID, error) {
+	if key == "" {
+		return s.CreateEvent(ctx, event)
+	}
+	if err := validateIdempotencyKey(key); err != nil {
+		return uuid.Nil, err
+	}
+
+	// Одно и то же время в разных часовых поясах не делает запрос другим
+	event.StartTime = event.StartTime.UTC()
+	event.EndTime = event.EndTime.UTC()
+	const operation = "CreateEvent"
+	hash, err := requestHash(operation, event)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var response struct {
+		ID uuid.UUID `json:"id"`
+	}
+	scoped := storage.IdempotencyKey{UserID: event.UserID, Operation: operation, Key: key}
+	err = runIdempotent(ctx, s.store, scoped, hash, &response, func(tx storage.Storage) error {
+		var err error
+		response.ID, err = s.withStorage(tx).CreateEvent(ctx, event)
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return response.ID, nil
 }
 
 func (s *EventServiceImpl) UpdateEvent(ctx context.Context, id uuid.UUID, event dto.EventData) error {
 	storageEvent := dto.ToStorageEvent(event)
-	return s.repo.UpdateEvent(ctx, id, storageEvent)
+	if err := validateAttendees(storageEvent.Attendees); err != nil {
+		return err
+	}
+	// Календарь проверяется в транзакции: вне ее чтение идет с реплики, которая может отставать
+	return s.store.WithTx(ctx, func(tx storage.Storage) error {
+		txService := s.withStorage(tx)
+		if _, err := txService.checkCalendar(ctx, storageEvent); err != nil {
+			return err
+		}
+		return txService.repo.UpdateEvent(ctx, id, storageEvent)
+	})
 }
 
+// PatchEvent обновляет только перечисленные в fields поля события значениями из patch.
+// Ненулевой patch.Version задает ожидаемую версию события, иначе изменение применяется к актуальной версии.
+func (s *EventServiceImpl) PatchEvent(ctx context.Context, id uuid.UUID, patch dto.EventData, fields []string) error {
+	if len(fields) == 0 {
+		return status.Error(codes.InvalidArgument, "no fields to update")
+	}
+	for _, field := range fields {
+		if _, ok := eventPatchFields[field]; !ok {
+			return status.Errorf(codes.InvalidArgument, "unknown event field %q", field)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Событие читается и изменяется в одной транзакции: вне ее чтение идет с реплики, и при отставании
+		// реплики все попытки прочитали бы одну и ту же устаревшую версию
+		err := s.store.WithTx(ctx, func(tx storage.Storage) error {
+			return s.withStorage(tx).patchEvent(ctx, id, patch, fields)
+		})
+		if !errors.Is(err, storage.ErrVersionConflict) || patch.Version != 0 || attempt == patchAttempts {
+			return err
+		}
+	}
+}
+
+// patchEvent выполняет одну попытку частичного обновления события.
+func (s *EventServiceImpl) patchEvent(ctx context.Context, id uuid.UUID, patch dto.EventData, fields []string) error {
+	stored, err := s.repo.GetEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+	if patch.Version != 0 && patch.Version != stored.Version {
+		return storage.ErrVersionConflict
+	}
+
+	for _, field := range fields {
+		eventPatchFields[field](&stored, patch)
+	}
+
+	if !stored.StartTime.Before(stored.EndTime) {
+		return status.Error(codes.InvalidArgument, "the beginning of events must be before the end")
+	}
+	if stored.UserID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "user is required")
+	}
+	if err := validateAttendees(stored.Attendees); err != nil {
+		return err
+	}
+	if _, err := s.checkCalendar(ctx, stored); err != nil {
+		return err
+	}
+	return s.repo.UpdateEvent(ctx, id, stored)
+}
+
+// DeleteEvent удаляет событие вместе с его уведомлениями.
 func (s *EventServiceImpl) DeleteEvent(ctx context.Context, id uuid.UUID) error {
-	return s.repo.DeleteEvent(ctx, id)
+	return s.store.WithTx(ctx, func(tx storage.Storage) error {
+		if err := tx.EventRepository().DeleteEvent(ctx, id); err != nil {
+			return err
+		}
+		return tx.NotificationRepository().DeleteEventNotifications(ctx, id)
+	})
 }
 
 func (s *EventServiceImpl) GetEvent(ctx context.Context, id uuid.UUID) (dto.EventData, error) {
@@ -63,9 +238,249 @@ func (s *EventServiceImpl) ListEvents(ctx context.Context, start, end time.Time)
 	if err != nil {
 		return nil, err
 	}
+	return fromStorageEvents(storageEvents), nil
+}
+
+func (s *EventServiceImpl) ListEventsByCalendars(
+	ctx context.Context,
+	calendarIDs []uuid.UUID,
+	start,
+	end time.Time,
+) ([]dto.EventData, error) {
+	storageEvents, err := s.repo.ListEventsByCalendars(ctx, calendarIDs, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return fromStorageEvents(storageEvents), nil
+}
+
+// WatchEvents возвращает поток изменений событий пользователя до отмены ctx.
+func (s *EventServiceImpl) WatchEvents(ctx context.Context, userID uuid.UUID) (<-chan dto.EventChangeData, error) {
+	storageChanges, err := s.changes.Subscribe(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("on subscribe to event changes: %w", err)
+	}
+
+	changes := make(chan dto.EventChangeData)
+	go func() {
+		defer close(changes)
+		for change := range storageChanges {
+			select {
+			case changes <- dto.FromStorageEventChange(change):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// SyncEvents возвращает изменения событий пользователя после состояния, зафиксированного в токене.
+// Пустой токен означает первую синхронизацию: возвращаются только существующие события.
+func (s *EventServiceImpl) SyncEvents(
+	ctx context.Context,
+	userID uuid.UUID,
+	token string,
+	limit int,
+) (dto.SyncResult, error) {
+	sinceVersion, err := decodeSyncToken(token)
+	if err != nil {
+		return dto.SyncResult{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	switch {
+	case limit < 0:
+		return dto.SyncResult{}, status.Error(codes.InvalidArgument, "limit must not be negative")
+	case limit == 0:
+		limit = defaultSyncLimit
+	case limit > maxSyncLimit:
+		limit = maxSyncLimit
+	}
+
+	// Одна лишняя запись показывает, остались ли изменения после текущей страницы
+	storageEvents, err := s.repo.SyncEvents(ctx, userID, sinceVersion, limit+1)
+	if err != nil {
+		return dto.SyncResult{}, err
+	}
+
+	result := dto.SyncResult{
+		Events:  []dto.EventData{},
+		Deleted: []uuid.UUID{},
+		HasMore: len(storageEvents) > limit,
+	}
+	if result.HasMore {
+		storageEvents = storageEvents[:limit]
+	}
+
+	nextVersion := sinceVersion
+	for _, event := range storageEvents {
+		if event.Deleted {
+			result.Deleted = append(result.Deleted, event.ID)
+		} else {
+			result.Events = append(result.Events, dto.FromStorageEvent(event))
+		}
+		nextVersion = event.Version
+	}
+	result.NextToken = encodeSyncToken(nextVersion)
+	return result, nil
+}
+
+// SearchEvents ищет события по словам из названия и описания с дополнительными фильтрами.
+func (s *EventServiceImpl) SearchEvents(ctx context.Context, search dto.EventSearchData) ([]dto.EventData, error) {
+	if !search.Start.IsZero() && !search.End.IsZero() && search.Start.After(search.End) {
+		return nil, status.Error(codes.InvalidArgument, "the beginning of the period must be before the end")
+	}
+
+	switch {
+	case search.Limit < 0:
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	case search.Limit == 0:
+		search.Limit = defaultSearchLimit
+	case search.Limit > maxSearchLimit:
+		search.Limit = maxSearchLimit
+	}
+
+	storageEvents, err := s.repo.SearchEvents(ctx, dto.ToStorageEventFilter(search))
+	if err != nil {
+		return nil, err
+	}
+	return fromStorageEvents(storageEvents), nil
+}
+
+// BatchEvents выполняет операции над событиями в одной транзакции. Если какая-то операция завершилась
+// ошибкой, не применяется ни одна: в результате для нее указана ошибка, остальные помечены как aborted.
+func (s *EventServiceImpl) BatchEvents(
+	ctx context.Context,
+	operations []dto.EventOperation,
+) (dto.EventBatchResult, error) {
+	if len(operations) == 0 {
+		return dto.EventBatchResult{}, status.Error(codes.InvalidArgument, "no operations in batch")
+	}
+	if len(operations) > maxBatchSize {
+		return dto.EventBatchResult{}, status.Errorf(codes.InvalidArgument, "batch exceeds %d operations", maxBatchSize)
+	}
+
+	results := make([]dto.EventOperationResult, len(operations))
+	failed := -1
+	err := s.store.WithTx(ctx, func(tx storage.Storage) error {
+		txService := s.withStorage(tx)
+		for i, operation := range operations {
+			id, err := txService.applyOperation(ctx, operation)
+			if err != nil {
+				failed = i
+				results[i] = dto.EventOperationResult{
+					ID:     operation.ID,
+					Status: dto.OperationFailed,
+					Error:  status.Convert(err).Message(),
+				}
+				return errBatchAborted
+			}
+			results[i] = dto.EventOperationResult{ID: id, Status: dto.OperationOK}
+		}
+		return nil
+	})
+
+	if failed >= 0 {
+		for i, operation := range operations {
+			if i != failed {
+				// Идентификаторы созданных событий не возвращаются: их создание откачено
+				results[i] = dto.EventOperationResult{ID: operation.ID, Status: dto.OperationAborted}
+			}
+		}
+		return dto.EventBatchResult{Applied: false, Results: results}, nil
+	}
+	if err != nil {
+		return dto.EventBatchResult{}, err
+	}
+	return dto.EventBatchResult{Applied: true, Results: results}, nil
+}
+
+func (s *EventServiceImpl) applyOperation(ctx context.Context, operation dto.EventOperation) (uuid.UUID, error) {
+	switch operation.Op {
+	case dto.EventOperationCreate:
+		return s.CreateEvent(ctx, operation.Event)
+	case dto.EventOperationUpdate, dto.EventOperationDelete:
+		if operation.ID == uuid.Nil {
+			return uuid.Nil, status.Errorf(codes.InvalidArgument, "%s operation requires id", operation.Op)
+		}
+		if operation.Op == dto.EventOperationUpdate {
+			return operation.ID, s.UpdateEvent(ctx, operation.ID, operation.Event)
+		}
+		return operation.ID, s.DeleteEvent(ctx, operation.ID)
+	default:
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "unknown operation %q", operation.Op)
+	}
+}
+
+// withStorage возвращает сервис, работающий с хранилищем транзакции tx.
+func (s *EventServiceImpl) withStorage(tx storage.Storage) *EventServiceImpl {
+	return &EventServiceImpl{
+		store:        tx,
+		repo:         tx.EventRepository(),
+		calendarRepo: tx.CalendarRepository(),
+		changes:      s.changes,
+	}
+}
+
+// checkCalendar проверяет, что календарь события существует и принадлежит владельцу события, и возвращает его.
+// Для события без календаря возвращается пустой календарь.
+func (s *EventServiceImpl) checkCalendar(ctx context.Context, event storage.Event) (storage.Calendar, error) {
+	if event.CalendarID == uuid.Nil {
+		return storage.Calendar{}, nil
+	}
+
+	calendar, err := s.calendarRepo.GetCalendar(ctx, event.CalendarID)
+	if errors.Is(err, storage.ErrCalendarNotFound) {
+		return storage.Calendar{}, status.Error(codes.InvalidArgument, "calendar does not exist")
+	}
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+
+	if calendar.UserID != event.UserID {
+		return storage.Calendar{}, status.Error(codes.InvalidArgument, "calendar belongs to another user")
+	}
+	return calendar, nil
+}
+
+// createDefaultReminder создает уведомление о событии за DefaultReminder календаря до его начала.
+func (s *EventServiceImpl) createDefaultReminder(
+	ctx context.Context,
+	event storage.Event,
+	calendar storage.Calendar,
+) error {
+	if calendar.DefaultReminder <= 0 {
+		return nil
+	}
+
+	_, err := s.store.NotificationRepository().CreateNotification(ctx, storage.Notification{
+		EventID: event.ID,
+		UserID:  event.UserID,
+		Time:    event.StartTime.Add(-calendar.DefaultReminder),
+		Message: event.Title,
+		Sent:    dto.NotificationOnWait,
+	})
+	if err != nil {
+		return fmt.Errorf("on create default reminder: %w", err)
+	}
+	return nil
+}
+
+// validateAttendees проверяет, что участники заданы адресами электронной почты.
+func validateAttendees(attendees []string) error {
+	for _, attendee := range attendees {
+		address, err := mail.ParseAddress(attendee)
+		if err != nil || address.Address != attendee {
+			return status.Errorf(codes.InvalidArgument, "invalid attendee %q: expected an email address", attendee)
+		}
+	}
+	return nil
+}
+
+func fromStorageEvents(storageEvents []storage.Event) []dto.EventData {
 	events := make([]dto.EventData, len(storageEvents))
 	for i, storageEvent := range storageEvents {
 		events[i] = dto.FromStorageEvent(storageEvent)
 	}
-	return events, nil
+	return events
 }
@@ -2,14 +2,18 @@ package services
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestEventService(t *testing.T) {
@@ -31,6 +35,44 @@ func TestEventService(t *testing.T) {
 		assert.NotEqual(t, uuid.Nil, id)
 	})
 
+	t.Run("CreateEventIdempotent", func(t *testing.T) {
+		key := uuid.NewString()
+		id, err := service.CreateEventIdempotent(ctx, key, event)
+		require.NoError(t, err)
+
+		// Повтор с тем же ключом возвращает то же событие, в том числе со временем в другом часовом поясе
+		retry := event
+		retry.StartTime = event.StartTime.In(time.FixedZone("UTC+3", 3*60*60))
+		retriedID, err := service.CreateEventIdempotent(ctx, key, retry)
+		require.NoError(t, err)
+		assert.Equal(t, id, retriedID)
+
+		changed := event
+		changed.Title = "Another Event"
+		_, err = service.CreateEventIdempotent(ctx, key, changed)
+		assert.ErrorIs(t, err, storage.ErrIdempotencyKeyReused)
+
+		// Ключ действует в пределах пользователя: другой пользователь с тем же ключом создает свое событие
+		otherUser := event
+		otherUser.UserID = uuid.New()
+		otherID, err := service.CreateEventIdempotent(ctx, key, otherUser)
+		require.NoError(t, err)
+		assert.NotEqual(t, id, otherID)
+
+		// Ошибка проверки не сохраняется: исправленный запрос с тем же ключом создает событие
+		invalidKey := uuid.NewString()
+		invalid := event
+		invalid.EndTime = event.StartTime
+		_, err = service.CreateEventIdempotent(ctx, invalidKey, invalid)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		fixedID, err := service.CreateEventIdempotent(ctx, invalidKey, event)
+		require.NoError(t, err)
+		assert.NotEqual(t, id, fixedID)
+
+		_, err = service.CreateEventIdempotent(ctx, strings.Repeat("k", 256), event)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
 	t.Run("GetEvent", func(t *testing.T) {
 		id, err := service.CreateEvent(ctx, event)
 		require.NoError(t, err)
@@ -79,6 +121,42 @@ func TestEventService(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("DefaultReminder", func(t *testing.T) {
+		calendarID, err := store.CalendarRepository().CreateCalendar(ctx, storage.Calendar{
+			UserID:          event.UserID,
+			Name:            "Work",
+			DefaultReminder: 15 * time.Minute,
+		})
+		require.NoError(t, err)
+
+		withCalendar := event
+		withCalendar.CalendarID = calendarID
+		id, err := service.CreateEvent(ctx, withCalendar)
+		require.NoError(t, err)
+
+		notifications, err := store.NotificationRepository().ListNotifications(
+			ctx,
+			event.StartTime.Add(-time.Hour),
+			event.StartTime,
+		)
+		require.NoError(t, err)
+
+		var reminder *storage.Notification
+		for i := range notifications {
+			if notifications[i].EventID == id {
+				reminder = &notifications[i]
+			}
+		}
+		require.NotNil(t, reminder)
+		assert.WithinDuration(t, event.StartTime.Add(-15*time.Minute), reminder.Time, time.Second)
+		assert.Equal(t, event.Title, reminder.Message)
+
+		// Уведомления удаляются вместе с событием
+		require.NoError(t, service.DeleteEvent(ctx, id))
+		_, err = store.NotificationRepository().GetNotification(ctx, reminder.ID)
+		assert.ErrorIs(t, err, storage.ErrNotificationNotFound)
+	})
+
 	t.Run("ListEvents", func(t *testing.T) {
 		start := time.Now()
 		end := start.Add(24 * time.Hour)
@@ -87,4 +165,130 @@ func TestEventService(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotEmpty(t, events)
 	})
+
+	t.Run("SyncEvents", func(t *testing.T) {
+		userID := uuid.New()
+		syncEvent := event
+		syncEvent.UserID = userID
+
+		first, err := service.CreateEvent(ctx, syncEvent)
+		require.NoError(t, err)
+		second, err := service.CreateEvent(ctx, syncEvent)
+		require.NoError(t, err)
+
+		page, err := service.SyncEvents(ctx, userID, "", 1)
+		require.NoError(t, err)
+		require.Len(t, page.Events, 1)
+		assert.Equal(t, first, page.Events[0].ID)
+		assert.True(t, page.HasMore)
+
+		page, err = service.SyncEvents(ctx, userID, page.NextToken, 1)
+		require.NoError(t, err)
+		require.Len(t, page.Events, 1)
+		assert.Equal(t, second, page.Events[0].ID)
+		assert.False(t, page.HasMore)
+
+		require.NoError(t, service.DeleteEvent(ctx, first))
+
+		page, err = service.SyncEvents(ctx, userID, page.NextToken, 0)
+		require.NoError(t, err)
+		assert.Empty(t, page.Events)
+		assert.Equal(t, []uuid.UUID{first}, page.Deleted)
+
+		unchanged, err := service.SyncEvents(ctx, userID, page.NextToken, 0)
+		require.NoError(t, err)
+		assert.Empty(t, unchanged.Events)
+		assert.Empty(t, unchanged.Deleted)
+		assert.Equal(t, page.NextToken, unchanged.NextToken)
+	})
+
+	t.Run("SyncEventsInvalidToken", func(t *testing.T) {
+		_, err := service.SyncEvents(ctx, uuid.New(), "not-a-token", 0)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("PatchEvent", func(t *testing.T) {
+		id, err := service.CreateEvent(ctx, event)
+		require.NoError(t, err)
+
+		patch := dto.EventData{Title: "Patched Event", Description: "ignored"}
+		require.NoError(t, service.PatchEvent(ctx, id, patch, []string{"title"}))
+
+		patched, err := service.GetEvent(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "Patched Event", patched.Title)
+		assert.Equal(t, event.Description, patched.Description)
+		assert.Equal(t, event.UserID, patched.UserID)
+
+		err = service.PatchEvent(ctx, id, dto.EventData{EndTime: event.StartTime}, []string{"end_time"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		err = service.PatchEvent(ctx, id, patch, []string{"id"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		patch.Version = patched.Version - 1
+		err = service.PatchEvent(ctx, id, patch, []string{"title"})
+		assert.ErrorIs(t, err, storage.ErrVersionConflict)
+	})
+
+	t.Run("SearchEvents", func(t *testing.T) {
+		searchEvent := event
+		searchEvent.Title = "Quarterly budget review"
+		searchEvent.Attendees = []string{"alice@example.com"}
+		id, err := service.CreateEvent(ctx, searchEvent)
+		require.NoError(t, err)
+
+		events, err := service.SearchEvents(ctx, dto.EventSearchData{Query: "budget", Attendee: "alice@example.com"})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, id, events[0].ID)
+		assert.Equal(t, searchEvent.Attendees, events[0].Attendees)
+
+		_, err = service.SearchEvents(ctx, dto.EventSearchData{Start: event.EndTime, End: event.StartTime})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		searchEvent.Attendees = []string{"not an email"}
+		_, err = service.CreateEvent(ctx, searchEvent)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("BatchEvents", func(t *testing.T) {
+		existing, err := service.CreateEvent(ctx, event)
+		require.NoError(t, err)
+
+		renamed := event
+		renamed.Title = "Renamed in batch"
+		result, err := service.BatchEvents(ctx, []dto.EventOperation{
+			{Op: dto.EventOperationCreate, Event: event},
+			{Op: dto.EventOperationUpdate, ID: existing, Event: renamed},
+		})
+		require.NoError(t, err)
+		require.True(t, result.Applied)
+		require.Len(t, result.Results, 2)
+		assert.Equal(t, dto.OperationOK, result.Results[0].Status)
+		assert.NotEqual(t, uuid.Nil, result.Results[0].ID)
+
+		updated, err := service.GetEvent(ctx, existing)
+		require.NoError(t, err)
+		assert.Equal(t, renamed.Title, updated.Title)
+
+		// Ошибка второй операции отменяет удаление из первой
+		result, err = service.BatchEvents(ctx, []dto.EventOperation{
+			{Op: dto.EventOperationDelete, ID: existing},
+			{Op: dto.EventOperationDelete, ID: uuid.New()},
+			{Op: dto.EventOperationCreate, Event: event},
+		})
+		require.NoError(t, err)
+		assert.False(t, result.Applied)
+		assert.Equal(t, dto.OperationAborted, result.Results[0].Status)
+		assert.Equal(t, dto.OperationFailed, result.Results[1].Status)
+		assert.NotEmpty(t, result.Results[1].Error)
+		assert.Equal(t, dto.OperationAborted, result.Results[2].Status)
+
+		_, err = service.GetEvent(ctx, existing)
+		assert.NoError(t, err)
+
+		_, err = service.BatchEvents(ctx, nil)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
 }
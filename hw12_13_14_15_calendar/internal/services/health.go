@@ -11,19 +11,36 @@ type HealthService interface {
 	HealthCheck(ctx context.Context) error
 }
 
+// HealthChecker зависимость, доступность которой входит в проверку здоровья сервиса.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
 type HealthServiceImpl struct {
 	storage storage.Storage
+	// broker брокер сообщений, проверяется только у воркеров
+	broker HealthChecker
 }
 
 func NewHealthService(store storage.Storage) *HealthServiceImpl {
 	return &HealthServiceImpl{storage: store}
 }
 
+// NewWorkerHealthService возвращает проверку здоровья воркера: кроме хранилища проверяется брокер сообщений.
+func NewWorkerHealthService(store storage.Storage, broker HealthChecker) *HealthServiceImpl {
+	return &HealthServiceImpl{storage: store, broker: broker}
+}
+
 func (s HealthServiceImpl) HealthCheck(ctx context.Context) error {
 	err := s.storage.HealthCheck(ctx)
 	if err != nil {
-		err = fmt.Errorf("on storage health check: %w", err)
+		return fmt.Errorf("on storage health check: %w", err)
 	}
 
-	return err
+	if s.broker != nil {
+		if err := s.broker.HealthCheck(ctx); err != nil {
+			return fmt.Errorf("on broker health check: %w", err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// idempotencyKeyTTL время, в течение которого повтор запроса с тем же ключом идемпотентности
+	// получает исходный результат.
+	idempotencyKeyTTL       = 24 * time.Hour
+	maxIdempotencyKeyLength = 255
+)
+
+func validateIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLength {
+		return status.Errorf(codes.InvalidArgument, "idempotency key exceeds %d characters", maxIdempotencyKeyLength)
+	}
+	return nil
+}
+
+// requestHash возвращает отпечаток запроса операции operation, по которому повтор запроса отличается
+// от другого запроса с тем же ключом.
+func requestHash(operation string, request interface{}) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("on encoding request for idempotency key: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(operation+"\n"), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// runIdempotent выполняет fn в транзакции и сохраняет ее результат под ключом key. Ключ действует
+// в пределах пользователя и операции, поэтому клиенты с одинаковыми ключами не получают чужие результаты.
+// Если результат для ключа уже сохранен, fn не выполняется, а сохраненный результат декодируется
+// в response. Повтор с другим запросом возвращает storage.ErrIdempotencyKeyReused.
+func runIdempotent(
+	ctx context.Context,
+	store storage.Storage,
+	key storage.IdempotencyKey,
+	hash string,
+	response interface{},
+	fn func(tx storage.Storage) error,
+) error {
+	err := store.WithTx(ctx, func(tx storage.Storage) error {
+		repo := tx.IdempotencyRepository()
+		record, err := repo.GetIdempotencyRecord(ctx, key)
+		if err == nil {
+			return replayIdempotent(record, hash, response)
+		}
+		if !errors.Is(err, storage.ErrIdempotencyRecordNotFound) {
+			return err
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+		data, err := json.Marshal(response)
+		if err != nil {
+			return fmt.Errorf("on encoding idempotent response: %w", err)
+		}
+		return repo.CreateIdempotencyRecord(ctx, storage.IdempotencyRecord{
+			IdempotencyKey: key,
+			RequestHash:    hash,
+			Response:       data,
+			ExpiresAt:      time.Now().Add(idempotencyKeyTTL),
+		})
+	})
+	if !errors.Is(err, storage.ErrIdempotencyKeyExists) {
+		return err
+	}
+
+	// Параллельный запрос с тем же ключом зафиксировал результат раньше, транзакция этого запроса откачена
+	record, getErr := store.IdempotencyRepository().GetIdempotencyRecord(ctx, key)
+	if getErr != nil {
+		return errors.Join(err, getErr)
+	}
+	return replayIdempotent(record, hash, response)
+}
+
+func replayIdempotent(record storage.IdempotencyRecord, hash string, response interface{}) error {
+	if record.RequestHash != hash {
+		return storage.ErrIdempotencyKeyReused
+	}
+	if err := json.Unmarshal(record.Response, response); err != nil {
+		return fmt.Errorf("on decoding idempotent response: %w", err)
+	}
+	return nil
+}
@@ -2,29 +2,44 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type NotificationService interface {
 	CreateNotification(ctx context.Context, notification dto.NotificationData) (uuid.UUID, error)
 	UpdateNotification(ctx context.Context, id uuid.UUID, notification dto.NotificationData) error
+	PatchNotification(ctx context.Context, id uuid.UUID, patch dto.NotificationData, fields []string) error
 	DeleteNotification(ctx context.Context, id uuid.UUID) error
 	GetNotification(ctx context.Context, id uuid.UUID) (dto.NotificationData, error)
 	ListNotifications(ctx context.Context, start, end time.Time) ([]dto.NotificationData, error)
 	DeleteSentNotifications(ctx context.Context) error
 }
 
+// notificationPatchFields описывает поля уведомления, доступные для частичного обновления.
+// Имена совпадают с путями google.protobuf.FieldMask в UpdateNotificationRequest.
+var notificationPatchFields = map[string]func(dst *storage.Notification, src dto.NotificationData){
+	"event_id": func(dst *storage.Notification, src dto.NotificationData) { dst.EventID = src.EventID },
+	"user_id":  func(dst *storage.Notification, src dto.NotificationData) { dst.UserID = src.UserID },
+	"time":     func(dst *storage.Notification, src dto.NotificationData) { dst.Time = src.Time },
+	"message":  func(dst *storage.Notification, src dto.NotificationData) { dst.Message = src.Message },
+	"sent":     func(dst *storage.Notification, src dto.NotificationData) { dst.Sent = src.Sent },
+}
+
 type NotificationServiceImpl struct {
-	repo storage.NotificationRepository
+	store storage.Storage
+	repo  storage.NotificationRepository
 }
 
 func NewNotificationService(store storage.Storage) NotificationService {
-	return &NotificationServiceImpl{repo: store.NotificationRepository()}
+	return &NotificationServiceImpl{store: store, repo: store.NotificationRepository()}
 }
 
 func (s *NotificationServiceImpl) CreateNotification(
@@ -45,6 +60,62 @@ func (s *NotificationServiceImpl) UpdateNotification(
 	return s.repo.UpdateNotification(ctx, id, storageNotification)
 }
 
+// PatchNotification обновляет только перечисленные в fields поля уведомления значениями из patch.
+// Ненулевой patch.Version задает ожидаемую версию уведомления, иначе изменение применяется к актуальной версии.
+func (s *NotificationServiceImpl) PatchNotification(
+	ctx context.Context,
+	id uuid.UUID,
+	patch dto.NotificationData,
+	fields []string,
+) error {
+	if len(fields) == 0 {
+		return status.Error(codes.InvalidArgument, "no fields to update")
+	}
+	for _, field := range fields {
+		if _, ok := notificationPatchFields[field]; !ok {
+			return status.Errorf(codes.InvalidArgument, "unknown notification field %q", field)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Уведомление читается и изменяется в одной транзакции, чтобы не читать его с отстающей реплики
+		err := s.store.WithTx(ctx, func(tx storage.Storage) error {
+			return patchNotification(ctx, tx.NotificationRepository(), id, patch, fields)
+		})
+		if !errors.Is(err, storage.ErrVersionConflict) || patch.Version != 0 || attempt == patchAttempts {
+			return err
+		}
+	}
+}
+
+// patchNotification выполняет одну попытку частичного обновления уведомления.
+func patchNotification(
+	ctx context.Context,
+	repo storage.NotificationRepository,
+	id uuid.UUID,
+	patch dto.NotificationData,
+	fields []string,
+) error {
+	stored, err := repo.GetNotification(ctx, id)
+	if err != nil {
+		return err
+	}
+	if patch.Version != 0 && patch.Version != stored.Version {
+		return storage.ErrVersionConflict
+	}
+
+	for _, field := range fields {
+		notificationPatchFields[field](&stored, patch)
+	}
+
+	switch stored.Sent {
+	case dto.NotificationOnWait, dto.NotificationOnQueue, dto.NotificationSent:
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown notification status %q", stored.Sent)
+	}
+	return repo.UpdateNotification(ctx, id, stored)
+}
+
 func (s *NotificationServiceImpl) DeleteNotification(ctx context.Context, id uuid.UUID) error {
 	return s.repo.DeleteNotification(ctx, id)
 }
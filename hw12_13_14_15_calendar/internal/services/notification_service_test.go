@@ -10,6 +10,8 @@ import (
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestNotificationService(t *testing.T) {
@@ -87,4 +89,21 @@ func TestNotificationService(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotEmpty(t, notifications)
 	})
+
+	t.Run("PatchNotification", func(t *testing.T) {
+		id, err := service.CreateNotification(ctx, notification)
+		require.NoError(t, err)
+
+		patch := dto.NotificationData{Sent: dto.NotificationSent}
+		require.NoError(t, service.PatchNotification(ctx, id, patch, []string{"sent"}))
+
+		patched, err := service.GetNotification(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, dto.NotificationSent, patched.Sent)
+		assert.Equal(t, notification.Message, patched.Message)
+
+		patch.Sent = "unknown"
+		err = service.PatchNotification(ctx, id, patch, []string{"sent"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
 }
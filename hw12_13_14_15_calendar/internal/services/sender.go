@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/email"
@@ -10,14 +11,22 @@ import (
 
 type SenderService struct {
 	emailClient email.Client
+	templates   atomic.Pointer[email.Templates]
 	logger      logger.Logger
 }
 
-func NewSenderService(emailClient email.Client, logger logger.Logger) *SenderService {
-	return &SenderService{
+func NewSenderService(emailClient email.Client, templates *email.Templates, logger logger.Logger) *SenderService {
+	service := &SenderService{
 		emailClient: emailClient,
 		logger:      logger,
 	}
+	service.templates.Store(templates)
+	return service
+}
+
+// SetTemplates заменяет шаблоны писем, например при перезагрузке конфигурации.
+func (s *SenderService) SetTemplates(templates *email.Templates) {
+	s.templates.Store(templates)
 }
 
 func (s *SenderService) ProcessNotification(notification dto.NotificationData) error {
@@ -35,8 +44,10 @@ func (s *SenderService) ProcessNotification(notification dto.NotificationData) e
 func (s *SenderService) sendEmailNotification(notification dto.NotificationData) error {
 	// Формируем email
 	to := "example@example.com" // Тут могла бы быть обработка получения EMAIL от пользователя
-	subject := fmt.Sprintf("Calendar Notification #%s", notification.ID)
-	body := notification.Message
+	subject, body, err := s.templates.Load().Render(notification)
+	if err != nil {
+		return err
+	}
 
 	// Отправляем email
 	if err := s.emailClient.SendEmail(to, subject, body); err != nil {
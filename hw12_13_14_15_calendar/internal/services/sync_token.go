@@ -0,0 +1,41 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// syncTokenPrefix задает формат токена и позволяет менять его без поломки старых клиентов.
+const syncTokenPrefix = "v1:"
+
+var errInvalidSyncToken = errors.New("invalid sync token")
+
+// encodeSyncToken упаковывает версию последнего отданного изменения в непрозрачный для клиента токен.
+func encodeSyncToken(version int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(version, 10)))
+}
+
+// decodeSyncToken возвращает версию из токена. Пустой токен означает полную синхронизацию.
+func decodeSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, errInvalidSyncToken
+	}
+
+	value, ok := strings.CutPrefix(string(raw), syncTokenPrefix)
+	if !ok {
+		return 0, errInvalidSyncToken
+	}
+
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version < 0 {
+		return 0, errInvalidSyncToken
+	}
+	return version, nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// subscriberBuffer размер буфера канала подписчика.
+const subscriberBuffer = 64
+
+// Broker in-process реализация ChangeFeed: раздает опубликованные изменения подписчикам по пользователю.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan EventChange]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[uuid.UUID]map[chan EventChange]struct{})}
+}
+
+func (b *Broker) Subscribe(ctx context.Context, userID uuid.UUID) (<-chan EventChange, error) {
+	ch := make(chan EventChange, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan EventChange]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.unsubscribe(userID, ch)
+	}()
+
+	return ch, nil
+}
+
+// Publish рассылает изменение подписчикам владельца события. Медленный подписчик, у которого
+// переполнен буфер, отключается: клиенту следует переподключиться и досинхронизироваться.
+func (b *Broker) Publish(change EventChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[change.Event.UserID] {
+		select {
+		case ch <- change:
+		default:
+			b.remove(change.Event.UserID, ch)
+		}
+	}
+}
+
+// Close отключает всех подписчиков.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for userID, channels := range b.subscribers {
+		for ch := range channels {
+			b.remove(userID, ch)
+		}
+	}
+}
+
+func (b *Broker) unsubscribe(userID uuid.UUID, ch chan EventChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(userID, ch)
+}
+
+func (b *Broker) remove(userID uuid.UUID, ch chan EventChange) {
+	channels, ok := b.subscribers[userID]
+	if !ok {
+		return
+	}
+	if _, ok := channels[ch]; !ok {
+		return
+	}
+	delete(channels, ch)
+	close(ch)
+	if len(channels) == 0 {
+		delete(b.subscribers, userID)
+	}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_PublishToOwner(t *testing.T) {
+	broker := NewBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	owner := uuid.New()
+	ownerChanges, err := broker.Subscribe(ctx, owner)
+	require.NoError(t, err)
+	otherChanges, err := broker.Subscribe(ctx, uuid.New())
+	require.NoError(t, err)
+
+	broker.Publish(EventChange{Type: ChangeCreated, Event: Event{ID: uuid.New(), UserID: owner}})
+
+	select {
+	case change := <-ownerChanges:
+		assert.Equal(t, ChangeCreated, change.Type)
+	case <-time.After(time.Second):
+		t.Fatal("owner did not receive change")
+	}
+
+	select {
+	case change := <-otherChanges:
+		t.Fatalf("unexpected change for another user: %+v", change)
+	default:
+	}
+}
+
+func TestBroker_UnsubscribeOnCancel(t *testing.T) {
+	broker := NewBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	changes, err := broker.Subscribe(ctx, uuid.New())
+	require.NoError(t, err)
+
+	cancel()
+
+	select {
+	case _, ok := <-changes:
+		assert.False(t, ok, "channel must be closed after cancel")
+	case <-time.After(time.Second):
+		t.Fatal("channel was not closed")
+	}
+}
+
+func TestBroker_DropSlowSubscriber(t *testing.T) {
+	broker := NewBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	userID := uuid.New()
+	changes, err := broker.Subscribe(ctx, userID)
+	require.NoError(t, err)
+
+	for i := 0; i < subscriberBuffer+1; i++ {
+		broker.Publish(EventChange{Type: ChangeUpdated, Event: Event{UserID: userID}})
+	}
+
+	received := 0
+	for range changes {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+}
@@ -0,0 +1,153 @@
+package cachestorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Backend хранилище записей кэша и счетчиков поколений.
+type Backend interface {
+	// Get возвращает значение ключа, ok == false, если ключа нет или его время жизни истекло.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Counters возвращает значения счетчиков, отсутствующий счетчик равен 0.
+	Counters(ctx context.Context, keys ...string) ([]int64, error)
+	// Incr увеличивает счетчик на 1. Счетчики не должны вытесняться, иначе устаревшие записи снова станут видны.
+	Incr(ctx context.Context, key string) (int64, error)
+	Close() error
+}
+
+// allScope область выборок всех событий за период. Ее поколение растет при любом изменении событий.
+const allScope = "all"
+
+// calendarScope область выборок событий календаря.
+func calendarScope(id uuid.UUID) string {
+	return "calendar:" + id.String()
+}
+
+// eventScopes возвращает области, выборки которых затрагивает изменение событий календарей calendarIDs.
+func eventScopes(calendarIDs ...uuid.UUID) []string {
+	scopes := []string{allScope}
+	for _, id := range calendarIDs {
+		if id != uuid.Nil {
+			scopes = append(scopes, calendarScope(id))
+		}
+	}
+	return scopes
+}
+
+// eventCache кэширует выборки событий за период. Ключ записи содержит поколения областей, от которых
+// зависит выборка, поэтому для инвалидации достаточно увеличить поколение: старые записи перестают
+// читаться и вытесняются по времени жизни.
+type eventCache struct {
+	backend Backend
+	ttl     time.Duration
+	logger  logger.Logger
+}
+
+func generationKey(scope string) string {
+	return "events:gen:" + scope
+}
+
+// listKey ключ выборки всех событий за период.
+func (c *eventCache) listKey(ctx context.Context, start, end time.Time) (string, error) {
+	generations, err := c.backend.Counters(ctx, generationKey(allScope))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("events:list:%d:%s", generations[0], window(start, end)), nil
+}
+
+// calendarsKey ключ выборки событий календарей за период. Порядок и повторы календарей не влияют на ключ.
+func (c *eventCache) calendarsKey(
+	ctx context.Context,
+	calendarIDs []uuid.UUID,
+	start,
+	end time.Time,
+) (string, error) {
+	ids := slices.Clone(calendarIDs)
+	slices.SortFunc(ids, func(a, b uuid.UUID) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	ids = slices.Compact(ids)
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = generationKey(calendarScope(id))
+	}
+	generations, err := c.backend.Counters(ctx, keys...)
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = id.String() + "=" + strconv.FormatInt(generations[i], 10)
+	}
+	return fmt.Sprintf("events:calendars:%s:%s", strings.Join(parts, ","), window(start, end)), nil
+}
+
+func window(start, end time.Time) string {
+	return strconv.FormatInt(start.UnixNano(), 10) + ":" + strconv.FormatInt(end.UnixNano(), 10)
+}
+
+// get возвращает выборку из кэша, а при промахе загружает ее через load и сохраняет.
+// Ошибки кэша не прерывают запрос: выборка загружается из хранилища.
+func (c *eventCache) get(
+	ctx context.Context,
+	key func() (string, error),
+	load func() ([]storage.Event, error),
+) ([]storage.Event, error) {
+	cacheKey, err := key()
+	if err != nil {
+		c.logger.Errorf("on reading cache generations: %s", err)
+		return load()
+	}
+
+	data, ok, err := c.backend.Get(ctx, cacheKey)
+	if err != nil {
+		c.logger.Errorf("on reading cache: %s", err)
+	}
+	if ok {
+		var events []storage.Event
+		decodeErr := json.Unmarshal(data, &events)
+		if decodeErr == nil {
+			return events, nil
+		}
+		c.logger.Errorf("on decoding cache entry %s: %s", cacheKey, decodeErr)
+	}
+
+	events, err := load()
+	if err != nil {
+		return nil, err
+	}
+	data, err = json.Marshal(events)
+	if err != nil {
+		c.logger.Errorf("on encoding cache entry: %s", err)
+		return events, nil
+	}
+	if err := c.backend.Set(ctx, cacheKey, data, c.ttl); err != nil {
+		c.logger.Errorf("on writing cache: %s", err)
+	}
+	return events, nil
+}
+
+// invalidate увеличивает поколения областей. Если увеличить поколение не удалось, устаревшие записи
+// остаются видны до истечения времени жизни.
+func (c *eventCache) invalidate(ctx context.Context, scopes ...string) {
+	for _, scope := range scopes {
+		if _, err := c.backend.Incr(ctx, generationKey(scope)); err != nil {
+			c.logger.Errorf("on invalidating cache scope %s: %s", scope, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package cachestorage
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeEntry struct {
+	value   string
+	expires time.Time
+}
+
+// fakeRedis сервер с подмножеством команд Redis, которые использует клиент кэша.
+type fakeRedis struct {
+	listener net.Listener
+	password string
+	mu       sync.Mutex
+	data     map[string]fakeEntry
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeRedis{listener: listener, password: password, data: make(map[string]fakeEntry)}
+	go server.serve()
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	return server
+}
+
+func (s *fakeRedis) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := s.password == ""
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		var reply string
+		switch name := strings.ToUpper(args[0]); {
+		case name == "AUTH":
+			authenticated = len(args) == 2 && args[1] == s.password
+			reply = "+OK\r\n"
+			if !authenticated {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		default:
+			reply = s.exec(name, args[1:])
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedis) exec(name string, args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case "PING":
+		return "+PONG\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		return bulk(s.get(args[0]))
+	case "MGET":
+		reply := "*" + strconv.Itoa(len(args)) + "\r\n"
+		for _, key := range args {
+			reply += bulk(s.get(key))
+		}
+		return reply
+	case "SET":
+		entry := fakeEntry{value: args[1]}
+		if len(args) == 4 && strings.ToUpper(args[2]) == "PX" {
+			ms, err := strconv.Atoi(args[3])
+			if err != nil {
+				return "-ERR value is not an integer or out of range\r\n"
+			}
+			entry.expires = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		s.data[args[0]] = entry
+		return "+OK\r\n"
+	case "INCR":
+		value, _ := s.get(args[0])
+		counter, err := strconv.ParseInt(value, 10, 64)
+		if value != "" && err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		counter++
+		s.data[args[0]] = fakeEntry{value: strconv.FormatInt(counter, 10)}
+		return ":" + strconv.FormatInt(counter, 10) + "\r\n"
+	default:
+		return "-ERR unknown command '" + name + "'\r\n"
+	}
+}
+
+// get возвращает значение ключа, ok == false для отсутствующего или истекшего ключа. Вызывается под s.mu.
+func (s *fakeRedis) get(key string) (string, bool) {
+	entry, ok := s.data[key]
+	if !ok {
+		return "", false
+	}
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		delete(s.data, key)
+		return "", false
+	}
+	return entry.value, true
+}
+
+func bulk(value string, ok bool) string {
+	if !ok {
+		return "$-1\r\n"
+	}
+	return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, errors.New("expected array")
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || count < 1 {
+		return nil, errors.New("invalid array size")
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
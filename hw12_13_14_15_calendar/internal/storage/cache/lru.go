@@ -0,0 +1,73 @@
+package cachestorage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+type lruEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// LRU кэш в памяти процесса, вытесняющий давно не использованные записи. Счетчики поколений хранятся
+// отдельно от записей и не вытесняются.
+type LRU struct {
+	entries  *lru.Cache[string, lruEntry]
+	mu       sync.Mutex
+	counters map[string]int64
+}
+
+func NewLRU(size int) (*LRU, error) {
+	entries, err := lru.New[string, lruEntry](size)
+	if err != nil {
+		return nil, err
+	}
+	return &LRU{entries: entries, counters: make(map[string]int64)}, nil
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	entry, ok := c.entries.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.entries.Remove(key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := lruEntry{value: value}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+	c.entries.Add(key, entry)
+	return nil
+}
+
+func (c *LRU) Counters(_ context.Context, keys ...string) ([]int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := make([]int64, len(keys))
+	for i, key := range keys {
+		values[i] = c.counters[key]
+	}
+	return values, nil
+}
+
+func (c *LRU) Incr(_ context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters[key]++
+	return c.counters[key], nil
+}
+
+func (c *LRU) Close() error {
+	c.entries.Purge()
+	return nil
+}
@@ -0,0 +1,265 @@
+package cachestorage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+)
+
+const (
+	redisTimeout  = 2 * time.Second
+	redisPoolSize = 8
+)
+
+// RedisError ошибка, которую вернул сервер. Соединение после нее остается рабочим.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// Redis клиент сервера, совместимого с протоколом Redis (RESP). Для работы кэша нужны только
+// команды GET, SET, MGET и INCR. Сервер должен вытеснять только ключи со временем жизни
+// (политика noeviction или volatile-*), так как счетчики поколений хранятся без него.
+type Redis struct {
+	address  string
+	password string
+	db       int
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func NewRedis(cfg config.CacheConfig) *Redis {
+	return &Redis{
+		address:  cfg.Address,
+		password: cfg.Password.Value(),
+		db:       cfg.DB,
+		idle:     make(chan *redisConn, redisPoolSize),
+	}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected GET reply %T", reply)
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(ctx, args...)
+	return err
+}
+
+func (r *Redis) Counters(ctx context.Context, keys ...string) ([]int64, error) {
+	reply, err := r.do(ctx, append([]string{"MGET"}, keys...)...)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok || len(items) != len(keys) {
+		return nil, fmt.Errorf("unexpected MGET reply %v", reply)
+	}
+
+	values := make([]int64, len(keys))
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		data, ok := item.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("unexpected MGET item %T", item)
+		}
+		if values[i], err = strconv.ParseInt(string(data), 10, 64); err != nil {
+			return nil, fmt.Errorf("on parsing counter %s: %w", keys[i], err)
+		}
+	}
+	return values, nil
+}
+
+func (r *Redis) Incr(ctx context.Context, key string) (int64, error) {
+	reply, err := r.do(ctx, "INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected INCR reply %T", reply)
+	}
+	return value, nil
+}
+
+// Ping проверяет доступность сервера.
+func (r *Redis) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
+	return err
+}
+
+func (r *Redis) Close() error {
+	for {
+		select {
+		case c := <-r.idle:
+			c.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do выполняет команду на соединении из пула. Соединение после сетевой ошибки закрывается.
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	c, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.do(ctx, args...)
+	var replyErr RedisError
+	if err != nil && !errors.As(err, &replyErr) {
+		c.conn.Close()
+		return nil, err
+	}
+
+	select {
+	case r.idle <- c:
+	default:
+		c.conn.Close()
+	}
+	return reply, err
+}
+
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: redisTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", r.address)
+	if err != nil {
+		return nil, fmt.Errorf("on connecting to redis: %w", err)
+	}
+	c := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if r.password != "" {
+		if _, err := c.do(ctx, "AUTH", r.password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("on authenticating to redis: %w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := c.do(ctx, "SELECT", strconv.Itoa(r.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("on selecting redis database: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *redisConn) do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisTimeout)
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if _, err := c.conn.Write(encodeCommand(args)); err != nil {
+		return nil, fmt.Errorf("on writing redis command: %w", err)
+	}
+	return readReply(c.reader)
+}
+
+// encodeCommand кодирует команду массивом bulk-строк.
+func encodeCommand(args []string) []byte {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// readReply читает ответ сервера: string для простой строки, []byte для bulk-строки, int64 для числа,
+// []interface{} для массива и nil для отсутствующего значения. Ответ-ошибка возвращается как RedisError.
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if line == "" {
+		return nil, errors.New("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, RedisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, fmt.Errorf("on reading redis reply: %w", err)
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			// Ошибка элемента массива не прерывает чтение ответа
+			if items[i], err = readReply(reader); err != nil && !errors.As(err, new(RedisError)) {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unexpected redis reply %q", line)
+	}
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("on reading redis reply: %w", err)
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("malformed redis reply %q", line)
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,60 @@
+package cachestorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeRedis(t, "secret")
+	client := NewRedis(config.CacheConfig{Address: server.addr(), Password: "secret", DB: 1})
+	defer client.Close()
+
+	require.NoError(t, client.Ping(ctx))
+
+	_, ok, err := client.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, client.Set(ctx, "key", []byte("value\r\nwith separators"), 0))
+	value, ok, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "value\r\nwith separators", string(value))
+
+	require.NoError(t, client.Set(ctx, "short", []byte("value"), 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	_, ok, err = client.Get(ctx, "short")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	counter, err := client.Incr(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counter)
+	counters, err := client.Counters(ctx, "counter", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 0}, counters)
+
+	// Ответ-ошибка не закрывает соединение
+	_, err = client.Incr(ctx, "key")
+	var replyErr RedisError
+	assert.ErrorAs(t, err, &replyErr)
+	require.NoError(t, client.Ping(ctx))
+}
+
+func TestRedis_Unavailable(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+
+	client := NewRedis(config.CacheConfig{Address: server.addr(), Password: "wrong"})
+	assert.Error(t, client.Ping(context.Background()))
+
+	server.listener.Close()
+	client = NewRedis(config.CacheConfig{Address: server.addr()})
+	assert.Error(t, client.Ping(context.Background()))
+}
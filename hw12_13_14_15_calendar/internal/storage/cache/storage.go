@@ -0,0 +1,192 @@
+package cachestorage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Storage декоратор хранилища, кэширующий выборки событий за период. Изменения событий и удаление
+// календарей, сделанные через декоратор, инвалидируют затронутые выборки.
+type Storage struct {
+	storage.Storage
+	cache *eventCache
+	// pending копит области, измененные в транзакции, nil вне транзакции
+	pending *pendingScopes
+}
+
+// pendingScopes области, которые нужно инвалидировать после фиксации транзакции.
+type pendingScopes struct {
+	mu     sync.Mutex
+	scopes []string
+}
+
+func New(inner storage.Storage, backend Backend, ttl time.Duration, logger logger.Logger) *Storage {
+	return &Storage{
+		Storage: inner,
+		cache:   &eventCache{backend: backend, ttl: ttl, logger: logger},
+	}
+}
+
+func (s *Storage) Close() error {
+	err := s.Storage.Close()
+	if s.pending != nil {
+		return err
+	}
+	return errors.Join(err, s.cache.backend.Close())
+}
+
+func (s *Storage) EventRepository() storage.EventRepository {
+	return &EventRepo{EventRepository: s.Storage.EventRepository(), storage: s}
+}
+
+func (s *Storage) CalendarRepository() storage.CalendarRepository {
+	return &CalendarRepo{CalendarRepository: s.Storage.CalendarRepository(), storage: s}
+}
+
+// WithTx выполняет fn в транзакции исходного хранилища. Выборки, затронутые изменениями в транзакции,
+// инвалидируются после фиксации, чтобы параллельные чтения не закэшировали данные до фиксации под новым поколением.
+func (s *Storage) WithTx(ctx context.Context, fn func(tx storage.Storage) error) error {
+	if s.pending != nil {
+		return s.Storage.WithTx(ctx, func(tx storage.Storage) error {
+			return fn(&Storage{Storage: tx, cache: s.cache, pending: s.pending})
+		})
+	}
+
+	pending := &pendingScopes{}
+	err := s.Storage.WithTx(ctx, func(tx storage.Storage) error {
+		return fn(&Storage{Storage: tx, cache: s.cache, pending: pending})
+	})
+	if err != nil {
+		return err
+	}
+	s.cache.invalidate(ctx, pending.scopes...)
+	return nil
+}
+
+// invalidate инвалидирует области сразу или после фиксации транзакции.
+func (s *Storage) invalidate(ctx context.Context, scopes ...string) {
+	if s.pending == nil {
+		s.cache.invalidate(ctx, scopes...)
+		return
+	}
+
+	s.pending.mu.Lock()
+	defer s.pending.mu.Unlock()
+	for _, scope := range scopes {
+		if !slices.Contains(s.pending.scopes, scope) {
+			s.pending.scopes = append(s.pending.scopes, scope)
+		}
+	}
+}
+
+type EventRepo struct {
+	storage.EventRepository
+	storage *Storage
+}
+
+func (r *EventRepo) CreateEvent(ctx context.Context, event storage.Event) (uuid.UUID, error) {
+	id, err := r.EventRepository.CreateEvent(ctx, event)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	r.storage.invalidate(ctx, eventScopes(event.CalendarID)...)
+	return id, nil
+}
+
+func (r *EventRepo) UpdateEvent(ctx context.Context, id uuid.UUID, event storage.Event) error {
+	// Прежний календарь события читается в транзакции: вне ее чтение идет с реплики, которая может отставать
+	if r.storage.pending == nil {
+		return r.storage.WithTx(ctx, func(tx storage.Storage) error {
+			return tx.EventRepository().UpdateEvent(ctx, id, event)
+		})
+	}
+
+	// Событие могло перейти в другой календарь: инвалидируются выборки и прежнего, и нового календаря
+	calendarIDs := []uuid.UUID{event.CalendarID}
+	if stored, err := r.EventRepository.GetEvent(ctx, id); err == nil {
+		calendarIDs = append(calendarIDs, stored.CalendarID)
+	}
+
+	if err := r.EventRepository.UpdateEvent(ctx, id, event); err != nil {
+		return err
+	}
+	r.storage.invalidate(ctx, eventScopes(calendarIDs...)...)
+	return nil
+}
+
+func (r *EventRepo) DeleteEvent(ctx context.Context, id uuid.UUID) error {
+	if r.storage.pending == nil {
+		return r.storage.WithTx(ctx, func(tx storage.Storage) error {
+			return tx.EventRepository().DeleteEvent(ctx, id)
+		})
+	}
+
+	var calendarIDs []uuid.UUID
+	if stored, err := r.EventRepository.GetEvent(ctx, id); err == nil {
+		calendarIDs = append(calendarIDs, stored.CalendarID)
+	}
+
+	if err := r.EventRepository.DeleteEvent(ctx, id); err != nil {
+		return err
+	}
+	r.storage.invalidate(ctx, eventScopes(calendarIDs...)...)
+	return nil
+}
+
+// ListEvents читает выборку через кэш. В транзакции кэш не используется: выборка должна учитывать
+// еще не зафиксированные изменения.
+func (r *EventRepo) ListEvents(ctx context.Context, start, end time.Time) ([]storage.Event, error) {
+	if r.storage.pending != nil {
+		return r.EventRepository.ListEvents(ctx, start, end)
+	}
+	return r.storage.cache.get(
+		ctx,
+		func() (string, error) {
+			return r.storage.cache.listKey(ctx, start, end)
+		},
+		func() ([]storage.Event, error) {
+			return r.EventRepository.ListEvents(ctx, start, end)
+		},
+	)
+}
+
+func (r *EventRepo) ListEventsByCalendars(
+	ctx context.Context,
+	calendarIDs []uuid.UUID,
+	start,
+	end time.Time,
+) ([]storage.Event, error) {
+	if r.storage.pending != nil {
+		return r.EventRepository.ListEventsByCalendars(ctx, calendarIDs, start, end)
+	}
+	return r.storage.cache.get(
+		ctx,
+		func() (string, error) {
+			return r.storage.cache.calendarsKey(ctx, calendarIDs, start, end)
+		},
+		func() ([]storage.Event, error) {
+			return r.EventRepository.ListEventsByCalendars(ctx, calendarIDs, start, end)
+		},
+	)
+}
+
+type CalendarRepo struct {
+	storage.CalendarRepository
+	storage *Storage
+}
+
+// DeleteCalendar удаляет календарь вместе с его событиями, поэтому инвалидирует выборки календаря и всех событий.
+func (r *CalendarRepo) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	if err := r.CalendarRepository.DeleteCalendar(ctx, id); err != nil {
+		return err
+	}
+	r.storage.invalidate(ctx, eventScopes(id)...)
+	return nil
+}
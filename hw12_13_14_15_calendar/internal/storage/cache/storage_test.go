@@ -0,0 +1,226 @@
+package cachestorage
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStorage считает выборки событий, дошедшие до исходного хранилища.
+type countingStorage struct {
+	storage.Storage
+	lists atomic.Int64
+}
+
+func (s *countingStorage) EventRepository() storage.EventRepository {
+	return &countingEventRepo{EventRepository: s.Storage.EventRepository(), lists: &s.lists}
+}
+
+func (s *countingStorage) WithTx(ctx context.Context, fn func(tx storage.Storage) error) error {
+	return s.Storage.WithTx(ctx, func(tx storage.Storage) error {
+		return fn(&countingStorage{Storage: tx})
+	})
+}
+
+type countingEventRepo struct {
+	storage.EventRepository
+	lists *atomic.Int64
+}
+
+func (r *countingEventRepo) ListEvents(ctx context.Context, start, end time.Time) ([]storage.Event, error) {
+	r.lists.Add(1)
+	return r.EventRepository.ListEvents(ctx, start, end)
+}
+
+func (r *countingEventRepo) ListEventsByCalendars(
+	ctx context.Context,
+	calendarIDs []uuid.UUID,
+	start,
+	end time.Time,
+) ([]storage.Event, error) {
+	r.lists.Add(1)
+	return r.EventRepository.ListEventsByCalendars(ctx, calendarIDs, start, end)
+}
+
+func newTestLogger(t *testing.T) logger.Logger {
+	t.Helper()
+
+	logInstance, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+	return logInstance
+}
+
+// backends создает хранилища кэша для каждого поддерживаемого типа.
+var backends = []struct {
+	name       string
+	newBackend func(t *testing.T) Backend
+}{
+	{
+		"lru",
+		func(t *testing.T) Backend {
+			t.Helper()
+			backend, err := NewLRU(128)
+			require.NoError(t, err)
+			return backend
+		},
+	},
+	{
+		"redis",
+		func(t *testing.T) Backend {
+			t.Helper()
+			return NewRedis(config.CacheConfig{Address: newFakeRedis(t, "").addr()})
+		},
+	},
+}
+
+func TestConformance(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			storagetest.Run(t, func(t *testing.T) storage.Storage {
+				return New(memorystorage.New(), b.newBackend(t), time.Minute, newTestLogger(t))
+			})
+		})
+	}
+}
+
+func TestStorage_ReadThrough(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			inner := &countingStorage{Storage: memorystorage.New()}
+			store := New(inner, b.newBackend(t), time.Minute, newTestLogger(t))
+			defer store.Close()
+			repo := store.EventRepository()
+
+			start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+			end := start.Add(24 * time.Hour)
+			work, personal := uuid.New(), uuid.New()
+			event := storage.Event{
+				Title:      "Planning",
+				StartTime:  start.Add(10 * time.Hour),
+				EndTime:    start.Add(11 * time.Hour),
+				UserID:     uuid.New(),
+				CalendarID: work,
+			}
+			id, err := repo.CreateEvent(ctx, event)
+			require.NoError(t, err)
+
+			// Повторная выборка за тот же период читается из кэша
+			for range 2 {
+				events, err := repo.ListEvents(ctx, start, end)
+				require.NoError(t, err)
+				require.Len(t, events, 1)
+				assert.Equal(t, "Planning", events[0].Title)
+			}
+			assert.Equal(t, int64(1), inner.lists.Load())
+
+			// Порядок и повторы календарей не меняют ключ
+			_, err = repo.ListEventsByCalendars(ctx, []uuid.UUID{work, personal}, start, end)
+			require.NoError(t, err)
+			_, err = repo.ListEventsByCalendars(ctx, []uuid.UUID{personal, work, work}, start, end)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), inner.lists.Load())
+
+			// Новое событие без календаря не инвалидирует выборку календаря work
+			_, err = repo.CreateEvent(ctx, storage.Event{StartTime: event.StartTime, EndTime: event.EndTime})
+			require.NoError(t, err)
+			events, err := repo.ListEventsByCalendars(ctx, []uuid.UUID{work}, start, end)
+			require.NoError(t, err)
+			assert.Len(t, events, 1)
+			_, err = repo.ListEventsByCalendars(ctx, []uuid.UUID{work}, start, end)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), inner.lists.Load())
+
+			// Перенос события в другой календарь инвалидирует выборки обоих календарей
+			event.CalendarID = personal
+			require.NoError(t, repo.UpdateEvent(ctx, id, event))
+			events, err = repo.ListEventsByCalendars(ctx, []uuid.UUID{work}, start, end)
+			require.NoError(t, err)
+			assert.Empty(t, events)
+			events, err = repo.ListEventsByCalendars(ctx, []uuid.UUID{personal}, start, end)
+			require.NoError(t, err)
+			assert.Len(t, events, 1)
+
+			require.NoError(t, repo.DeleteEvent(ctx, id))
+			events, err = repo.ListEventsByCalendars(ctx, []uuid.UUID{personal}, start, end)
+			require.NoError(t, err)
+			assert.Empty(t, events)
+		})
+	}
+}
+
+func TestStorage_InvalidateAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	backend, err := NewLRU(128)
+	require.NoError(t, err)
+	store := New(memorystorage.New(), backend, time.Minute, newTestLogger(t))
+	defer store.Close()
+
+	calendarID, err := store.CalendarRepository().CreateCalendar(ctx, storage.Calendar{UserID: uuid.New()})
+	require.NoError(t, err)
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	event := storage.Event{StartTime: start.Add(time.Hour), EndTime: start.Add(2 * time.Hour), CalendarID: calendarID}
+
+	events, err := store.EventRepository().ListEvents(ctx, start, end)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	err = store.WithTx(ctx, func(tx storage.Storage) error {
+		if _, err := tx.EventRepository().CreateEvent(ctx, event); err != nil {
+			return err
+		}
+		// До фиксации поколения не меняются, а выборка в транзакции видит свои изменения
+		generations, err := backend.Counters(ctx, generationKey(allScope))
+		require.NoError(t, err)
+		assert.Equal(t, []int64{0}, generations)
+
+		events, err := tx.EventRepository().ListEvents(ctx, start, end)
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+		return nil
+	})
+	require.NoError(t, err)
+
+	events, err = store.EventRepository().ListEvents(ctx, start, end)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// Удаление календаря удаляет его события из закэшированных выборок
+	require.NoError(t, store.CalendarRepository().DeleteCalendar(ctx, calendarID))
+	events, err = store.EventRepository().ListEvents(ctx, start, end)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestStorage_BackendUnavailable(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeRedis(t, "")
+	server.listener.Close()
+	store := New(memorystorage.New(), NewRedis(config.CacheConfig{Address: server.addr()}), time.Minute, newTestLogger(t))
+	defer store.Close()
+
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	_, err := store.EventRepository().CreateEvent(ctx, storage.Event{StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	// Ошибки кэша не мешают чтению из хранилища
+	events, err := store.EventRepository().ListEvents(ctx, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
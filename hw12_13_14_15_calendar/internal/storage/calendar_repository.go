@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Calendar именованный календарь пользователя (например, "Работа" или "Личное").
+type Calendar struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	Name            string
+	Color           string
+	DefaultReminder time.Duration
+	Timezone        string
+}
+
+type CalendarRepository interface {
+	CreateCalendar(ctx context.Context, calendar Calendar) (uuid.UUID, error)
+	UpdateCalendar(ctx context.Context, id uuid.UUID, calendar Calendar) error
+	DeleteCalendar(ctx context.Context, id uuid.UUID) error
+	GetCalendar(ctx context.Context, id uuid.UUID) (Calendar, error)
+	ListCalendars(ctx context.Context, userID uuid.UUID) ([]Calendar, error)
+}
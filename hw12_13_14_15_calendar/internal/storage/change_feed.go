@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// EventChange изменение события, доставляемое подписчикам ленты изменений.
+type EventChange struct {
+	Type  ChangeType
+	Event Event
+}
+
+// ChangeFeed лента изменений событий пользователя.
+type ChangeFeed interface {
+	// Subscribe возвращает канал изменений событий пользователя. Канал закрывается при отмене ctx
+	// или если подписчик не успевает вычитывать изменения.
+	Subscribe(ctx context.Context, userID uuid.UUID) (<-chan EventChange, error)
+}
@@ -5,4 +5,15 @@ import "errors"
 var (
 	ErrEventNotFound        = errors.New("event not found")
 	ErrNotificationNotFound = errors.New("notification not found")
+	ErrCalendarNotFound     = errors.New("calendar not found")
+	// ErrIdempotencyRecordNotFound возвращается, если записи с ключом идемпотентности нет или она истекла.
+	ErrIdempotencyRecordNotFound = errors.New("idempotency record not found")
+	// ErrIdempotencyKeyExists возвращается при сохранении записи с ключом, который уже занят.
+	ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+	// ErrIdempotencyKeyReused возвращается, если ключ идемпотентности повторно использован с другим запросом.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used with a different request")
+	// ErrVersionConflict возвращается при обновлении записи, версия которой не совпала с ожидаемой.
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrInTransaction возвращается при вызове внутри транзакции операции, недоступной в ней.
+	ErrInTransaction = errors.New("operation is not allowed inside a transaction")
 )
@@ -14,12 +14,42 @@ type Event struct {
 	StartTime   time.Time
 	EndTime     time.Time
 	UserID      uuid.UUID
+	CalendarID  uuid.UUID
+	// Attendees адреса участников события
+	Attendees []string
+	// Version растет при каждом изменении события и задает порядок изменений для синхронизации.
+	// При обновлении ненулевая версия означает ожидаемую текущую версию события.
+	Version   int64
+	UpdatedAt time.Time
+	// Deleted отмечает tombstone удаленного события, который хранится для синхронизации клиентов
+	Deleted bool
+}
+
+// EventFilter условия поиска событий. Нулевые значения полей не ограничивают выборку.
+type EventFilter struct {
+	// Query слова, каждое из которых должно встречаться в названии или описании события
+	Query      string
+	UserID     uuid.UUID
+	CalendarID uuid.UUID
+	// Start и End задают период, с которым должно пересекаться событие
+	Start    time.Time
+	End      time.Time
+	Attendee string
+	Limit    int
 }
 
 type EventRepository interface {
+	// CreateEvent сохраняет событие с идентификатором event.ID, а если он не задан - с новым идентификатором.
 	CreateEvent(ctx context.Context, event Event) (uuid.UUID, error)
 	UpdateEvent(ctx context.Context, id uuid.UUID, event Event) error
 	DeleteEvent(ctx context.Context, id uuid.UUID) error
 	GetEvent(ctx context.Context, id uuid.UUID) (Event, error)
+	// ListEvents возвращает события, которые начинаются и заканчиваются в периоде [start, end].
 	ListEvents(ctx context.Context, start, end time.Time) ([]Event, error)
+	ListEventsByCalendars(ctx context.Context, calendarIDs []uuid.UUID, start, end time.Time) ([]Event, error)
+	// SyncEvents возвращает изменения событий пользователя (включая tombstone) с версией больше sinceVersion
+	// в порядке возрастания версии. При sinceVersion == 0 tombstone не возвращаются.
+	SyncEvents(ctx context.Context, userID uuid.UUID, sinceVersion int64, limit int) ([]Event, error)
+	// SearchEvents возвращает события, подходящие под фильтр, в порядке начала события.
+	SearchEvents(ctx context.Context, filter EventFilter) ([]Event, error)
 }
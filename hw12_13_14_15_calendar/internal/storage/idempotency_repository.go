@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey ключ идемпотентности вместе с пользователем и операцией, к которым он относится:
+// одинаковые ключи разных пользователей или разных операций не пересекаются.
+type IdempotencyKey struct {
+	UserID    uuid.UUID
+	Operation string
+	Key       string
+}
+
+// IdempotencyRecord результат запроса, выполненного с ключом идемпотентности. Повторный запрос с тем же
+// ключом получает сохраненный ответ вместо повторного выполнения.
+type IdempotencyRecord struct {
+	IdempotencyKey
+	// RequestHash отпечаток запроса, по которому повтор отличается от другого запроса с тем же ключом
+	RequestHash string
+	Response    []byte
+	ExpiresAt   time.Time
+}
+
+type IdempotencyRepository interface {
+	// CreateIdempotencyRecord сохраняет запись. Если действующая запись с тем же ключом уже есть,
+	// возвращает ErrIdempotencyKeyExists, истекшая запись заменяется.
+	CreateIdempotencyRecord(ctx context.Context, record IdempotencyRecord) error
+	// GetIdempotencyRecord возвращает действующую запись по ключу.
+	GetIdempotencyRecord(ctx context.Context, key IdempotencyKey) (IdempotencyRecord, error)
+	// DeleteExpiredIdempotencyRecords удаляет записи, истекшие к моменту now.
+	DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) error
+}
@@ -0,0 +1,121 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type CalendarRepo struct {
+	calendars        map[uuid.UUID]storage.Calendar
+	mu               sync.RWMutex
+	eventRepo        *EventRepo
+	notificationRepo *NotificationRepo
+	// journal принимает изменения до их применения, nil для хранилища без сохранения на диск
+	journal recorder
+}
+
+func (r *CalendarRepo) CreateCalendar(_ context.Context, calendar storage.Calendar) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calendar.ID = uuid.New()
+	if err := r.write(walRecord{Calendars: []storage.Calendar{calendar}}); err != nil {
+		return uuid.Nil, err
+	}
+	return calendar.ID, nil
+}
+
+func (r *CalendarRepo) UpdateCalendar(_ context.Context, id uuid.UUID, calendar storage.Calendar) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.calendars[id]; !exists {
+		return storage.ErrCalendarNotFound
+	}
+	calendar.ID = id
+	return r.write(walRecord{Calendars: []storage.Calendar{calendar}})
+}
+
+func (r *CalendarRepo) DeleteCalendar(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.calendars[id]; !exists {
+		return storage.ErrCalendarNotFound
+	}
+
+	// Как и в SQL хранилище, вместе с календарем удаляются его события и их уведомления.
+	// Все изменения попадают в журнал одной записью.
+	r.eventRepo.mu.Lock()
+	defer r.eventRepo.mu.Unlock()
+	r.notificationRepo.mu.Lock()
+	defer r.notificationRepo.mu.Unlock()
+
+	tombstones := r.eventRepo.tombstonesByCalendar(id)
+	eventIDs := make([]uuid.UUID, 0, len(tombstones))
+	for _, event := range tombstones {
+		eventIDs = append(eventIDs, event.ID)
+	}
+	rec := walRecord{
+		DeletedCalendars:     []uuid.UUID{id},
+		Events:               tombstones,
+		DeletedNotifications: r.notificationRepo.idsByEvents(eventIDs...),
+	}
+	if err := writeRecord(r.journal, rec); err != nil {
+		return err
+	}
+	r.apply(rec)
+	r.eventRepo.apply(rec)
+	r.notificationRepo.apply(rec)
+
+	for _, event := range tombstones {
+		r.eventRepo.broker.Publish(storage.EventChange{Type: storage.ChangeDeleted, Event: event})
+	}
+	return nil
+}
+
+func (r *CalendarRepo) GetCalendar(_ context.Context, id uuid.UUID) (storage.Calendar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	calendar, exists := r.calendars[id]
+	if !exists {
+		return storage.Calendar{}, storage.ErrCalendarNotFound
+	}
+	return calendar, nil
+}
+
+func (r *CalendarRepo) ListCalendars(_ context.Context, userID uuid.UUID) ([]storage.Calendar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var calendars []storage.Calendar
+	for _, calendar := range r.calendars {
+		if calendar.UserID == userID {
+			calendars = append(calendars, calendar)
+		}
+	}
+
+	sort.Slice(calendars, func(i, j int) bool {
+		return calendars[i].Name < calendars[j].Name
+	})
+	return calendars, nil
+}
+
+// write записывает изменения в журнал и применяет их. Вызывается под r.mu.
+func (r *CalendarRepo) write(rec walRecord) error {
+	if err := writeRecord(r.journal, rec); err != nil {
+		return err
+	}
+	r.apply(rec)
+	return nil
+}
+
+// apply применяет календари записи журнала. Вызывается под r.mu.
+func (r *CalendarRepo) apply(rec walRecord) {
+	for _, calendar := range rec.Calendars {
+		r.calendars[calendar.ID] = calendar
+	}
+	for _, id := range rec.DeletedCalendars {
+		delete(r.calendars, id)
+	}
+}
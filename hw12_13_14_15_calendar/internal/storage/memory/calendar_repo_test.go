@@ -0,0 +1,95 @@
+package memorystorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarRepo_CreateAndGetCalendar(t *testing.T) {
+	memStore := New()
+	repo := memStore.CalendarRepository()
+
+	calendar := storage.Calendar{
+		UserID:          uuid.New(),
+		Name:            "Work",
+		Color:           "#3366ff",
+		DefaultReminder: 15 * time.Minute,
+		Timezone:        "Europe/Moscow",
+	}
+
+	id, err := repo.CreateCalendar(context.Background(), calendar)
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, id)
+
+	stored, err := repo.GetCalendar(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "Work", stored.Name)
+	assert.Equal(t, 15*time.Minute, stored.DefaultReminder)
+}
+
+func TestCalendarRepo_UpdateCalendar(t *testing.T) {
+	memStore := New()
+	repo := memStore.CalendarRepository()
+
+	calendar := storage.Calendar{UserID: uuid.New(), Name: "Work", Timezone: "UTC"}
+	id, _ := repo.CreateCalendar(context.Background(), calendar)
+
+	calendar.Name = "Personal"
+	err := repo.UpdateCalendar(context.Background(), id, calendar)
+	assert.NoError(t, err)
+
+	stored, err := repo.GetCalendar(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Personal", stored.Name)
+
+	err = repo.UpdateCalendar(context.Background(), uuid.New(), calendar)
+	assert.ErrorIs(t, err, storage.ErrCalendarNotFound)
+}
+
+func TestCalendarRepo_DeleteCalendarRemovesEvents(t *testing.T) {
+	memStore := New()
+	calendarRepo := memStore.CalendarRepository()
+	eventRepo := memStore.EventRepository()
+
+	userID := uuid.New()
+	calendarID, _ := calendarRepo.CreateCalendar(
+		context.Background(),
+		storage.Calendar{UserID: userID, Name: "Work", Timezone: "UTC"},
+	)
+	eventID, _ := eventRepo.CreateEvent(context.Background(), storage.Event{
+		Title:      "Meeting",
+		StartTime:  time.Now(),
+		EndTime:    time.Now().Add(time.Hour),
+		UserID:     userID,
+		CalendarID: calendarID,
+	})
+
+	err := calendarRepo.DeleteCalendar(context.Background(), calendarID)
+	assert.NoError(t, err)
+
+	_, err = calendarRepo.GetCalendar(context.Background(), calendarID)
+	assert.ErrorIs(t, err, storage.ErrCalendarNotFound)
+
+	_, err = eventRepo.GetEvent(context.Background(), eventID)
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+}
+
+func TestCalendarRepo_ListCalendars(t *testing.T) {
+	memStore := New()
+	repo := memStore.CalendarRepository()
+
+	userID := uuid.New()
+	_, _ = repo.CreateCalendar(context.Background(), storage.Calendar{UserID: userID, Name: "Work"})
+	_, _ = repo.CreateCalendar(context.Background(), storage.Calendar{UserID: userID, Name: "Personal"})
+	_, _ = repo.CreateCalendar(context.Background(), storage.Calendar{UserID: uuid.New(), Name: "Other"})
+
+	calendars, err := repo.ListCalendars(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Len(t, calendars, 2)
+}
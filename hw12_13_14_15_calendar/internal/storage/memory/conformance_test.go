@@ -0,0 +1,25 @@
+package memorystorage
+
+import (
+	"testing"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(*testing.T) storage.Storage {
+		return New()
+	})
+}
+
+func TestConformance_Persistent(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		store := openTestStorage(t, t.TempDir())
+		t.Cleanup(func() {
+			assert.NoError(t, store.Close())
+		})
+		return store
+	})
+}
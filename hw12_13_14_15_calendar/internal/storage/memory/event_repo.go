@@ -2,6 +2,8 @@ package memorystorage
 
 import (
 	"context"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,42 +14,60 @@ import (
 type EventRepo struct {
 	events map[uuid.UUID]storage.Event
 	mu     sync.RWMutex
+	broker changePublisher
+	// version последняя выданная версия, общая для всех событий
+	version int64
+	index   *searchIndex
+	// journal принимает изменения до их применения, nil для хранилища без сохранения на диск
+	journal recorder
 }
 
 func (r *EventRepo) CreateEvent(_ context.Context, event storage.Event) (uuid.UUID, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	event.ID = uuid.New()
-	r.events[event.ID] = event
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if err := r.save(&event); err != nil {
+		return uuid.Nil, err
+	}
+	r.broker.Publish(storage.EventChange{Type: storage.ChangeCreated, Event: event})
 	return event.ID, nil
 }
 
 func (r *EventRepo) UpdateEvent(_ context.Context, id uuid.UUID, event storage.Event) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, exists := r.events[id]; !exists {
+	stored, exists := r.events[id]
+	if !exists || stored.Deleted {
 		return storage.ErrEventNotFound
 	}
+	if event.Version != 0 && event.Version != stored.Version {
+		return storage.ErrVersionConflict
+	}
 	event.ID = id
-	r.events[id] = event
+	if err := r.save(&event); err != nil {
+		return err
+	}
+	r.broker.Publish(storage.EventChange{Type: storage.ChangeUpdated, Event: event})
 	return nil
 }
 
 func (r *EventRepo) DeleteEvent(_ context.Context, id uuid.UUID) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, exists := r.events[id]; !exists {
+	event, exists := r.events[id]
+	if !exists || event.Deleted {
 		return storage.ErrEventNotFound
 	}
-	delete(r.events, id)
-	return nil
+	return r.markDeleted(event)
 }
 
 func (r *EventRepo) GetEvent(_ context.Context, id uuid.UUID) (storage.Event, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	event, exists := r.events[id]
-	if !exists {
+	if !exists || event.Deleted {
 		return storage.Event{}, storage.ErrEventNotFound
 	}
 	return event, nil
@@ -58,9 +78,174 @@ func (r *EventRepo) ListEvents(_ context.Context, start, end time.Time) ([]stora
 	defer r.mu.RUnlock()
 	var events []storage.Event
 	for _, event := range r.events {
-		if event.StartTime.After(start) && event.EndTime.Before(end) {
+		if event.Deleted {
+			continue
+		}
+		if inPeriod(event, start, end) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *EventRepo) ListEventsByCalendars(
+	_ context.Context,
+	calendarIDs []uuid.UUID,
+	start,
+	end time.Time,
+) ([]storage.Event, error) {
+	selected := make(map[uuid.UUID]struct{}, len(calendarIDs))
+	for _, id := range calendarIDs {
+		selected[id] = struct{}{}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var events []storage.Event
+	for _, event := range r.events {
+		if _, ok := selected[event.CalendarID]; !ok || event.Deleted {
+			continue
+		}
+		if inPeriod(event, start, end) {
 			events = append(events, event)
 		}
 	}
 	return events, nil
 }
+
+func (r *EventRepo) SyncEvents(
+	_ context.Context,
+	userID uuid.UUID,
+	sinceVersion int64,
+	limit int,
+) ([]storage.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var events []storage.Event
+	for _, event := range r.events {
+		if event.UserID != userID || event.Version <= sinceVersion {
+			continue
+		}
+		if sinceVersion == 0 && event.Deleted {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Version < events[j].Version
+	})
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *EventRepo) SearchEvents(_ context.Context, filter storage.EventFilter) ([]storage.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched map[uuid.UUID]struct{}
+	if len(tokenize(filter.Query)) > 0 {
+		matched = r.index.match(filter.Query)
+	}
+
+	var events []storage.Event
+	for _, event := range r.events {
+		if matched != nil {
+			if _, ok := matched[event.ID]; !ok {
+				continue
+			}
+		}
+		if event.Deleted || !matchFilter(event, filter) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].StartTime.Equal(events[j].StartTime) {
+			return events[i].StartTime.Before(events[j].StartTime)
+		}
+		return events[i].ID.String() < events[j].ID.String()
+	})
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+// inPeriod проверяет, что событие начинается и заканчивается в периоде [start, end].
+func inPeriod(event storage.Event, start, end time.Time) bool {
+	return !event.StartTime.Before(start) && !event.EndTime.After(end)
+}
+
+// matchFilter проверяет условия фильтра, кроме текстового запроса.
+func matchFilter(event storage.Event, filter storage.EventFilter) bool {
+	switch {
+	case filter.UserID != uuid.Nil && event.UserID != filter.UserID,
+		filter.CalendarID != uuid.Nil && event.CalendarID != filter.CalendarID,
+		!filter.Start.IsZero() && !event.EndTime.After(filter.Start),
+		!filter.End.IsZero() && !event.StartTime.Before(filter.End),
+		filter.Attendee != "" && !slices.Contains(event.Attendees, filter.Attendee):
+		return false
+	default:
+		return true
+	}
+}
+
+// tombstonesByCalendar возвращает tombstone живых событий календаря с новыми версиями, не сохраняя их.
+// Вызывается под r.mu.
+func (r *EventRepo) tombstonesByCalendar(calendarID uuid.UUID) []storage.Event {
+	var tombstones []storage.Event
+	version := r.version
+	for _, event := range r.events {
+		if event.CalendarID != calendarID || event.Deleted {
+			continue
+		}
+		version++
+		event.Deleted = true
+		event.Version = version
+		event.UpdatedAt = time.Now().UTC()
+		tombstones = append(tombstones, event)
+	}
+	return tombstones
+}
+
+// save присваивает событию новую версию, записывает его в журнал и сохраняет. Вызывается под r.mu.
+func (r *EventRepo) save(event *storage.Event) error {
+	event.Version = r.version + 1
+	event.UpdatedAt = time.Now().UTC()
+	event.Attendees = slices.Clone(event.Attendees)
+
+	rec := walRecord{Events: []storage.Event{*event}}
+	if err := writeRecord(r.journal, rec); err != nil {
+		return err
+	}
+	r.apply(rec)
+	return nil
+}
+
+// apply применяет события записи журнала. Вызывается под r.mu.
+func (r *EventRepo) apply(rec walRecord) {
+	for _, event := range rec.Events {
+		r.events[event.ID] = event
+		r.version = max(r.version, event.Version)
+
+		if event.Deleted {
+			r.index.remove(event.ID)
+		} else {
+			r.index.add(event.ID, event.Title, event.Description)
+		}
+	}
+}
+
+// markDeleted заменяет событие на tombstone. Вызывается под r.mu.
+func (r *EventRepo) markDeleted(event storage.Event) error {
+	event.Deleted = true
+	if err := r.save(&event); err != nil {
+		return err
+	}
+	r.broker.Publish(storage.EventChange{Type: storage.ChangeDeleted, Event: event})
+	return nil
+}
@@ -99,3 +99,207 @@ func TestEventRepo_ListEvents(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, events, 2)
 }
+
+func TestEventRepo_ListEventsByCalendars(t *testing.T) {
+	memStore := New()
+	repo := memStore.EventRepository()
+
+	work := uuid.New()
+	personal := uuid.New()
+	other := uuid.New()
+
+	for _, calendarID := range []uuid.UUID{work, personal, other} {
+		_, _ = repo.CreateEvent(context.Background(), storage.Event{
+			Title:      "Event",
+			StartTime:  time.Now(),
+			EndTime:    time.Now().Add(1 * time.Hour),
+			UserID:     uuid.New(),
+			CalendarID: calendarID,
+		})
+	}
+
+	events, err := repo.ListEventsByCalendars(
+		context.Background(),
+		[]uuid.UUID{work, personal},
+		time.Now().Add(-time.Minute),
+		time.Now().Add(4*time.Hour),
+	)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestEventRepo_PublishChanges(t *testing.T) {
+	memStore := New()
+	repo := memStore.EventRepository()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	userID := uuid.New()
+	changes, err := memStore.ChangeFeed().Subscribe(ctx, userID)
+	assert.NoError(t, err)
+
+	event := storage.Event{
+		Title:     "Test Event",
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(1 * time.Hour),
+		UserID:    userID,
+	}
+	id, _ := repo.CreateEvent(context.Background(), event)
+	_ = repo.UpdateEvent(context.Background(), id, event)
+	_ = repo.DeleteEvent(context.Background(), id)
+
+	for _, expected := range []storage.ChangeType{storage.ChangeCreated, storage.ChangeUpdated, storage.ChangeDeleted} {
+		change := <-changes
+		assert.Equal(t, expected, change.Type)
+		assert.Equal(t, id, change.Event.ID)
+	}
+}
+
+func TestEventRepo_SyncEvents(t *testing.T) {
+	memStore := New()
+	repo := memStore.EventRepository()
+	ctx := context.Background()
+
+	userID := uuid.New()
+	event := storage.Event{
+		Title:     "Event",
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(1 * time.Hour),
+		UserID:    userID,
+	}
+	kept, _ := repo.CreateEvent(ctx, event)
+	deleted, _ := repo.CreateEvent(ctx, event)
+	_, _ = repo.CreateEvent(ctx, storage.Event{UserID: uuid.New()})
+
+	initial, err := repo.SyncEvents(ctx, userID, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, initial, 2)
+	assert.Less(t, initial[0].Version, initial[1].Version)
+	since := initial[1].Version
+
+	assert.NoError(t, repo.DeleteEvent(ctx, deleted))
+	assert.NoError(t, repo.UpdateEvent(ctx, kept, event))
+
+	changes, err := repo.SyncEvents(ctx, userID, since, 0)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, deleted, changes[0].ID)
+	assert.True(t, changes[0].Deleted)
+	assert.Equal(t, kept, changes[1].ID)
+	assert.False(t, changes[1].Deleted)
+
+	// Tombstone не участвует в полной синхронизации и не доступен через обычные методы
+	full, err := repo.SyncEvents(ctx, userID, 0, 1)
+	assert.NoError(t, err)
+	assert.Len(t, full, 1)
+	assert.Equal(t, kept, full[0].ID)
+
+	_, err = repo.GetEvent(ctx, deleted)
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+	assert.ErrorIs(t, repo.DeleteEvent(ctx, deleted), storage.ErrEventNotFound)
+}
+
+func TestEventRepo_UpdateEventVersion(t *testing.T) {
+	memStore := New()
+	repo := memStore.EventRepository()
+	ctx := context.Background()
+
+	event := storage.Event{
+		Title:     "Test Event",
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(1 * time.Hour),
+		UserID:    uuid.New(),
+	}
+	id, _ := repo.CreateEvent(ctx, event)
+	created, _ := repo.GetEvent(ctx, id)
+
+	event.Title = "First"
+	event.Version = created.Version
+	assert.NoError(t, repo.UpdateEvent(ctx, id, event))
+
+	// Повторное обновление со старой версией не должно перезаписать изменения
+	event.Title = "Second"
+	assert.ErrorIs(t, repo.UpdateEvent(ctx, id, event), storage.ErrVersionConflict)
+
+	stored, _ := repo.GetEvent(ctx, id)
+	assert.Equal(t, "First", stored.Title)
+	assert.Greater(t, stored.Version, created.Version)
+
+	assert.ErrorIs(t, repo.UpdateEvent(ctx, uuid.New(), event), storage.ErrEventNotFound)
+}
+
+func TestEventRepo_SearchEvents(t *testing.T) {
+	memStore := New()
+	repo := memStore.EventRepository()
+	ctx := context.Background()
+
+	userID := uuid.New()
+	start := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	planning, _ := repo.CreateEvent(ctx, storage.Event{
+		Title:       "Sprint planning",
+		Description: "Обсуждение задач на спринт",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		UserID:      userID,
+		Attendees:   []string{"alice@example.com", "bob@example.com"},
+	})
+	review, _ := repo.CreateEvent(ctx, storage.Event{
+		Title:     "Sprint review",
+		StartTime: start.Add(24 * time.Hour),
+		EndTime:   start.Add(25 * time.Hour),
+		UserID:    userID,
+		Attendees: []string{"alice@example.com"},
+	})
+	_, _ = repo.CreateEvent(ctx, storage.Event{
+		Title:     "Sprint retro",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		UserID:    uuid.New(),
+	})
+
+	tests := []struct {
+		name     string
+		filter   storage.EventFilter
+		expected []uuid.UUID
+	}{
+		{"query", storage.EventFilter{Query: "SPRINT", UserID: userID}, []uuid.UUID{planning, review}},
+		{"all words", storage.EventFilter{Query: "sprint planning", UserID: userID}, []uuid.UUID{planning}},
+		{"description", storage.EventFilter{Query: "задач"}, []uuid.UUID{planning}},
+		{"attendee", storage.EventFilter{Attendee: "bob@example.com"}, []uuid.UUID{planning}},
+		{
+			"period",
+			storage.EventFilter{UserID: userID, Start: start.Add(2 * time.Hour), End: start.Add(48 * time.Hour)},
+			[]uuid.UUID{review},
+		},
+		{"limit", storage.EventFilter{UserID: userID, Limit: 1}, []uuid.UUID{planning}},
+		{"no match", storage.EventFilter{Query: "demo"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := repo.SearchEvents(ctx, tt.filter)
+			assert.NoError(t, err)
+
+			var ids []uuid.UUID
+			for _, event := range events {
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+
+	// После изменения названия событие ищется по новым словам и не находится по старым
+	renamed, _ := repo.GetEvent(ctx, review)
+	renamed.Title = "Demo"
+	assert.NoError(t, repo.UpdateEvent(ctx, review, renamed))
+
+	events, err := repo.SearchEvents(ctx, storage.EventFilter{Query: "demo"})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	assert.NoError(t, repo.DeleteEvent(ctx, review))
+	events, err = repo.SearchEvents(ctx, storage.EventFilter{Query: "demo"})
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,103 @@
+package memorystorage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// idempotencyPurgeInterval период, не чаще которого создание записи удаляет истекшие записи.
+const idempotencyPurgeInterval = time.Minute
+
+type IdempotencyRepo struct {
+	records map[storage.IdempotencyKey]storage.IdempotencyRecord
+	mu      sync.RWMutex
+	// journal принимает изменения до их применения, nil для хранилища без сохранения на диск
+	journal recorder
+	// purgedAt время последнего удаления истекших записей, общее с репозиториями транзакций
+	purgedAt *time.Time
+}
+
+// CreateIdempotencyRecord сохраняет запись и заодно удаляет истекшие записи. Планировщик удаляет их
+// в собственном хранилище, поэтому без этого записи хранилища в памяти копились бы вместе с журналом
+// и снимками.
+func (r *IdempotencyRepo) CreateIdempotencyRecord(_ context.Context, record storage.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if stored, exists := r.records[record.IdempotencyKey]; exists && stored.ExpiresAt.After(now) {
+		return storage.ErrIdempotencyKeyExists
+	}
+
+	rec := walRecord{IdempotencyRecords: []storage.IdempotencyRecord{record}}
+	purge := now.Sub(*r.purgedAt) >= idempotencyPurgeInterval
+	if purge {
+		// Истекшая запись с ключом новой заменяется ею, а не удаляется: удаления применяются после записей
+		for _, key := range r.expired(now) {
+			if key != record.IdempotencyKey {
+				rec.DeletedIdempotencyKeys = append(rec.DeletedIdempotencyKeys, key)
+			}
+		}
+	}
+	if err := r.write(rec); err != nil {
+		return err
+	}
+	if purge {
+		*r.purgedAt = now
+	}
+	return nil
+}
+
+func (r *IdempotencyRepo) GetIdempotencyRecord(
+	_ context.Context,
+	key storage.IdempotencyKey,
+) (storage.IdempotencyRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	record, exists := r.records[key]
+	if !exists || !record.ExpiresAt.After(time.Now()) {
+		return storage.IdempotencyRecord{}, storage.ErrIdempotencyRecordNotFound
+	}
+	return record, nil
+}
+
+func (r *IdempotencyRepo) DeleteExpiredIdempotencyRecords(_ context.Context, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.write(walRecord{DeletedIdempotencyKeys: r.expired(now)})
+}
+
+// expired возвращает ключи записей, истекших к моменту now. Вызывается под r.mu.
+func (r *IdempotencyRepo) expired(now time.Time) []storage.IdempotencyKey {
+	var keys []storage.IdempotencyKey
+	for key, record := range r.records {
+		if !record.ExpiresAt.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// write записывает изменения в журнал и применяет их. Вызывается под r.mu.
+func (r *IdempotencyRepo) write(rec walRecord) error {
+	if rec.empty() {
+		return nil
+	}
+	if err := writeRecord(r.journal, rec); err != nil {
+		return err
+	}
+	r.apply(rec)
+	return nil
+}
+
+// apply применяет записи идемпотентности записи журнала. Вызывается под r.mu.
+func (r *IdempotencyRepo) apply(rec walRecord) {
+	for _, record := range rec.IdempotencyRecords {
+		r.records[record.IdempotencyKey] = record
+	}
+	for _, key := range rec.DeletedIdempotencyKeys {
+		delete(r.records, key)
+	}
+}
@@ -0,0 +1,50 @@
+package memorystorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyRepo_PurgeExpiredOnCreate(t *testing.T) {
+	ctx := context.Background()
+	memStore := New()
+	repo := memStore.idempotencyRepo
+	newRecord := func(expiresAt time.Time) storage.IdempotencyRecord {
+		return storage.IdempotencyRecord{
+			IdempotencyKey: storage.IdempotencyKey{UserID: uuid.New(), Operation: "CreateEvent", Key: uuid.NewString()},
+			RequestHash:    "hash",
+			Response:       []byte(`{}`),
+			ExpiresAt:      expiresAt,
+		}
+	}
+
+	expired := newRecord(time.Now().Add(-time.Minute))
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, expired))
+
+	// Истекшие записи удаляются не чаще раза в idempotencyPurgeInterval
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, newRecord(time.Now().Add(time.Hour))))
+	assert.Contains(t, repo.records, expired.IdempotencyKey)
+
+	*repo.purgedAt = time.Now().Add(-idempotencyPurgeInterval)
+	active := newRecord(time.Now().Add(time.Hour))
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, active))
+	assert.NotContains(t, repo.records, expired.IdempotencyKey)
+	assert.Contains(t, repo.records, active.IdempotencyKey)
+	assert.Len(t, repo.records, 2)
+
+	// Истекшая запись с ключом новой заменяется, а не удаляется
+	*repo.purgedAt = time.Time{}
+	replaced := newRecord(time.Now().Add(-time.Minute))
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, replaced))
+	*repo.purgedAt = time.Time{}
+	replaced.ExpiresAt = time.Now().Add(time.Hour)
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, replaced))
+	_, err := repo.GetIdempotencyRecord(ctx, replaced.IdempotencyKey)
+	assert.NoError(t, err)
+}
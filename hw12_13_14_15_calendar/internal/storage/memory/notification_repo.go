@@ -2,6 +2,7 @@ package memorystorage
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"time"
 
@@ -13,13 +14,18 @@ import (
 type NotificationRepo struct {
 	notifications map[uuid.UUID]storage.Notification
 	mu            sync.RWMutex
+	// journal принимает изменения до их применения, nil для хранилища без сохранения на диск
+	journal recorder
 }
 
 func (r *NotificationRepo) CreateNotification(_ context.Context, notification storage.Notification) (uuid.UUID, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	notification.ID = uuid.New()
-	r.notifications[notification.ID] = notification
+	notification.Version = 1
+	if err := r.write(walRecord{Notifications: []storage.Notification{notification}}); err != nil {
+		return uuid.Nil, err
+	}
 	return notification.ID, nil
 }
 
@@ -30,12 +36,16 @@ func (r *NotificationRepo) UpdateNotification(
 ) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, exists := r.notifications[id]; !exists {
+	stored, exists := r.notifications[id]
+	if !exists {
 		return storage.ErrNotificationNotFound
 	}
+	if notification.Version != 0 && notification.Version != stored.Version {
+		return storage.ErrVersionConflict
+	}
 	notification.ID = id
-	r.notifications[id] = notification
-	return nil
+	notification.Version = stored.Version + 1
+	return r.write(walRecord{Notifications: []storage.Notification{notification}})
 }
 
 func (r *NotificationRepo) DeleteNotification(_ context.Context, id uuid.UUID) error {
@@ -44,8 +54,7 @@ func (r *NotificationRepo) DeleteNotification(_ context.Context, id uuid.UUID) e
 	if _, exists := r.notifications[id]; !exists {
 		return storage.ErrNotificationNotFound
 	}
-	delete(r.notifications, id)
-	return nil
+	return r.write(walRecord{DeletedNotifications: []uuid.UUID{id}})
 }
 
 func (r *NotificationRepo) GetNotification(_ context.Context, id uuid.UUID) (storage.Notification, error) {
@@ -63,7 +72,10 @@ func (r *NotificationRepo) ListNotifications(_ context.Context, start, end time.
 	defer r.mu.RUnlock()
 	var notifications []storage.Notification
 	for _, notification := range r.notifications {
-		if notification.Time.After(start) && notification.Time.Before(end) {
+		if notification.Sent != dto.NotificationOnWait {
+			continue
+		}
+		if !notification.Time.Before(start) && !notification.Time.After(end) {
 			notifications = append(notifications, notification)
 		}
 	}
@@ -74,10 +86,55 @@ func (r *NotificationRepo) DeleteSentNotifications(_ context.Context) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	var rec walRecord
 	for id, notification := range r.notifications {
 		if notification.Sent == dto.NotificationSent {
-			delete(r.notifications, id)
+			rec.DeletedNotifications = append(rec.DeletedNotifications, id)
+		}
+	}
+	return r.write(rec)
+}
+
+func (r *NotificationRepo) DeleteEventNotifications(_ context.Context, eventID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.write(walRecord{DeletedNotifications: r.idsByEvents(eventID)})
+}
+
+// idsByEvents возвращает идентификаторы уведомлений перечисленных событий. Вызывается под r.mu.
+func (r *NotificationRepo) idsByEvents(eventIDs ...uuid.UUID) []uuid.UUID {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	var ids []uuid.UUID
+	for id, notification := range r.notifications {
+		if slices.Contains(eventIDs, notification.EventID) {
+			ids = append(ids, id)
 		}
 	}
+	return ids
+}
+
+// write записывает изменения в журнал и применяет их. Пустая запись в журнал не попадает.
+// Вызывается под r.mu.
+func (r *NotificationRepo) write(rec walRecord) error {
+	if rec.empty() {
+		return nil
+	}
+	if err := writeRecord(r.journal, rec); err != nil {
+		return err
+	}
+	r.apply(rec)
 	return nil
 }
+
+// apply применяет уведомления записи журнала. Вызывается под r.mu.
+func (r *NotificationRepo) apply(rec walRecord) {
+	for _, notification := range rec.Notifications {
+		r.notifications[notification.ID] = notification
+	}
+	for _, id := range rec.DeletedNotifications {
+		delete(r.notifications, id)
+	}
+}
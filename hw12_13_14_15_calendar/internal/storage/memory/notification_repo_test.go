@@ -95,3 +95,30 @@ func TestNotificationRepo_ListNotifications(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, notifications, 2)
 }
+
+func TestNotificationRepo_UpdateNotificationVersion(t *testing.T) {
+	memStore := New()
+	repo := memStore.NotificationRepository()
+	ctx := context.Background()
+
+	notification := storage.Notification{
+		EventID: uuid.New(),
+		Time:    time.Now(),
+		Message: "Test Notification",
+		Sent:    dto.NotificationOnWait,
+	}
+	id, _ := repo.CreateNotification(ctx, notification)
+	created, _ := repo.GetNotification(ctx, id)
+	assert.Equal(t, int64(1), created.Version)
+
+	notification.Version = created.Version
+	assert.NoError(t, repo.UpdateNotification(ctx, id, notification))
+	assert.ErrorIs(t, repo.UpdateNotification(ctx, id, notification), storage.ErrVersionConflict)
+
+	// Нулевая версия обновляет запись без проверки
+	notification.Version = 0
+	assert.NoError(t, repo.UpdateNotification(ctx, id, notification))
+
+	stored, _ := repo.GetNotification(ctx, id)
+	assert.Equal(t, int64(3), stored.Version)
+}
@@ -0,0 +1,179 @@
+package memorystorage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	journalFile  = "wal.log"
+	snapshotFile = "snapshot.json"
+)
+
+// persistence состояние сохранения хранилища на диск: журнал упреждающей записи и периодические снимки.
+// Снимок содержит полное состояние, журнал - изменения после последнего снимка.
+type persistence struct {
+	dir       string
+	journal   *journal
+	logger    logger.Logger
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// Open создает хранилище, сохраняющее изменения в каталог cfg.Dir, и восстанавливает в нем
+// состояние из последнего снимка и журнала.
+func Open(cfg config.MemoryStorageConfig, logger logger.Logger) (*MemoryStorage, error) {
+	fsync := cfg.Fsync
+	if fsync == "" {
+		fsync = FsyncAlways
+	}
+	switch fsync {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+	default:
+		return nil, fmt.Errorf("unknown fsync policy: %s", cfg.Fsync)
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("on creating storage directory: %w", err)
+	}
+
+	s := New()
+	data, err := readSnapshot(filepath.Join(cfg.Dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+	s.apply(walRecord{
+		Calendars:          data.Calendars,
+		Events:             data.Events,
+		Notifications:      data.Notifications,
+		IdempotencyRecords: data.IdempotencyRecords,
+	})
+
+	replayed, truncated, err := replayJournal(filepath.Join(cfg.Dir, journalFile), s.apply)
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		logger.Infof("memory storage journal ended with an incomplete record, truncated")
+	}
+	logger.Infof("memory storage restored from %s, journal records replayed: %d", cfg.Dir, replayed)
+
+	j, err := openJournal(filepath.Join(cfg.Dir, journalFile), fsync)
+	if err != nil {
+		return nil, err
+	}
+	s.persistence = &persistence{
+		dir:     cfg.Dir,
+		journal: j,
+		logger:  logger,
+		stop:    make(chan struct{}),
+	}
+	s.eventRepo.journal = j
+	s.notificationRepo.journal = j
+	s.calendarRepo.journal = j
+	s.idempotencyRepo.journal = j
+
+	if fsync == FsyncInterval && cfg.FsyncInterval > 0 {
+		s.persistence.every(time.Duration(cfg.FsyncInterval)*time.Second, j.sync)
+	}
+	if cfg.SnapshotInterval > 0 {
+		s.persistence.every(time.Duration(cfg.SnapshotInterval)*time.Second, s.Snapshot)
+	}
+	return s, nil
+}
+
+// Snapshot сохраняет полное состояние хранилища в снимок и очищает журнал.
+// Для хранилища без сохранения на диск ничего не делает.
+func (s *MemoryStorage) Snapshot() error {
+	if s.persistence == nil {
+		return nil
+	}
+
+	// Порядок блокировок совпадает с WithTx. Пока пишется снимок, изменения ждут, поэтому из журнала
+	// удаляются ровно те записи, которые вошли в снимок.
+	s.calendarRepo.mu.RLock()
+	defer s.calendarRepo.mu.RUnlock()
+	s.eventRepo.mu.RLock()
+	defer s.eventRepo.mu.RUnlock()
+	s.notificationRepo.mu.RLock()
+	defer s.notificationRepo.mu.RUnlock()
+	s.idempotencyRepo.mu.RLock()
+	defer s.idempotencyRepo.mu.RUnlock()
+
+	data := snapshotData{
+		Calendars:          make([]storage.Calendar, 0, len(s.calendarRepo.calendars)),
+		Events:             make([]storage.Event, 0, len(s.eventRepo.events)),
+		Notifications:      make([]storage.Notification, 0, len(s.notificationRepo.notifications)),
+		IdempotencyRecords: make([]storage.IdempotencyRecord, 0, len(s.idempotencyRepo.records)),
+	}
+	for _, calendar := range s.calendarRepo.calendars {
+		data.Calendars = append(data.Calendars, calendar)
+	}
+	for _, event := range s.eventRepo.events {
+		data.Events = append(data.Events, event)
+	}
+	for _, notification := range s.notificationRepo.notifications {
+		data.Notifications = append(data.Notifications, notification)
+	}
+	for _, record := range s.idempotencyRepo.records {
+		data.IdempotencyRecords = append(data.IdempotencyRecords, record)
+	}
+
+	if err := writeSnapshot(filepath.Join(s.persistence.dir, snapshotFile), data); err != nil {
+		return err
+	}
+	// Сбой до очистки журнала безопасен: повторное применение его записей к снимку ничего не меняет
+	return s.persistence.journal.reset()
+}
+
+// apply применяет запись журнала ко всем репозиториям. Используется при восстановлении, до начала работы.
+func (s *MemoryStorage) apply(rec walRecord) {
+	s.calendarRepo.apply(rec)
+	s.eventRepo.apply(rec)
+	s.notificationRepo.apply(rec)
+	s.idempotencyRepo.apply(rec)
+}
+
+// closePersistence останавливает фоновые задачи, сохраняет снимок и закрывает журнал.
+func (s *MemoryStorage) closePersistence() (err error) {
+	s.persistence.closeOnce.Do(func() {
+		close(s.persistence.stop)
+		s.persistence.wg.Wait()
+
+		if err = s.Snapshot(); err != nil {
+			err = fmt.Errorf("on saving snapshot: %w", err)
+		}
+		if closeErr := s.persistence.journal.close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("on closing journal: %w", closeErr)
+		}
+	})
+	return err
+}
+
+// every выполняет task с интервалом до закрытия хранилища.
+func (p *persistence) every(interval time.Duration, task func() error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				if err := task(); err != nil {
+					p.logger.Errorf("memory storage background task failed: %s", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,245 @@
+package memorystorage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestStorage(t *testing.T, dir string) *MemoryStorage {
+	t.Helper()
+
+	logInstance, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+
+	store, err := Open(config.MemoryStorageConfig{Dir: dir, Fsync: FsyncAlways}, logInstance)
+	require.NoError(t, err)
+	return store
+}
+
+func journalLines(t *testing.T, dir string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, journalFile))
+	require.NoError(t, err)
+	return bytes.Count(data, []byte("\n"))
+}
+
+func TestPersistence_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := openTestStorage(t, dir)
+
+	userID := uuid.New()
+	calendarID, err := store.CalendarRepository().CreateCalendar(ctx, storage.Calendar{UserID: userID, Name: "Work"})
+	require.NoError(t, err)
+	start := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	kept, err := store.EventRepository().CreateEvent(ctx, storage.Event{
+		Title:      "Sprint planning",
+		StartTime:  start,
+		EndTime:    start.Add(time.Hour),
+		UserID:     userID,
+		CalendarID: calendarID,
+	})
+	require.NoError(t, err)
+	deleted, err := store.EventRepository().CreateEvent(ctx, storage.Event{Title: "Deleted", UserID: userID})
+	require.NoError(t, err)
+	require.NoError(t, store.EventRepository().DeleteEvent(ctx, deleted))
+	notificationID, err := store.NotificationRepository().CreateNotification(ctx, storage.Notification{
+		EventID: kept,
+		Time:    start,
+		Sent:    dto.NotificationOnWait,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Close())
+
+	reopened := openTestStorage(t, dir)
+	defer reopened.Close()
+
+	event, err := reopened.EventRepository().GetEvent(ctx, kept)
+	require.NoError(t, err)
+	assert.Equal(t, "Sprint planning", event.Title)
+	assert.True(t, start.Equal(event.StartTime))
+	_, err = reopened.EventRepository().GetEvent(ctx, deleted)
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+	_, err = reopened.CalendarRepository().GetCalendar(ctx, calendarID)
+	assert.NoError(t, err)
+	_, err = reopened.NotificationRepository().GetNotification(ctx, notificationID)
+	assert.NoError(t, err)
+
+	// Поиск и синхронизация работают по восстановленным данным, версии продолжают расти
+	found, err := reopened.EventRepository().SearchEvents(ctx, storage.EventFilter{Query: "planning"})
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	synced, err := reopened.EventRepository().SyncEvents(ctx, userID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, synced, 1)
+	require.NoError(t, reopened.EventRepository().UpdateEvent(ctx, kept, event))
+	updated, err := reopened.EventRepository().GetEvent(ctx, kept)
+	require.NoError(t, err)
+	assert.Greater(t, updated.Version, event.Version)
+}
+
+func TestPersistence_RecoverWithoutClose(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := openTestStorage(t, dir)
+
+	calendarID, err := store.CalendarRepository().CreateCalendar(ctx, storage.Calendar{UserID: uuid.New()})
+	require.NoError(t, err)
+	eventID, err := store.EventRepository().CreateEvent(ctx, storage.Event{CalendarID: calendarID})
+	require.NoError(t, err)
+	_, err = store.NotificationRepository().CreateNotification(ctx, storage.Notification{EventID: eventID})
+	require.NoError(t, err)
+	require.NoError(t, store.CalendarRepository().DeleteCalendar(ctx, calendarID))
+
+	// Хранилище не закрыто, как после падения процесса: состояние восстанавливается только из журнала
+	recovered := openTestStorage(t, dir)
+	defer recovered.Close()
+
+	_, err = recovered.CalendarRepository().GetCalendar(ctx, calendarID)
+	assert.ErrorIs(t, err, storage.ErrCalendarNotFound)
+	_, err = recovered.EventRepository().GetEvent(ctx, eventID)
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+	assert.Empty(t, recovered.notificationRepo.notifications)
+}
+
+func TestPersistence_TruncatedJournal(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := openTestStorage(t, dir)
+
+	id, err := store.EventRepository().CreateEvent(ctx, storage.Event{Title: "Saved"})
+	require.NoError(t, err)
+
+	// Запись, оборванная сбоем посреди записи
+	file, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"events":[{"ID":`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	recovered := openTestStorage(t, dir)
+	defer recovered.Close()
+
+	_, err = recovered.EventRepository().GetEvent(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, journalLines(t, dir))
+
+	_, err = recovered.EventRepository().CreateEvent(ctx, storage.Event{Title: "After recovery"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, journalLines(t, dir))
+}
+
+func TestPersistence_FailedJournalWrite(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := openTestStorage(t, dir)
+
+	id, err := store.EventRepository().CreateEvent(ctx, storage.Event{Title: "Saved"})
+	require.NoError(t, err)
+
+	// Запись оборвалась посреди строки, а отрезать ее не удалось: файл журнала открыт только на чтение
+	path := filepath.Join(dir, journalFile)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"events":[{"ID":`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	readOnly, err := os.Open(path)
+	require.NoError(t, err)
+	writable := store.persistence.journal.file
+	store.persistence.journal.file = readOnly
+
+	err = store.WithTx(ctx, func(tx storage.Storage) error {
+		_, err := tx.EventRepository().CreateEvent(ctx, storage.Event{Title: "Not saved"})
+		return err
+	})
+	require.Error(t, err)
+	found, err := store.EventRepository().SearchEvents(ctx, storage.EventFilter{Query: "saved"})
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	// Следующие записи не дописываются после оборванной строки, иначе журнал нельзя было бы воспроизвести
+	_, err = store.EventRepository().CreateEvent(ctx, storage.Event{Title: "Not saved either"})
+	require.Error(t, err)
+	require.NoError(t, readOnly.Close())
+	require.NoError(t, writable.Close())
+
+	recovered := openTestStorage(t, dir)
+	defer recovered.Close()
+	_, err = recovered.EventRepository().GetEvent(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, journalLines(t, dir))
+}
+
+func TestPersistence_Transaction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := openTestStorage(t, dir)
+	defer store.Close()
+
+	err := store.WithTx(ctx, func(tx storage.Storage) error {
+		eventID, err := tx.EventRepository().CreateEvent(ctx, storage.Event{Title: "Event"})
+		if err != nil {
+			return err
+		}
+		_, err = tx.NotificationRepository().CreateNotification(ctx, storage.Notification{EventID: eventID})
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, journalLines(t, dir))
+
+	errRollback := errors.New("rollback")
+	err = store.WithTx(ctx, func(tx storage.Storage) error {
+		if _, err := tx.EventRepository().CreateEvent(ctx, storage.Event{Title: "Rolled back"}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+	assert.Equal(t, 1, journalLines(t, dir))
+}
+
+func TestPersistence_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := openTestStorage(t, dir)
+
+	id, err := store.EventRepository().CreateEvent(ctx, storage.Event{Title: "Event"})
+	require.NoError(t, err)
+	require.Equal(t, 1, journalLines(t, dir))
+
+	require.NoError(t, store.Snapshot())
+	assert.Equal(t, 0, journalLines(t, dir))
+
+	// Снимок содержит изменения, очищенные из журнала
+	recovered := openTestStorage(t, dir)
+	defer recovered.Close()
+	_, err = recovered.EventRepository().GetEvent(ctx, id)
+	assert.NoError(t, err)
+}
+
+func TestOpen_UnknownFsyncPolicy(t *testing.T) {
+	_, err := Open(config.MemoryStorageConfig{Dir: t.TempDir(), Fsync: "sometimes"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package memorystorage
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// searchIndex обратный индекс слов названия и описания событий для полнотекстового поиска.
+// Разбиение на слова повторяет конфигурацию simple в Postgres: слова в нижнем регистре без стемминга.
+type searchIndex struct {
+	tokens map[string]map[uuid.UUID]struct{}
+	byID   map[uuid.UUID][]string
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		tokens: make(map[string]map[uuid.UUID]struct{}),
+		byID:   make(map[uuid.UUID][]string),
+	}
+}
+
+// tokenize разбивает текст на уникальные слова в нижнем регистре.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]struct{}, len(words))
+	tokens := words[:0]
+	for _, word := range words {
+		if _, ok := seen[word]; ok {
+			continue
+		}
+		seen[word] = struct{}{}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+func (i *searchIndex) add(id uuid.UUID, texts ...string) {
+	i.remove(id)
+
+	tokens := tokenize(strings.Join(texts, " "))
+	for _, token := range tokens {
+		ids, ok := i.tokens[token]
+		if !ok {
+			ids = make(map[uuid.UUID]struct{})
+			i.tokens[token] = ids
+		}
+		ids[id] = struct{}{}
+	}
+	i.byID[id] = tokens
+}
+
+func (i *searchIndex) remove(id uuid.UUID) {
+	for _, token := range i.byID[id] {
+		delete(i.tokens[token], id)
+		if len(i.tokens[token]) == 0 {
+			delete(i.tokens, token)
+		}
+	}
+	delete(i.byID, id)
+}
+
+// match возвращает идентификаторы событий, содержащих все слова запроса.
+func (i *searchIndex) match(query string) map[uuid.UUID]struct{} {
+	tokens := tokenize(query)
+	result := make(map[uuid.UUID]struct{})
+	for n, token := range tokens {
+		ids := i.tokens[token]
+		if n == 0 {
+			for id := range ids {
+				result[id] = struct{}{}
+			}
+			continue
+		}
+		for id := range result {
+			if _, ok := ids[id]; !ok {
+				delete(result, id)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package memorystorage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// snapshotData полное состояние хранилища, включая tombstone событий.
+type snapshotData struct {
+	Calendars     []storage.Calendar     `json:"calendars"`
+	Events        []storage.Event        `json:"events"`
+	Notifications []storage.Notification `json:"notifications"`
+	// IdempotencyRecords отсутствуют в снимках, сделанных до появления ключей идемпотентности
+	IdempotencyRecords []storage.IdempotencyRecord `json:"idempotencyRecords,omitempty"`
+}
+
+// writeSnapshot атомарно заменяет файл снимка: данные пишутся во временный файл, который после сброса
+// на диск переименовывается.
+func writeSnapshot(path string, data snapshotData) error {
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("on creating snapshot: %w", err)
+	}
+
+	if err := json.NewEncoder(file).Encode(data); err != nil {
+		file.Close()
+		return fmt.Errorf("on writing snapshot: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("on syncing snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("on closing snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("on replacing snapshot: %w", err)
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// readSnapshot читает снимок. Отсутствие снимка означает пустое хранилище.
+func readSnapshot(path string) (snapshotData, error) {
+	var data snapshotData
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return data, fmt.Errorf("on opening snapshot: %w", err)
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(&data); err != nil {
+		return data, fmt.Errorf("on reading snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// syncDir сбрасывает на диск каталог, чтобы переименование файла пережило сбой.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
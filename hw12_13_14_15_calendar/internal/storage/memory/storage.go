@@ -3,6 +3,7 @@ package memorystorage
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
@@ -11,12 +12,39 @@ import (
 type MemoryStorage struct {
 	eventRepo        *EventRepo
 	notificationRepo *NotificationRepo
+	calendarRepo     *CalendarRepo
+	idempotencyRepo  *IdempotencyRepo
+	broker           *storage.Broker
+	// inTx отмечает хранилище, через которое выполняется транзакция
+	inTx bool
+	// persistence журнал и снимки на диске, nil для хранилища без сохранения на диск
+	persistence *persistence
 }
 
 func New() *MemoryStorage {
+	broker := storage.NewBroker()
+	eventRepo := &EventRepo{
+		events: make(map[uuid.UUID]storage.Event),
+		mu:     sync.RWMutex{},
+		broker: broker,
+		index:  newSearchIndex(),
+	}
+	notificationRepo := &NotificationRepo{notifications: make(map[uuid.UUID]storage.Notification), mu: sync.RWMutex{}}
 	store := &MemoryStorage{
-		eventRepo:        &EventRepo{events: make(map[uuid.UUID]storage.Event), mu: sync.RWMutex{}},
-		notificationRepo: &NotificationRepo{notifications: make(map[uuid.UUID]storage.Notification), mu: sync.RWMutex{}},
+		eventRepo:        eventRepo,
+		notificationRepo: notificationRepo,
+		calendarRepo: &CalendarRepo{
+			calendars:        make(map[uuid.UUID]storage.Calendar),
+			mu:               sync.RWMutex{},
+			eventRepo:        eventRepo,
+			notificationRepo: notificationRepo,
+		},
+		idempotencyRepo: &IdempotencyRepo{
+			records:  make(map[storage.IdempotencyKey]storage.IdempotencyRecord),
+			mu:       sync.RWMutex{},
+			purgedAt: new(time.Time),
+		},
+		broker: broker,
 	}
 	return store
 }
@@ -27,7 +55,14 @@ func (s *MemoryStorage) Connect(context.Context) error {
 }
 
 func (s *MemoryStorage) Close() error {
-	// No connection to close for in-memory storage
+	if s.inTx {
+		return storage.ErrInTransaction
+	}
+	// No connection to close for in-memory storage, only change feed subscribers are disconnected
+	s.broker.Close()
+	if s.persistence != nil {
+		return s.closePersistence()
+	}
 	return nil
 }
 
@@ -39,6 +74,18 @@ func (s *MemoryStorage) NotificationRepository() storage.NotificationRepository
 	return s.notificationRepo
 }
 
+func (s *MemoryStorage) CalendarRepository() storage.CalendarRepository {
+	return s.calendarRepo
+}
+
+func (s *MemoryStorage) IdempotencyRepository() storage.IdempotencyRepository {
+	return s.idempotencyRepo
+}
+
+func (s *MemoryStorage) ChangeFeed() storage.ChangeFeed {
+	return s.broker
+}
+
 func (s *MemoryStorage) HealthCheck(context.Context) error {
 	return nil
 }
@@ -0,0 +1,222 @@
+package memorystorage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// changePublisher принимает изменения событий для ленты изменений.
+type changePublisher interface {
+	Publish(change storage.EventChange)
+}
+
+// pendingChanges копит изменения транзакции, чтобы разослать их только после фиксации.
+type pendingChanges struct {
+	changes []storage.EventChange
+}
+
+func (p *pendingChanges) Publish(change storage.EventChange) {
+	p.changes = append(p.changes, change)
+}
+
+// txLog журнал транзакции. Перед применением каждого изменения запоминает прежнее состояние затронутых
+// объектов, чтобы откатить транзакцию, и копит изменения для записи в журнал хранилища при фиксации.
+// Работает под блокировками всех репозиториев, которые держит WithTx.
+type txLog struct {
+	storage *MemoryStorage
+	// rec изменения для журнала, nil для хранилища без сохранения на диск
+	rec  *walRecord
+	undo []func()
+}
+
+func (l *txLog) record(rec walRecord) error {
+	for _, event := range rec.Events {
+		l.saveEvent(event.ID)
+	}
+	for _, notification := range rec.Notifications {
+		l.saveNotification(notification.ID)
+	}
+	for _, id := range rec.DeletedNotifications {
+		l.saveNotification(id)
+	}
+	for _, calendar := range rec.Calendars {
+		l.saveCalendar(calendar.ID)
+	}
+	for _, id := range rec.DeletedCalendars {
+		l.saveCalendar(id)
+	}
+	for _, record := range rec.IdempotencyRecords {
+		l.saveIdempotencyRecord(record.IdempotencyKey)
+	}
+	for _, key := range rec.DeletedIdempotencyKeys {
+		l.saveIdempotencyRecord(key)
+	}
+
+	if l.rec != nil {
+		l.rec.merge(rec)
+	}
+	return nil
+}
+
+func (l *txLog) saveEvent(id uuid.UUID) {
+	repo := l.storage.eventRepo
+	prev, existed := repo.events[id]
+	l.undo = append(l.undo, func() {
+		if !existed {
+			delete(repo.events, id)
+			repo.index.remove(id)
+			return
+		}
+		repo.events[id] = prev
+		if prev.Deleted {
+			repo.index.remove(id)
+		} else {
+			repo.index.add(id, prev.Title, prev.Description)
+		}
+	})
+}
+
+func (l *txLog) saveNotification(id uuid.UUID) {
+	notifications := l.storage.notificationRepo.notifications
+	prev, existed := notifications[id]
+	l.undo = append(l.undo, func() {
+		if existed {
+			notifications[id] = prev
+		} else {
+			delete(notifications, id)
+		}
+	})
+}
+
+func (l *txLog) saveCalendar(id uuid.UUID) {
+	calendars := l.storage.calendarRepo.calendars
+	prev, existed := calendars[id]
+	l.undo = append(l.undo, func() {
+		if existed {
+			calendars[id] = prev
+		} else {
+			delete(calendars, id)
+		}
+	})
+}
+
+func (l *txLog) saveIdempotencyRecord(key storage.IdempotencyKey) {
+	records := l.storage.idempotencyRepo.records
+	prev, existed := records[key]
+	l.undo = append(l.undo, func() {
+		if existed {
+			records[key] = prev
+		} else {
+			delete(records, key)
+		}
+	})
+}
+
+// rollback возвращает прежнее состояние объектов в обратном порядке изменений.
+func (l *txLog) rollback() {
+	for i := len(l.undo) - 1; i >= 0; i-- {
+		l.undo[i]()
+	}
+	l.undo = nil
+}
+
+// WithTx выполняет fn под блокировкой всех репозиториев. Изменения применяются к данным хранилища сразу,
+// а прежнее состояние измененных объектов запоминается. Если fn завершилась без ошибки, изменения
+// записываются в журнал одной записью, иначе прежнее состояние восстанавливается.
+// Вызов на хранилище транзакции присоединяется к ней: фиксацию и откат выполняет внешний WithTx.
+func (s *MemoryStorage) WithTx(_ context.Context, fn func(tx storage.Storage) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	// Порядок блокировок совпадает с DeleteCalendar: сначала календари, затем события
+	s.calendarRepo.mu.Lock()
+	defer s.calendarRepo.mu.Unlock()
+	s.eventRepo.mu.Lock()
+	defer s.eventRepo.mu.Unlock()
+	s.notificationRepo.mu.Lock()
+	defer s.notificationRepo.mu.Unlock()
+	s.idempotencyRepo.mu.Lock()
+	defer s.idempotencyRepo.mu.Unlock()
+
+	pending := &pendingChanges{}
+	txJournal := &txLog{storage: s}
+	if s.persistence != nil {
+		txJournal.rec = &walRecord{}
+	}
+	txEventRepo := s.eventRepo.txView(pending, txJournal)
+	txNotificationRepo := s.notificationRepo.txView(txJournal)
+	tx := &MemoryStorage{
+		eventRepo:        txEventRepo,
+		notificationRepo: txNotificationRepo,
+		calendarRepo:     s.calendarRepo.txView(txEventRepo, txNotificationRepo, txJournal),
+		idempotencyRepo:  s.idempotencyRepo.txView(txJournal),
+		broker:           s.broker,
+		inTx:             true,
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		txJournal.rollback()
+		if p := recover(); p != nil {
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if txJournal.rec != nil && !txJournal.rec.empty() {
+		if err := s.persistence.journal.record(*txJournal.rec); err != nil {
+			return err
+		}
+	}
+	committed = true
+
+	s.eventRepo.version = txEventRepo.version
+	for _, change := range pending.changes {
+		s.broker.Publish(change)
+	}
+	return nil
+}
+
+// txView возвращает репозиторий транзакции, который работает с данными r. Вызывается под r.mu.
+func (r *EventRepo) txView(publisher changePublisher, journal recorder) *EventRepo {
+	return &EventRepo{
+		events:  r.events,
+		broker:  publisher,
+		version: r.version,
+		index:   r.index,
+		journal: journal,
+	}
+}
+
+// txView возвращает репозиторий транзакции, который работает с данными r. Вызывается под r.mu.
+func (r *NotificationRepo) txView(journal recorder) *NotificationRepo {
+	return &NotificationRepo{notifications: r.notifications, journal: journal}
+}
+
+// txView возвращает репозиторий транзакции, который работает с данными r. Вызывается под r.mu.
+func (r *IdempotencyRepo) txView(journal recorder) *IdempotencyRepo {
+	return &IdempotencyRepo{records: r.records, journal: journal, purgedAt: r.purgedAt}
+}
+
+// txView возвращает репозиторий транзакции, который работает с данными r и связан с репозиториями
+// транзакции для событий и уведомлений. Вызывается под r.mu.
+func (r *CalendarRepo) txView(
+	eventRepo *EventRepo,
+	notificationRepo *NotificationRepo,
+	journal recorder,
+) *CalendarRepo {
+	return &CalendarRepo{
+		calendars:        r.calendars,
+		eventRepo:        eventRepo,
+		notificationRepo: notificationRepo,
+		journal:          journal,
+	}
+}
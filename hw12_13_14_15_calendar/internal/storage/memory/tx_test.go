@@ -0,0 +1,133 @@
+package memorystorage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStorage_WithTx(t *testing.T) {
+	ctx := context.Background()
+	event := storage.Event{
+		Title:     "Transactional Event",
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(time.Hour),
+		UserID:    uuid.New(),
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		memStore := New()
+		changes, err := memStore.ChangeFeed().Subscribe(ctx, event.UserID)
+		require.NoError(t, err)
+
+		var id uuid.UUID
+		err = memStore.WithTx(ctx, func(tx storage.Storage) error {
+			id, err = tx.EventRepository().CreateEvent(ctx, event)
+			if err != nil {
+				return err
+			}
+			// До фиксации чтение через исходное хранилище ждет транзакцию, изменения не попадают в ленту
+			read := make(chan error, 1)
+			go func() {
+				_, err := memStore.EventRepository().GetEvent(ctx, id)
+				read <- err
+			}()
+			t.Cleanup(func() { assert.NoError(t, <-read) })
+			time.Sleep(20 * time.Millisecond)
+			assert.Empty(t, read)
+			assert.Empty(t, changes)
+
+			_, err = tx.NotificationRepository().CreateNotification(ctx, storage.Notification{EventID: id})
+			return err
+		})
+		require.NoError(t, err)
+
+		stored, err := memStore.EventRepository().GetEvent(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, event.Title, stored.Title)
+
+		found, err := memStore.EventRepository().SearchEvents(ctx, storage.EventFilter{Query: "transactional"})
+		require.NoError(t, err)
+		assert.Len(t, found, 1)
+
+		change := <-changes
+		assert.Equal(t, storage.ChangeCreated, change.Type)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		memStore := New()
+		id, err := memStore.EventRepository().CreateEvent(ctx, event)
+		require.NoError(t, err)
+
+		errFailed := errors.New("failed")
+		err = memStore.WithTx(ctx, func(tx storage.Storage) error {
+			if err := tx.EventRepository().DeleteEvent(ctx, id); err != nil {
+				return err
+			}
+			if _, err := tx.EventRepository().CreateEvent(ctx, event); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		assert.ErrorIs(t, err, errFailed)
+
+		_, err = memStore.EventRepository().GetEvent(ctx, id)
+		assert.NoError(t, err)
+
+		events, err := memStore.EventRepository().SyncEvents(ctx, event.UserID, 0, 0)
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+
+		// Поисковый индекс откатывается вместе с событиями
+		found, err := memStore.EventRepository().SearchEvents(ctx, storage.EventFilter{Query: "transactional"})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, id, found[0].ID)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		memStore := New()
+		assert.Panics(t, func() {
+			_ = memStore.WithTx(ctx, func(tx storage.Storage) error {
+				if _, err := tx.EventRepository().CreateEvent(ctx, event); err != nil {
+					return err
+				}
+				panic("failed")
+			})
+		})
+
+		events, err := memStore.EventRepository().SyncEvents(ctx, event.UserID, 0, 0)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		memStore := New()
+		var id uuid.UUID
+		err := memStore.WithTx(ctx, func(tx storage.Storage) error {
+			// Вложенный вызов работает в той же транзакции и откатывается вместе с ней
+			err := tx.WithTx(ctx, func(nested storage.Storage) error {
+				var err error
+				id, err = nested.EventRepository().CreateEvent(ctx, storage.Event{UserID: uuid.New()})
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			_, err = tx.EventRepository().GetEvent(ctx, id)
+			assert.NoError(t, err)
+			return errFailed
+		})
+		assert.ErrorIs(t, err, errFailed)
+
+		_, err = memStore.EventRepository().GetEvent(ctx, id)
+		assert.ErrorIs(t, err, storage.ErrEventNotFound)
+	})
+}
@@ -0,0 +1,203 @@
+package memorystorage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Политики сброса журнала на диск.
+const (
+	// FsyncAlways сбрасывает журнал после каждой записи: подтвержденное изменение не теряется при сбое.
+	FsyncAlways = "always"
+	// FsyncInterval сбрасывает журнал раз в интервал: при сбое теряются изменения за последний интервал.
+	FsyncInterval = "interval"
+	// FsyncNever оставляет сброс на усмотрение ОС: изменения переживают падение процесса, но не ОС.
+	FsyncNever = "never"
+)
+
+// walRecord запись журнала с изменениями, которые применяются вместе: одна операция репозитория
+// или вся транзакция. Записи содержат полное новое состояние объектов, поэтому повторное применение
+// записи не меняет результат.
+type walRecord struct {
+	Calendars              []storage.Calendar          `json:"calendars,omitempty"`
+	DeletedCalendars       []uuid.UUID                 `json:"deletedCalendars,omitempty"`
+	Events                 []storage.Event             `json:"events,omitempty"`
+	Notifications          []storage.Notification      `json:"notifications,omitempty"`
+	DeletedNotifications   []uuid.UUID                 `json:"deletedNotifications,omitempty"`
+	IdempotencyRecords     []storage.IdempotencyRecord `json:"idempotencyRecords,omitempty"`
+	DeletedIdempotencyKeys []storage.IdempotencyKey    `json:"deletedIdempotencyKeys,omitempty"`
+}
+
+// merge дописывает изменения rec после изменений r.
+func (r *walRecord) merge(rec walRecord) {
+	r.Calendars = append(r.Calendars, rec.Calendars...)
+	r.DeletedCalendars = append(r.DeletedCalendars, rec.DeletedCalendars...)
+	r.Events = append(r.Events, rec.Events...)
+	r.Notifications = append(r.Notifications, rec.Notifications...)
+	r.DeletedNotifications = append(r.DeletedNotifications, rec.DeletedNotifications...)
+	r.IdempotencyRecords = append(r.IdempotencyRecords, rec.IdempotencyRecords...)
+	r.DeletedIdempotencyKeys = append(r.DeletedIdempotencyKeys, rec.DeletedIdempotencyKeys...)
+}
+
+func (r *walRecord) empty() bool {
+	return len(r.Calendars) == 0 && len(r.DeletedCalendars) == 0 && len(r.Events) == 0 &&
+		len(r.Notifications) == 0 && len(r.DeletedNotifications) == 0 &&
+		len(r.IdempotencyRecords) == 0 && len(r.DeletedIdempotencyKeys) == 0
+}
+
+// recorder принимает изменения репозиториев до их применения.
+type recorder interface {
+	record(rec walRecord) error
+}
+
+// writeRecord передает изменения в журнал, если хранилище его ведет.
+func writeRecord(r recorder, rec walRecord) error {
+	if r == nil {
+		return nil
+	}
+	return r.record(rec)
+}
+
+// journal журнал упреждающей записи: по записи JSON в строке.
+type journal struct {
+	mu    sync.Mutex
+	file  *os.File
+	fsync string
+	// size длина журнала после последней успешной записи
+	size int64
+	// dirty отмечает записи, еще не сброшенные на диск
+	dirty bool
+	// broken ошибка, после которой в конце журнала могла остаться недописанная запись
+	broken error
+}
+
+func openJournal(path string, fsync string) (*journal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("on opening journal: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("on opening journal: %w", err)
+	}
+	return &journal{file: file, fsync: fsync, size: info.Size()}, nil
+}
+
+func (j *journal) record(rec walRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("on encoding journal record: %w", err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.broken != nil {
+		return fmt.Errorf("journal is unusable after failed write: %w", j.broken)
+	}
+
+	n, err := j.file.Write(append(data, '\n'))
+	if err == nil && j.fsync == FsyncAlways {
+		err = j.file.Sync()
+	}
+	if err != nil {
+		// Изменение не будет применено, поэтому запись удаляется из журнала: недописанная строка
+		// оказалась бы в середине журнала после следующей записи, а целая - воспроизвелась бы при запуске
+		if truncateErr := j.file.Truncate(j.size); truncateErr != nil {
+			j.broken = truncateErr
+			return fmt.Errorf("on writing journal: %w", errors.Join(err, truncateErr))
+		}
+		return fmt.Errorf("on writing journal: %w", err)
+	}
+	j.size += int64(n)
+	if j.fsync != FsyncAlways {
+		j.dirty = true
+	}
+	return nil
+}
+
+// sync сбрасывает на диск записи, сделанные после предыдущего сброса.
+func (j *journal) sync() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.dirty {
+		return nil
+	}
+	j.dirty = false
+	return j.file.Sync()
+}
+
+// reset очищает журнал после того, как его записи вошли в снимок.
+func (j *journal) reset() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.file.Truncate(0); err != nil {
+		return fmt.Errorf("on truncating journal: %w", err)
+	}
+	j.size = 0
+	j.broken = nil
+	j.dirty = false
+	return j.file.Sync()
+}
+
+func (j *journal) close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	return j.file.Close()
+}
+
+// replayJournal передает в apply записи журнала по порядку. Недописанная последняя запись, оставшаяся
+// после сбоя во время записи, отбрасывается и обрезается. Испорченная запись в середине журнала - ошибка.
+func replayJournal(path string, apply func(rec walRecord)) (replayed int, truncated bool, err error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0o600)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("on opening journal: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if errors.Is(readErr, io.EOF) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				return replayed, false, nil
+			}
+			// Запись без перевода строки не была дописана до конца
+			return replayed, true, truncateJournal(file, offset)
+		}
+		if readErr != nil {
+			return replayed, false, fmt.Errorf("on reading journal: %w", readErr)
+		}
+
+		var rec walRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return replayed, false, fmt.Errorf("on decoding journal record at offset %d: %w", offset, err)
+		}
+		apply(rec)
+		replayed++
+		offset += int64(len(line))
+	}
+}
+
+func truncateJournal(file *os.File, offset int64) error {
+	if err := file.Truncate(offset); err != nil {
+		return fmt.Errorf("on truncating journal: %w", err)
+	}
+	return file.Sync()
+}
@@ -14,6 +14,8 @@ type Notification struct {
 	Message string    `json:"message"`
 	Sent    string    `json:"sent"`
 	UserID  uuid.UUID `json:"userId"`
+	// Version увеличивается при каждом обновлении. При обновлении ненулевая версия означает ожидаемую текущую версию.
+	Version int64 `json:"version"`
 }
 
 type NotificationRepository interface {
@@ -21,6 +23,10 @@ type NotificationRepository interface {
 	UpdateNotification(ctx context.Context, id uuid.UUID, notification Notification) error
 	DeleteNotification(ctx context.Context, id uuid.UUID) error
 	GetNotification(ctx context.Context, id uuid.UUID) (Notification, error)
+	// ListNotifications возвращает ожидающие отправки уведомления со временем в периоде [start, end].
 	ListNotifications(ctx context.Context, start, end time.Time) ([]Notification, error)
+	// DeleteSentNotifications удаляет отправленные уведомления.
 	DeleteSentNotifications(ctx context.Context) error
+	// DeleteEventNotifications удаляет все уведомления события.
+	DeleteEventNotifications(ctx context.Context, eventID uuid.UUID) error
 }
@@ -0,0 +1,184 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type CalendarRepo struct {
+	db Querier
+	// reader выполняет запросы чтения: реплика, если она настроена, иначе тот же db
+	reader Querier
+	logger logger.Logger
+}
+
+func NewCalendarRepo(db, reader Querier, logger logger.Logger) *CalendarRepo {
+	return &CalendarRepo{
+		db:     db,
+		reader: reader,
+		logger: logger,
+	}
+}
+
+func (r *CalendarRepo) CreateCalendar(ctx context.Context, calendar storage.Calendar) (uuid.UUID, error) {
+	id := uuid.New()
+	query := `INSERT INTO calendars (id, user_id, name, color, default_reminder_minutes, timezone) 
+              VALUES ($1, $2, $3, $4, $5, $6)`
+	r.logger.Debugf("CreateCalendar SQL: %s", query)
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		id,
+		calendar.UserID,
+		calendar.Name,
+		calendar.Color,
+		int(calendar.DefaultReminder/time.Minute),
+		calendar.Timezone,
+	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func (r *CalendarRepo) UpdateCalendar(ctx context.Context, id uuid.UUID, calendar storage.Calendar) error {
+	query := `UPDATE calendars SET user_id=$1, name=$2, color=$3, default_reminder_minutes=$4, timezone=$5 
+              WHERE id=$6`
+	r.logger.Debugf("UpdateCalendar SQL: %s", query)
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		calendar.UserID,
+		calendar.Name,
+		calendar.Color,
+		int(calendar.DefaultReminder/time.Minute),
+		calendar.Timezone,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result, storage.ErrCalendarNotFound)
+}
+
+func (r *CalendarRepo) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	// События календаря удаляются вместе с ним, но остаются tombstone для синхронизации
+	query := `WITH events_deleted AS (
+					UPDATE events SET deleted_at = now() AT TIME ZONE 'UTC'
+					WHERE calendar_id=$1 AND deleted_at IS NULL
+					RETURNING id
+				), notifications_deleted AS (
+					DELETE FROM notifications WHERE event_id IN (SELECT id FROM events_deleted)
+				)
+				DELETE FROM calendars WHERE id=$1`
+	r.logger.Debugf("DeleteCalendar SQL: %s", query)
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result, storage.ErrCalendarNotFound)
+}
+
+func (r *CalendarRepo) GetCalendar(ctx context.Context, id uuid.UUID) (storage.Calendar, error) {
+	query := `SELECT id, user_id, name, color, default_reminder_minutes, timezone FROM calendars WHERE id=$1`
+	r.logger.Debugf("GetCalendar SQL: %s", query)
+
+	calendar, err := scanCalendar(r.reader.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Calendar{}, storage.ErrCalendarNotFound
+	}
+	return calendar, err
+}
+
+func (r *CalendarRepo) ListCalendars(ctx context.Context, userID uuid.UUID) ([]storage.Calendar, error) {
+	query := `SELECT id, user_id, name, color, default_reminder_minutes, timezone 
+				FROM calendars WHERE user_id=$1 ORDER BY name`
+	r.logger.Debugf("ListCalendars SQL: %s", query)
+
+	rows, err := r.reader.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("on list calendars: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.logger.Errorf("on closing rows in ListCalendars: %v", err)
+		}
+	}(rows)
+
+	var calendars []storage.Calendar
+	for rows.Next() {
+		calendar, err := scanCalendar(rows)
+		if err != nil {
+			return nil, fmt.Errorf("on scan calendars: %w", err)
+		}
+		calendars = append(calendars, calendar)
+	}
+	return calendars, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCalendar(row rowScanner) (storage.Calendar, error) {
+	var calendar storage.Calendar
+	var reminderMinutes int
+	err := row.Scan(
+		&calendar.ID,
+		&calendar.UserID,
+		&calendar.Name,
+		&calendar.Color,
+		&reminderMinutes,
+		&calendar.Timezone,
+	)
+	calendar.DefaultReminder = time.Duration(reminderMinutes) * time.Minute
+	return calendar, err
+}
+
+// checkAffected возвращает notFound, если запрос не затронул ни одной строки.
+func checkAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// checkVersioned проверяет результат условного по версии обновления. Если строки не затронуты,
+// existsQuery выясняет причину: запись есть, но версия устарела, либо записи нет.
+func checkVersioned(
+	ctx context.Context,
+	db Querier,
+	result sql.Result,
+	existsQuery string,
+	id uuid.UUID,
+	notFound error,
+) error {
+	err := checkAffected(result, notFound)
+	if !errors.Is(err, notFound) {
+		return err
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, existsQuery, id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return storage.ErrVersionConflict
+	}
+	return notFound
+}
@@ -0,0 +1,177 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// eventChangesChannel канал NOTIFY, в который триггер events_notify_change пишет изменения событий.
+const eventChangesChannel = "event_changes"
+
+// readEventTimeout ограничение времени чтения события, о котором пришло уведомление.
+const readEventTimeout = 5 * time.Second
+
+// ChangeFeed лента изменений на основе Postgres LISTEN/NOTIFY. Уведомления принимаются одним
+// соединением и раздаются подписчикам через in-process брокер. Уведомление содержит только
+// идентификатор события, само событие перечитывается из основной базы.
+type ChangeFeed struct {
+	dsn    string
+	db     Querier
+	logger logger.Logger
+	broker *storage.Broker
+
+	mu       sync.Mutex
+	listener *pq.Listener
+}
+
+func NewChangeFeed(dsn string, db Querier, logger logger.Logger) *ChangeFeed {
+	return &ChangeFeed{
+		dsn:    dsn,
+		db:     db,
+		logger: logger,
+		broker: storage.NewBroker(),
+	}
+}
+
+func (f *ChangeFeed) Subscribe(ctx context.Context, userID uuid.UUID) (<-chan storage.EventChange, error) {
+	if err := f.listen(); err != nil {
+		return nil, err
+	}
+	return f.broker.Subscribe(ctx, userID)
+}
+
+func (f *ChangeFeed) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.broker.Close()
+	if f.listener == nil {
+		return nil
+	}
+	err := f.listener.Close()
+	f.listener = nil
+	return err
+}
+
+// listen лениво поднимает соединение LISTEN при первой подписке.
+func (f *ChangeFeed) listen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.listener != nil {
+		return nil
+	}
+
+	listener := pq.NewListener(f.dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			f.logger.Errorf("change feed listener event %d: %v", event, err)
+		}
+	})
+	if err := listener.Listen(eventChangesChannel); err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("on listen %s: %w", eventChangesChannel, err)
+	}
+	f.listener = listener
+
+	go f.dispatch(listener)
+	return nil
+}
+
+func (f *ChangeFeed) dispatch(listener *pq.Listener) {
+	for notification := range listener.Notify {
+		// nil приходит после переподключения: изменения за время разрыва потеряны, поэтому подписчики
+		// отключаются и, как и при переполнении буфера, досинхронизируются через SyncEvents
+		if notification == nil {
+			f.logger.Info("change feed listener reconnected, disconnecting subscribers to resync")
+			f.broker.Close()
+			continue
+		}
+
+		payload, err := decodeEventChange(notification.Extra)
+		if err != nil {
+			f.logger.Errorf("on decode event change: %v", err)
+			continue
+		}
+		change, ok, err := f.resolve(payload)
+		if err != nil {
+			f.logger.Errorf("on read changed event %s: %v", payload.ID, err)
+			continue
+		}
+		if ok {
+			f.broker.Publish(change)
+		}
+	}
+}
+
+// eventChangePayload уведомление триггера events_notify_change.
+type eventChangePayload struct {
+	Op      string    `json:"op"`
+	ID      uuid.UUID `json:"id"`
+	UserID  uuid.UUID `json:"user_id"`
+	Version int64     `json:"version"`
+}
+
+var changeTypes = map[string]storage.ChangeType{
+	"INSERT": storage.ChangeCreated,
+	"UPDATE": storage.ChangeUpdated,
+	"DELETE": storage.ChangeDeleted,
+}
+
+func decodeEventChange(payload string) (eventChangePayload, error) {
+	var data eventChangePayload
+	if err := json.Unmarshal([]byte(payload), &data); err != nil {
+		return eventChangePayload{}, err
+	}
+	if _, ok := changeTypes[data.Op]; !ok {
+		return eventChangePayload{}, fmt.Errorf("unknown operation %q", data.Op)
+	}
+	return data, nil
+}
+
+// resolve перечитывает событие из уведомления. Если событие успело измениться еще раз, уведомление
+// пропускается: подписчики получат актуальное состояние по следующему уведомлению.
+func (f *ChangeFeed) resolve(payload eventChangePayload) (storage.EventChange, bool, error) {
+	tombstone := storage.EventChange{
+		Type: storage.ChangeDeleted,
+		Event: storage.Event{
+			ID:      payload.ID,
+			UserID:  payload.UserID,
+			Version: payload.Version,
+			Deleted: true,
+		},
+	}
+	if payload.Op == "DELETE" {
+		return tombstone, true, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), readEventTimeout)
+	defer cancel()
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id=$1`
+	event, err := scanEvent(f.db.QueryRowContext(ctx, query, payload.ID))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Строка удалена следующей транзакцией, ее уведомление DELETE придет следом
+		return storage.EventChange{}, false, nil
+	case err != nil:
+		return storage.EventChange{}, false, err
+	case payload.Version != 0 && event.Version != payload.Version:
+		return storage.EventChange{}, false, nil
+	}
+
+	change := storage.EventChange{Type: changeTypes[payload.Op], Event: event}
+	// Мягкое удаление приходит как UPDATE строки с заполненным deleted_at
+	if event.Deleted {
+		change.Type = storage.ChangeDeleted
+	}
+	return change, true, nil
+}
@@ -0,0 +1,49 @@
+package sqlstorage
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeEventChange(t *testing.T) {
+	id := uuid.New()
+	userID := uuid.New()
+	payload := `{"op" : "UPDATE", "id" : "` + id.String() + `", "user_id" : "` + userID.String() + `", "version" : 42}`
+
+	change, err := decodeEventChange(payload)
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE", change.Op)
+	assert.Equal(t, id, change.ID)
+	assert.Equal(t, userID, change.UserID)
+	assert.Equal(t, int64(42), change.Version)
+
+	// До миграции 008 версия в уведомлении не передается
+	change, err = decodeEventChange(`{"op":"INSERT","id":"` + id.String() + `","user_id":"` + userID.String() +
+		`","version":null}`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), change.Version)
+}
+
+func TestDecodeEventChange_UnknownOperation(t *testing.T) {
+	_, err := decodeEventChange(`{"op":"TRUNCATE"}`)
+	assert.Error(t, err)
+}
+
+func TestChangeFeed_ResolveDelete(t *testing.T) {
+	// Удаленную строку перечитать нельзя, изменение собирается из уведомления без запроса к базе
+	feed := NewChangeFeed("", nil, newTestLogger(t))
+	payload := eventChangePayload{Op: "DELETE", ID: uuid.New(), UserID: uuid.New(), Version: 7}
+
+	change, ok, err := feed.resolve(payload)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, storage.ChangeDeleted, change.Type)
+	assert.Equal(t, payload.ID, change.Event.ID)
+	assert.Equal(t, payload.UserID, change.Event.UserID)
+	assert.Equal(t, int64(7), change.Event.Version)
+	assert.True(t, change.Event.Deleted)
+}
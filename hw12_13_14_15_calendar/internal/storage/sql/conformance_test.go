@@ -0,0 +1,53 @@
+package sqlstorage
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConformance проверяет хранилище на тестовой базе Postgres, заданной переменными окружения
+// TEST_DB_HOST, TEST_DB_PORT, TEST_DB_USER, TEST_DB_PASSWORD и TEST_DB_NAME. Без TEST_DB_HOST тест пропускается.
+func TestConformance(t *testing.T) {
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST is not set")
+	}
+
+	port := 5432
+	if value := os.Getenv("TEST_DB_PORT"); value != "" {
+		var err error
+		port, err = strconv.Atoi(value)
+		require.NoError(t, err)
+	}
+	cfg := config.DatabaseConfig{
+		Host:     host,
+		Port:     port,
+		User:     os.Getenv("TEST_DB_USER"),
+		Password: config.Secret(os.Getenv("TEST_DB_PASSWORD")),
+		Name:     os.Getenv("TEST_DB_NAME"),
+	}
+
+	migrator, err := NewMigrator(cfg)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.CheckVersion())
+	require.NoError(t, migrator.Close())
+
+	store, err := New(cfg, newTestLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, store.Connect(context.Background()))
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+
+	storagetest.Run(t, func(*testing.T) storage.Storage {
+		return store
+	})
+}
@@ -4,75 +4,105 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
+// eventColumns перечисляет колонки событий в порядке, который ожидает scanEvent.
+const eventColumns = `id, title, description, start_time, end_time, user_id, calendar_id, attendees, version, updated_at, 
+						deleted_at`
+
 type EventRepo struct {
-	db     *sql.DB
+	db Querier
+	// reader выполняет запросы чтения: реплика, если она настроена, иначе тот же db
+	reader Querier
 	logger logger.Logger
 }
 
-func NewEventRepo(db *sql.DB, logger logger.Logger) *EventRepo {
+func NewEventRepo(db, reader Querier, logger logger.Logger) *EventRepo {
 	return &EventRepo{
 		db:     db,
+		reader: reader,
 		logger: logger,
 	}
 }
 
 func (r *EventRepo) CreateEvent(ctx context.Context, event storage.Event) (uuid.UUID, error) {
-	query := `INSERT INTO events (id, title, description, start_time, end_time, user_id) 
-              VALUES ($1, $2, $3, $4, $5, $6)`
+	query := `INSERT INTO events (id, title, description, start_time, end_time, user_id, calendar_id, attendees) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 	r.logger.Debugf("CreateEvent SQL: %s", query)
 
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
 	_, err := r.db.ExecContext(
 		ctx,
 		query,
 		event.ID,
 		event.Title,
 		event.Description,
-		event.StartTime,
-		event.EndTime,
+		event.StartTime.UTC(),
+		event.EndTime.UTC(),
 		event.UserID,
+		nullUUID(event.CalendarID),
+		attendeesArray(event.Attendees),
 	)
-	return event.ID, err
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return event.ID, nil
 }
 
 func (r *EventRepo) UpdateEvent(ctx context.Context, id uuid.UUID, event storage.Event) error {
-	query := `UPDATE events SET title=$1, description=$2, start_time=$3, end_time=$4, user_id=$5 WHERE id=$6`
+	query := `UPDATE events SET title=$1, description=$2, start_time=$3, end_time=$4, user_id=$5, calendar_id=$6, 
+              attendees=$7
+              WHERE id=$8 AND deleted_at IS NULL AND ($9 = 0 OR version = $9)`
 	r.logger.Debugf("UpdateEvent SQL: %s", query)
 
-	_, err := r.db.ExecContext(
+	result, err := r.db.ExecContext(
 		ctx,
 		query,
 		event.Title,
 		event.Description,
-		event.StartTime,
-		event.EndTime,
+		event.StartTime.UTC(),
+		event.EndTime.UTC(),
 		event.UserID,
+		nullUUID(event.CalendarID),
+		attendeesArray(event.Attendees),
 		id,
+		event.Version,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	existsQuery := `SELECT EXISTS(SELECT 1 FROM events WHERE id=$1 AND deleted_at IS NULL)`
+	return checkVersioned(ctx, r.db, result, existsQuery, id, storage.ErrEventNotFound)
 }
 
 func (r *EventRepo) DeleteEvent(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM events WHERE id=$1`
+	// Событие остается в таблице как tombstone для синхронизации
+	query := `UPDATE events SET deleted_at = now() AT TIME ZONE 'UTC' WHERE id=$1 AND deleted_at IS NULL`
 	r.logger.Debugf("DeleteEvent SQL: %s", query)
 
-	_, err := r.db.ExecContext(ctx, query, id)
-	return err
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result, storage.ErrEventNotFound)
 }
 
 func (r *EventRepo) GetEvent(ctx context.Context, id uuid.UUID) (storage.Event, error) {
-	query := `SELECT id, title, description, start_time, end_time, user_id FROM events WHERE id=$1`
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id=$1 AND deleted_at IS NULL`
 	r.logger.Debugf("GetEvent SQL: %s", query)
 
-	row := r.db.QueryRowContext(ctx, query, id)
-	var event storage.Event
-	err := row.Scan(&event.ID, &event.Title, &event.Description, &event.StartTime, &event.EndTime, &event.UserID)
+	event, err := scanEvent(r.reader.QueryRowContext(ctx, query, id))
 	if errors.Is(err, sql.ErrNoRows) {
 		return storage.Event{}, storage.ErrEventNotFound
 	}
@@ -80,29 +110,154 @@ func (r *EventRepo) GetEvent(ctx context.Context, id uuid.UUID) (storage.Event,
 }
 
 func (r *EventRepo) ListEvents(ctx context.Context, start, end time.Time) ([]storage.Event, error) {
-	query := `SELECT id, title, description, start_time, end_time, user_id 
-				FROM events WHERE start_time >= $1 AND end_time <= $2`
+	query := `SELECT ` + eventColumns + ` 
+				FROM events WHERE start_time >= $1 AND end_time <= $2 AND deleted_at IS NULL`
 	r.logger.Debugf("ListEvents SQL: %s", query)
 
-	rows, err := r.db.QueryContext(ctx, query, start, end)
+	rows, err := r.reader.QueryContext(ctx, query, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return r.scanEvents(rows, "ListEvents")
+}
+
+func (r *EventRepo) ListEventsByCalendars(
+	ctx context.Context,
+	calendarIDs []uuid.UUID,
+	start,
+	end time.Time,
+) ([]storage.Event, error) {
+	query := `SELECT ` + eventColumns + ` 
+				FROM events WHERE calendar_id = ANY($1) AND start_time >= $2 AND end_time <= $3 AND deleted_at IS NULL`
+	r.logger.Debugf("ListEventsByCalendars SQL: %s", query)
+
+	ids := make([]string, len(calendarIDs))
+	for i, id := range calendarIDs {
+		ids[i] = id.String()
+	}
+
+	rows, err := r.reader.QueryContext(ctx, query, pq.Array(ids), start.UTC(), end.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return r.scanEvents(rows, "ListEventsByCalendars")
+}
+
+func (r *EventRepo) SyncEvents(
+	ctx context.Context,
+	userID uuid.UUID,
+	sinceVersion int64,
+	limit int,
+) ([]storage.Event, error) {
+	// Триггер events_bump_version выдает версии событий пользователя в порядке фиксации транзакций,
+	// поэтому изменение с версией меньше уже отданной не может появиться позже
+	query := `SELECT ` + eventColumns + ` 
+				FROM events WHERE user_id = $1 AND version > $2 AND ($2 > 0 OR deleted_at IS NULL)
+				ORDER BY version LIMIT NULLIF($3, 0)`
+	r.logger.Debugf("SyncEvents SQL: %s", query)
+
+	rows, err := r.db.QueryContext(ctx, query, userID, sinceVersion, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.scanEvents(rows, "SyncEvents")
+}
+
+func (r *EventRepo) SearchEvents(ctx context.Context, filter storage.EventFilter) ([]storage.Event, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if strings.TrimSpace(filter.Query) != "" {
+		addCondition("search_vector @@ plainto_tsquery('simple', $%d)", filter.Query)
+	}
+	if filter.UserID != uuid.Nil {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+	if filter.CalendarID != uuid.Nil {
+		addCondition("calendar_id = $%d", filter.CalendarID)
+	}
+	if !filter.Start.IsZero() {
+		addCondition("end_time > $%d", filter.Start.UTC())
+	}
+	if !filter.End.IsZero() {
+		addCondition("start_time < $%d", filter.End.UTC())
+	}
+	if filter.Attendee != "" {
+		addCondition("attendees @> ARRAY[$%d]::TEXT[]", filter.Attendee)
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM events WHERE ` + strings.Join(conditions, " AND ") +
+		` ORDER BY start_time, id`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	r.logger.Debugf("SearchEvents SQL: %s", query)
+
+	rows, err := r.reader.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	return r.scanEvents(rows, "SearchEvents")
+}
+
+func (r *EventRepo) scanEvents(rows *sql.Rows, method string) ([]storage.Event, error) {
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-			r.logger.Errorf("on closing rows in ListEvents: %v", err)
+			r.logger.Errorf("on closing rows in %s: %v", method, err)
 		}
 	}(rows)
 
 	var events []storage.Event
 	for rows.Next() {
-		var event storage.Event
-		err = rows.Scan(&event.ID, &event.Title, &event.Description, &event.StartTime, &event.EndTime, &event.UserID)
+		event, err := scanEvent(rows)
 		if err != nil {
 			return nil, err
 		}
 		events = append(events, event)
 	}
-	return events, nil
+	return events, rows.Err()
+}
+
+func scanEvent(row rowScanner) (storage.Event, error) {
+	var event storage.Event
+	var calendarID uuid.NullUUID
+	var deletedAt sql.NullTime
+	err := row.Scan(
+		&event.ID,
+		&event.Title,
+		&event.Description,
+		&event.StartTime,
+		&event.EndTime,
+		&event.UserID,
+		&calendarID,
+		(*pq.StringArray)(&event.Attendees),
+		&event.Version,
+		&event.UpdatedAt,
+		&deletedAt,
+	)
+	if len(event.Attendees) == 0 {
+		event.Attendees = nil
+	}
+	event.CalendarID = calendarID.UUID
+	event.Deleted = deletedAt.Valid
+	return event, err
+}
+
+// attendeesArray передает пустой список участников как пустой массив, а не NULL.
+func attendeesArray(attendees []string) pq.StringArray {
+	if attendees == nil {
+		return pq.StringArray{}
+	}
+	return attendees
+}
+
+// nullUUID превращает uuid.Nil в NULL, чтобы не нарушать внешний ключ на calendars.
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
 }
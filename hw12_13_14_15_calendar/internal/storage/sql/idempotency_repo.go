@@ -0,0 +1,82 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// IdempotencyRepo хранит ключи идемпотентности. Все запросы идут в основную базу: отставание реплики
+// привело бы к повторному выполнению запроса.
+type IdempotencyRepo struct {
+	db     Querier
+	logger logger.Logger
+}
+
+func NewIdempotencyRepo(db Querier, logger logger.Logger) *IdempotencyRepo {
+	return &IdempotencyRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *IdempotencyRepo) CreateIdempotencyRecord(ctx context.Context, record storage.IdempotencyRecord) error {
+	// Истекшая запись заменяется, действующая остается: тогда вставка не затрагивает строк
+	query := `INSERT INTO idempotency_keys (user_id, operation, key, request_hash, response, expires_at)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (user_id, operation, key) DO UPDATE
+				SET request_hash = EXCLUDED.request_hash, response = EXCLUDED.response, expires_at = EXCLUDED.expires_at
+				WHERE idempotency_keys.expires_at <= $7`
+	r.logger.Debugf("CreateIdempotencyRecord SQL: %s", query)
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		record.UserID,
+		record.Operation,
+		record.Key,
+		record.RequestHash,
+		record.Response,
+		record.ExpiresAt.UTC(),
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result, storage.ErrIdempotencyKeyExists)
+}
+
+func (r *IdempotencyRepo) GetIdempotencyRecord(
+	ctx context.Context,
+	key storage.IdempotencyKey,
+) (storage.IdempotencyRecord, error) {
+	query := `SELECT user_id, operation, key, request_hash, response, expires_at FROM idempotency_keys
+				WHERE user_id = $1 AND operation = $2 AND key = $3 AND expires_at > $4`
+	r.logger.Debugf("GetIdempotencyRecord SQL: %s", query)
+
+	var record storage.IdempotencyRecord
+	err := r.db.QueryRowContext(ctx, query, key.UserID, key.Operation, key.Key, time.Now().UTC()).Scan(
+		&record.UserID,
+		&record.Operation,
+		&record.Key,
+		&record.RequestHash,
+		&record.Response,
+		&record.ExpiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.IdempotencyRecord{}, storage.ErrIdempotencyRecordNotFound
+	}
+	return record, err
+}
+
+func (r *IdempotencyRepo) DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) error {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= $1`
+	r.logger.Debugf("DeleteExpiredIdempotencyRecords SQL: %s", query)
+
+	_, err := r.db.ExecContext(ctx, query, now.UTC())
+	return err
+}
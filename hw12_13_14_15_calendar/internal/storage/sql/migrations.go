@@ -0,0 +1,127 @@
+package sqlstorage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	// драйвер postgres для применения миграций.
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/migrations"
+)
+
+// ErrSchemaOutdated схема базы отстает от миграций, встроенных в бинарник, или осталась в незавершенном состоянии.
+var ErrSchemaOutdated = errors.New("database schema is outdated")
+
+// MigrationStatus версия схемы базы и последняя версия встроенных миграций.
+type MigrationStatus struct {
+	Version uint
+	// Dirty отмечает миграцию, которая завершилась ошибкой. Версию нужно исправить вручную и выставить через Force.
+	Dirty  bool
+	Latest uint
+}
+
+// Migrator применяет к базе миграции, встроенные в бинарник.
+type Migrator struct {
+	m      *migrate.Migrate
+	latest uint
+}
+
+func NewMigrator(cfg config.DatabaseConfig) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("on reading embedded migrations: %w", err)
+	}
+	latest, err := latestVersion(src)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, dataSourceName(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("on initializing migrations: %w", err)
+	}
+	return &Migrator{m: m, latest: latest}, nil
+}
+
+// latestVersion возвращает версию последней миграции источника.
+func latestVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("on reading first migration: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("on reading migration after %d: %w", version, err)
+		}
+		version = next
+	}
+}
+
+// Up применяет все еще не примененные миграции.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.m.Up())
+}
+
+// Down откатывает все миграции.
+func (m *Migrator) Down() error {
+	return ignoreNoChange(m.m.Down())
+}
+
+// To применяет или откатывает миграции до версии version.
+func (m *Migrator) To(version uint) error {
+	return ignoreNoChange(m.m.Migrate(version))
+}
+
+// Force выставляет версию схемы и снимает отметку о незавершенной миграции, не применяя миграции.
+// Версия -1 означает, что ни одна миграция не применена.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+func (m *Migrator) Status() (MigrationStatus, error) {
+	status := MigrationStatus{Latest: m.latest}
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, fmt.Errorf("on reading schema version: %w", err)
+	}
+	status.Version = version
+	status.Dirty = dirty
+	return status, nil
+}
+
+// CheckVersion возвращает ErrSchemaOutdated, если схема базы отстает от встроенных миграций.
+// Схема новее миграций допустима: ее мог обновить более новый экземпляр сервиса.
+func (m *Migrator) CheckVersion() error {
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("%w: migration %d is dirty", ErrSchemaOutdated, status.Version)
+	}
+	if status.Version < status.Latest {
+		return fmt.Errorf("%w: version %d, required %d", ErrSchemaOutdated, status.Version, status.Latest)
+	}
+	return nil
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
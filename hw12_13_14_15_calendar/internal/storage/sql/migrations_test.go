@@ -0,0 +1,28 @@
+package sqlstorage
+
+import (
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestVersion(t *testing.T) {
+	files, err := fs.Glob(migrations.FS, "*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	last := files[len(files)-1]
+	expected, err := strconv.ParseUint(last[:strings.Index(last, "_")], 10, 64)
+	require.NoError(t, err)
+
+	src, err := iofs.New(migrations.FS, ".")
+	require.NoError(t, err)
+	latest, err := latestVersion(src)
+	require.NoError(t, err)
+	assert.Equal(t, uint(expected), latest)
+}
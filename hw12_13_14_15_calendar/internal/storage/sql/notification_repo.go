@@ -8,18 +8,22 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
 type NotificationRepo struct {
-	db     *sql.DB
+	db Querier
+	// reader выполняет запросы чтения: реплика, если она настроена, иначе тот же db
+	reader Querier
 	logger logger.Logger
 }
 
-func NewNotificationRepo(db *sql.DB, logger logger.Logger) *NotificationRepo {
+func NewNotificationRepo(db, reader Querier, logger logger.Logger) *NotificationRepo {
 	return &NotificationRepo{
 		db:     db,
+		reader: reader,
 		logger: logger,
 	}
 }
@@ -39,7 +43,7 @@ func (r *NotificationRepo) CreateNotification(
 		id,
 		notification.EventID,
 		notification.UserID,
-		notification.Time,
+		notification.Time.UTC(),
 		notification.Message,
 		notification.Sent,
 	)
@@ -54,35 +58,46 @@ func (r *NotificationRepo) UpdateNotification(
 	id uuid.UUID,
 	notification storage.Notification,
 ) error {
-	query := `UPDATE notifications SET event_id = $2, user_id = $3, time = $4, message = $5, sent = $6 WHERE id = $1`
+	query := `UPDATE notifications SET event_id = $2, user_id = $3, time = $4, message = $5, sent = $6,
+				version = version + 1
+				WHERE id = $1 AND ($7 = 0 OR version = $7)`
 	r.logger.Debugf("UpdateNotification SQL: %s", query)
 
-	_, err := r.db.ExecContext(
+	result, err := r.db.ExecContext(
 		ctx,
 		query,
 		id,
 		notification.EventID,
 		notification.UserID,
-		notification.Time,
+		notification.Time.UTC(),
 		notification.Message,
 		notification.Sent,
+		notification.Version,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	existsQuery := `SELECT EXISTS(SELECT 1 FROM notifications WHERE id=$1)`
+	return checkVersioned(ctx, r.db, result, existsQuery, id, storage.ErrNotificationNotFound)
 }
 
 func (r *NotificationRepo) DeleteNotification(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM notifications WHERE id=$1`
 	r.logger.Debugf("DeleteNotification SQL: %s", query)
 
-	_, err := r.db.ExecContext(ctx, query, id)
-	return err
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result, storage.ErrNotificationNotFound)
 }
 
 func (r *NotificationRepo) GetNotification(ctx context.Context, id uuid.UUID) (storage.Notification, error) {
-	query := `SELECT id, event_id, user_id ,time, message, sent FROM notifications WHERE id=$1`
+	query := `SELECT id, event_id, user_id, time, message, sent, version FROM notifications WHERE id=$1`
 	r.logger.Debugf("GetNotification SQL: %s", query)
 
-	row := r.db.QueryRowContext(ctx, query, id)
+	row := r.reader.QueryRowContext(ctx, query, id)
 	var notification storage.Notification
 
 	err := row.Scan(
@@ -92,6 +107,7 @@ func (r *NotificationRepo) GetNotification(ctx context.Context, id uuid.UUID) (s
 		&notification.Time,
 		&notification.Message,
 		&notification.Sent,
+		&notification.Version,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return storage.Notification{}, storage.ErrNotificationNotFound
@@ -105,14 +121,16 @@ func (r *NotificationRepo) ListNotifications(
 	end time.Time,
 ) ([]storage.Notification, error) {
 	query := `
-	SELECT id, event_id, user_id, time, message, sent 
+	SELECT id, event_id, user_id, time, message, sent, version 
 	FROM notifications 
-	WHERE time >= $1 AND time <= $2 AND sent = 'wait'
+	WHERE time >= $1 AND time <= $2 AND sent = $3
 	`
 
 	r.logger.Debugf("ListNotifications SQL: %s", query)
 
-	rows, err := r.db.QueryContext(ctx, query, start, end)
+	// Выборка идет с основной базы: по ней планировщик ставит уведомления в очередь, и отставание реплики
+	// привело бы к повторной отправке
+	rows, err := r.db.QueryContext(ctx, query, start.UTC(), end.UTC(), dto.NotificationOnWait)
 	if err != nil {
 		return nil, fmt.Errorf("on list notifications: %w", err)
 	}
@@ -133,6 +151,7 @@ func (r *NotificationRepo) ListNotifications(
 			&notification.Time,
 			&notification.Message,
 			&notification.Sent,
+			&notification.Version,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("on scan notifications: %w", err)
@@ -143,10 +162,18 @@ func (r *NotificationRepo) ListNotifications(
 }
 
 func (r *NotificationRepo) DeleteSentNotifications(ctx context.Context) error {
-	query := `DELETE FROM notifications WHERE sent`
-	r.logger.Debugf("DeleteNotification SQL: %s", query)
+	query := `DELETE FROM notifications WHERE sent = $1`
+	r.logger.Debugf("DeleteSentNotifications SQL: %s", query)
+
+	_, err := r.db.ExecContext(ctx, query, dto.NotificationSent)
+
+	return err
+}
 
-	_, err := r.db.ExecContext(ctx, query)
+func (r *NotificationRepo) DeleteEventNotifications(ctx context.Context, eventID uuid.UUID) error {
+	query := `DELETE FROM notifications WHERE event_id=$1`
+	r.logger.Debugf("DeleteEventNotifications SQL: %s", query)
 
+	_, err := r.db.ExecContext(ctx, query, eventID)
 	return err
 }
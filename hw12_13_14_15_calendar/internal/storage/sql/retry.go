@@ -0,0 +1,68 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+// maxRetryInterval ограничивает рост задержки между попытками.
+const maxRetryInterval = 30 * time.Second
+
+// retry выполняет fn, повторяя ее до retries раз, пока она возвращает временную ошибку.
+// Задержка перед первым повтором равна interval и удваивается с каждой попыткой.
+func retry(
+	ctx context.Context,
+	retries int,
+	interval time.Duration,
+	logger logger.Logger,
+	fn func(ctx context.Context) error,
+) error {
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= retries || !isTransient(err) {
+			return err
+		}
+
+		logger.Infof("database is unavailable, retrying in %s (%d/%d): %v", interval, attempt+1, retries, err)
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+		interval = min(interval*2, maxRetryInterval)
+	}
+}
+
+// isTransient проверяет, что ошибка может исчезнуть при повторе: база еще не запущена, перегружена
+// или соединение оборвалось. Ошибки аутентификации и отсутствие базы повторять бесполезно.
+func isTransient(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code.Class() == "08", // connection_exception
+			pqErr.Code == "57P03", // cannot_connect_now: база запускается
+			pqErr.Code == "53300": // too_many_connections
+			return true
+		default:
+			return false
+		}
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, driver.ErrBadConn)
+}
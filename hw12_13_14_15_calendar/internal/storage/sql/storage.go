@@ -3,7 +3,15 @@ package sqlstorage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	// "github.com/lib/pq" подключение драйвера postgres.
 	_ "github.com/lib/pq"
@@ -13,39 +21,188 @@ import (
 )
 
 type SQLStorage struct {
-	db               *sql.DB
+	db *sql.DB
+	// replica подключение к реплике для запросов чтения, nil если реплика не настроена
+	replica *sql.DB
+	// tx транзакция, в которой работают репозитории хранилища, полученного в WithTx
+	tx               *sql.Tx
 	eventRepo        *EventRepo
 	notificationRepo *NotificationRepo
+	calendarRepo     *CalendarRepo
+	idempotencyRepo  *IdempotencyRepo
+	changeFeed       *ChangeFeed
+	pool             config.DatabasePoolConfig
 	logger           logger.Logger
 }
 
+// dataSourceName строка подключения к базе Postgres.
+func dataSourceName(cfg config.DatabaseConfig) string {
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	query := url.Values{"sslmode": []string{sslMode}}
+	for param, value := range map[string]string{
+		"sslrootcert": cfg.SSLRootCert,
+		"sslcert":     cfg.SSLCert,
+		"sslkey":      cfg.SSLKey,
+	} {
+		if value != "" {
+			query.Set(param, value)
+		}
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password.Value()),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// withStatementTimeout добавляет к строке подключения ограничение времени выполнения запросов.
+// Миграции подключаются без него: они могут выполняться дольше обычных запросов.
+func withStatementTimeout(dsn string, timeout int) string {
+	if timeout <= 0 {
+		return dsn
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "statement_timeout=" + strconv.Itoa(timeout)
+}
+
+// replicaDataSourceName дополняет строку подключения к реплике настройками TLS и ограничением времени
+// запросов основной базы. Параметры, заданные в самой строке, не меняются. Строка может быть как URL,
+// так и набором key=value.
+func replicaDataSourceName(cfg config.DatabaseConfig) (string, error) {
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	params := map[string]string{
+		"sslmode":     sslMode,
+		"sslrootcert": cfg.SSLRootCert,
+		"sslcert":     cfg.SSLCert,
+		"sslkey":      cfg.SSLKey,
+	}
+	if cfg.StatementTimeout > 0 {
+		params["statement_timeout"] = strconv.Itoa(cfg.StatementTimeout)
+	}
+	names := make([]string, 0, len(params))
+	for name, value := range params {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	dsn := cfg.ReplicaDSN.Value()
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		replicaURL, err := url.Parse(dsn)
+		if err != nil {
+			// Текст ошибки содержит строку подключения вместе с паролем
+			return "", errors.New("invalid replica DSN")
+		}
+		query := replicaURL.Query()
+		for _, name := range names {
+			if !query.Has(name) {
+				query.Set(name, params[name])
+			}
+		}
+		replicaURL.RawQuery = query.Encode()
+		return replicaURL.String(), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(dsn)
+	for _, name := range names {
+		if regexp.MustCompile(`(^|\s)` + name + `\s*=`).MatchString(dsn) {
+			continue
+		}
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(params[name])
+		fmt.Fprintf(&result, " %s='%s'", name, value)
+	}
+	return result.String(), nil
+}
+
 func New(cfg config.DatabaseConfig, logger logger.Logger) (*SQLStorage, error) {
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=disable",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.Name,
-	)
-	db, err := sql.Open("postgres", dsn)
+	dsn := withStatementTimeout(dataSourceName(cfg), cfg.StatementTimeout)
+	db, err := openDB(dsn, cfg.Pool)
 	if err != nil {
 		return nil, err
 	}
 
+	var reader Querier = db
+	var replica *sql.DB
+	if cfg.ReplicaDSN != "" {
+		var replicaDSN string
+		replicaDSN, err = replicaDataSourceName(cfg)
+		if err == nil {
+			replica, err = openDB(replicaDSN, cfg.Pool)
+		}
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("on opening replica: %w", err)
+		}
+		reader = replica
+	}
+
 	return &SQLStorage{
 		db:               db,
-		eventRepo:        NewEventRepo(db, logger),
-		notificationRepo: NewNotificationRepo(db, logger),
+		replica:          replica,
+		eventRepo:        NewEventRepo(db, reader, logger),
+		notificationRepo: NewNotificationRepo(db, reader, logger),
+		calendarRepo:     NewCalendarRepo(db, reader, logger),
+		idempotencyRepo:  NewIdempotencyRepo(db, logger),
+		changeFeed:       NewChangeFeed(dsn, db, logger),
+		pool:             cfg.Pool,
 		logger:           logger,
 	}, nil
 }
 
+func openDB(dsn string, pool config.DatabasePoolConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(pool.ConnMaxLifetime) * time.Second)
+	db.SetConnMaxIdleTime(time.Duration(pool.ConnMaxIdleTime) * time.Second)
+	return db, nil
+}
+
+// Connect проверяет подключение к базе и реплике. Временные ошибки, например недоступность базы при
+// одновременном запуске с ней, повторяются с экспоненциально растущей задержкой.
 func (s *SQLStorage) Connect(ctx context.Context) error {
-	return s.db.PingContext(ctx)
+	interval := time.Duration(s.pool.ConnectRetryInterval) * time.Millisecond
+	if err := retry(ctx, s.pool.ConnectRetries, interval, s.logger, s.db.PingContext); err != nil {
+		return err
+	}
+	if s.replica != nil {
+		if err := retry(ctx, s.pool.ConnectRetries, interval, s.logger, s.replica.PingContext); err != nil {
+			return fmt.Errorf("on connecting to replica: %w", err)
+		}
+	}
+	return nil
 }
 
 func (s *SQLStorage) Close() error {
+	if s.tx != nil {
+		return storage.ErrInTransaction
+	}
+	if err := s.changeFeed.Close(); err != nil {
+		s.logger.Errorf("on closing change feed: %v", err)
+	}
+	if s.replica != nil {
+		if err := s.replica.Close(); err != nil {
+			s.logger.Errorf("on closing replica: %v", err)
+		}
+	}
 	return s.db.Close()
 }
 
@@ -57,6 +214,26 @@ func (s *SQLStorage) NotificationRepository() storage.NotificationRepository {
 	return s.notificationRepo
 }
 
+func (s *SQLStorage) CalendarRepository() storage.CalendarRepository {
+	return s.calendarRepo
+}
+
+func (s *SQLStorage) IdempotencyRepository() storage.IdempotencyRepository {
+	return s.idempotencyRepo
+}
+
+func (s *SQLStorage) ChangeFeed() storage.ChangeFeed {
+	return s.changeFeed
+}
+
 func (s *SQLStorage) HealthCheck(ctx context.Context) error {
-	return s.db.PingContext(ctx)
+	if err := s.db.PingContext(ctx); err != nil {
+		return err
+	}
+	if s.replica != nil {
+		if err := s.replica.PingContext(ctx); err != nil {
+			return fmt.Errorf("replica: %w", err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,61 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Querier общие методы *sql.DB и *sql.Tx, через которые репозитории выполняют запросы.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// WithTx выполняет fn в транзакции базы данных. Репозитории tx выполняют в этой транзакции все запросы,
+// включая чтение: реплика не видит незафиксированных изменений.
+// Вызов на хранилище транзакции присоединяется к ней: фиксацию и откат выполняет внешний WithTx.
+func (s *SQLStorage) WithTx(ctx context.Context, fn func(tx storage.Storage) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("on begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	txStorage := &SQLStorage{
+		db:               s.db,
+		replica:          s.replica,
+		tx:               tx,
+		eventRepo:        NewEventRepo(tx, tx, s.logger),
+		notificationRepo: NewNotificationRepo(tx, tx, s.logger),
+		calendarRepo:     NewCalendarRepo(tx, tx, s.logger),
+		idempotencyRepo:  NewIdempotencyRepo(tx, s.logger),
+		changeFeed:       s.changeFeed,
+		pool:             s.pool,
+		logger:           s.logger,
+	}
+
+	if err := fn(txStorage); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			s.logger.Errorf("on rollback transaction: %v", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("on commit transaction: %w", err)
+	}
+	return nil
+}
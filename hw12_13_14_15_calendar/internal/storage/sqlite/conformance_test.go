@@ -0,0 +1,14 @@
+package sqlitestorage
+
+import (
+	"testing"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return newTestStorage(t)
+	})
+}
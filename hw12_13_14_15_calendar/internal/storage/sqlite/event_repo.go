@@ -0,0 +1,322 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// eventColumns перечисляет колонки событий в порядке, который ожидает scanEvent.
+const eventColumns = `id, title, description, start_time, end_time, user_id, calendar_id, attendees, version, updated_at, 
+						deleted_at`
+
+// nowUTC текущее время в формате, в котором драйвер записывает time.Time.
+const nowUTC = `strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')`
+
+type EventRepo struct {
+	db        Querier
+	publisher changePublisher
+	logger    logger.Logger
+}
+
+func NewEventRepo(db Querier, publisher changePublisher, logger logger.Logger) *EventRepo {
+	return &EventRepo{
+		db:        db,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+func (r *EventRepo) CreateEvent(ctx context.Context, event storage.Event) (uuid.UUID, error) {
+	query := `INSERT INTO events (id, title, description, start_time, end_time, user_id, calendar_id, attendees) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	r.logger.Debugf("CreateEvent SQL: %s", query)
+
+	attendees, err := encodeAttendees(event.Attendees)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		event.ID,
+		event.Title,
+		event.Description,
+		event.StartTime.UTC(),
+		event.EndTime.UTC(),
+		event.UserID,
+		nullUUID(event.CalendarID),
+		attendees,
+	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return event.ID, r.publish(ctx, storage.ChangeCreated, event.ID)
+}
+
+func (r *EventRepo) UpdateEvent(ctx context.Context, id uuid.UUID, event storage.Event) error {
+	query := `UPDATE events SET title=$1, description=$2, start_time=$3, end_time=$4, user_id=$5, calendar_id=$6, 
+              attendees=$7
+              WHERE id=$8 AND deleted_at IS NULL AND ($9 = 0 OR version = $9)`
+	r.logger.Debugf("UpdateEvent SQL: %s", query)
+
+	attendees, err := encodeAttendees(event.Attendees)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		event.Title,
+		event.Description,
+		event.StartTime.UTC(),
+		event.EndTime.UTC(),
+		event.UserID,
+		nullUUID(event.CalendarID),
+		attendees,
+		id,
+		event.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	existsQuery := `SELECT EXISTS(SELECT 1 FROM events WHERE id=$1 AND deleted_at IS NULL)`
+	if err := checkVersioned(ctx, r.db, result, existsQuery, id, storage.ErrEventNotFound); err != nil {
+		return err
+	}
+	return r.publish(ctx, storage.ChangeUpdated, id)
+}
+
+func (r *EventRepo) DeleteEvent(ctx context.Context, id uuid.UUID) error {
+	// Событие остается в таблице как tombstone для синхронизации
+	query := `UPDATE events SET deleted_at = ` + nowUTC + ` WHERE id=$1 AND deleted_at IS NULL`
+	r.logger.Debugf("DeleteEvent SQL: %s", query)
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result, storage.ErrEventNotFound); err != nil {
+		return err
+	}
+	return r.publish(ctx, storage.ChangeDeleted, id)
+}
+
+func (r *EventRepo) GetEvent(ctx context.Context, id uuid.UUID) (storage.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id=$1 AND deleted_at IS NULL`
+	r.logger.Debugf("GetEvent SQL: %s", query)
+
+	event, err := scanEvent(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	return event, err
+}
+
+func (r *EventRepo) ListEvents(ctx context.Context, start, end time.Time) ([]storage.Event, error) {
+	query := `SELECT ` + eventColumns + ` 
+				FROM events WHERE start_time >= $1 AND end_time <= $2 AND deleted_at IS NULL`
+	r.logger.Debugf("ListEvents SQL: %s", query)
+
+	rows, err := r.db.QueryContext(ctx, query, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return r.scanEvents(rows, "ListEvents")
+}
+
+func (r *EventRepo) ListEventsByCalendars(
+	ctx context.Context,
+	calendarIDs []uuid.UUID,
+	start,
+	end time.Time,
+) ([]storage.Event, error) {
+	query := `SELECT ` + eventColumns + ` 
+				FROM events WHERE calendar_id IN (SELECT value FROM json_each($1)) 
+				AND start_time >= $2 AND end_time <= $3 AND deleted_at IS NULL`
+	r.logger.Debugf("ListEventsByCalendars SQL: %s", query)
+
+	ids, err := json.Marshal(calendarIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, string(ids), start.UTC(), end.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return r.scanEvents(rows, "ListEventsByCalendars")
+}
+
+func (r *EventRepo) SyncEvents(
+	ctx context.Context,
+	userID uuid.UUID,
+	sinceVersion int64,
+	limit int,
+) ([]storage.Event, error) {
+	query := `SELECT ` + eventColumns + ` 
+				FROM events WHERE user_id = $1 AND version > $2 AND ($2 > 0 OR deleted_at IS NULL)
+				ORDER BY version LIMIT $3`
+	r.logger.Debugf("SyncEvents SQL: %s", query)
+
+	// Отрицательный LIMIT в SQLite снимает ограничение
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, userID, sinceVersion, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.scanEvents(rows, "SyncEvents")
+}
+
+func (r *EventRepo) SearchEvents(ctx context.Context, filter storage.EventFilter) ([]storage.Event, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if match := matchQuery(filter.Query); match != "" {
+		addCondition("rowid IN (SELECT rowid FROM events_search WHERE events_search MATCH $%d)", match)
+	}
+	if filter.UserID != uuid.Nil {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+	if filter.CalendarID != uuid.Nil {
+		addCondition("calendar_id = $%d", filter.CalendarID)
+	}
+	if !filter.Start.IsZero() {
+		addCondition("end_time > $%d", filter.Start.UTC())
+	}
+	if !filter.End.IsZero() {
+		addCondition("start_time < $%d", filter.End.UTC())
+	}
+	if filter.Attendee != "" {
+		addCondition("EXISTS (SELECT 1 FROM json_each(attendees) WHERE value = $%d)", filter.Attendee)
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM events WHERE ` + strings.Join(conditions, " AND ") +
+		` ORDER BY start_time, id`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	r.logger.Debugf("SearchEvents SQL: %s", query)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return r.scanEvents(rows, "SearchEvents")
+}
+
+// publish отправляет в ленту изменений текущее состояние события, включая tombstone.
+func (r *EventRepo) publish(ctx context.Context, changeType storage.ChangeType, id uuid.UUID) error {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id=$1`
+
+	event, err := scanEvent(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return fmt.Errorf("on load changed event: %w", err)
+	}
+	r.publisher.Publish(storage.EventChange{Type: changeType, Event: event})
+	return nil
+}
+
+func (r *EventRepo) scanEvents(rows *sql.Rows, method string) ([]storage.Event, error) {
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.logger.Errorf("on closing rows in %s: %v", method, err)
+		}
+	}(rows)
+
+	var events []storage.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func scanEvent(row rowScanner) (storage.Event, error) {
+	var event storage.Event
+	var calendarID uuid.NullUUID
+	var attendees string
+	var deletedAt sql.NullTime
+	err := row.Scan(
+		&event.ID,
+		&event.Title,
+		&event.Description,
+		&event.StartTime,
+		&event.EndTime,
+		&event.UserID,
+		&calendarID,
+		&attendees,
+		&event.Version,
+		&event.UpdatedAt,
+		&deletedAt,
+	)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	if err := json.Unmarshal([]byte(attendees), &event.Attendees); err != nil {
+		return storage.Event{}, fmt.Errorf("on decode attendees: %w", err)
+	}
+	if len(event.Attendees) == 0 {
+		event.Attendees = nil
+	}
+	event.StartTime = event.StartTime.UTC()
+	event.EndTime = event.EndTime.UTC()
+	event.UpdatedAt = event.UpdatedAt.UTC()
+	event.CalendarID = calendarID.UUID
+	event.Deleted = deletedAt.Valid
+	return event, nil
+}
+
+// encodeAttendees сохраняет список участников JSON-массивом, пустой список - как пустой массив.
+func encodeAttendees(attendees []string) (string, error) {
+	if attendees == nil {
+		attendees = []string{}
+	}
+	data, err := json.Marshal(attendees)
+	return string(data), err
+}
+
+// matchQuery превращает поисковый запрос в выражение FTS5, требующее наличия каждого слова.
+// Слова заключаются в кавычки, чтобы операторы FTS5 в запросе пользователя не интерпретировались.
+func matchQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+// nullUUID превращает uuid.Nil в NULL, чтобы не нарушать внешний ключ на calendars.
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
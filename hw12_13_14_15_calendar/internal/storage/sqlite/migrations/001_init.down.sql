@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS events_search_on_update;
+DROP TRIGGER IF EXISTS events_search_on_insert;
+DROP TABLE IF EXISTS events_search;
+DROP TRIGGER IF EXISTS calendars_delete_events;
+DROP TRIGGER IF EXISTS events_version_on_update;
+DROP TRIGGER IF EXISTS events_version_on_insert;
+DROP TABLE IF EXISTS events_version_seq;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS calendars;
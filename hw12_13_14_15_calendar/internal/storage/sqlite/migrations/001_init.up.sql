@@ -0,0 +1,121 @@
+-- Схема SQLite повторяет итоговую схему Postgres из каталога migrations в корне проекта.
+-- UUID хранятся текстом, время - текстом в UTC, поэтому сравнение строк совпадает со сравнением времени.
+CREATE TABLE IF NOT EXISTS calendars
+(
+    id                       TEXT PRIMARY KEY,
+    user_id                  TEXT    NOT NULL,
+    name                     TEXT    NOT NULL,
+    color                    TEXT    NOT NULL DEFAULT '',
+    default_reminder_minutes INTEGER NOT NULL DEFAULT 0,
+    timezone                 TEXT    NOT NULL DEFAULT 'UTC'
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendars_user_id ON calendars (user_id);
+
+CREATE TABLE IF NOT EXISTS events
+(
+    id          TEXT PRIMARY KEY,
+    title       TEXT      NOT NULL,
+    description TEXT      NOT NULL DEFAULT '',
+    start_time  TIMESTAMP NOT NULL,
+    end_time    TIMESTAMP NOT NULL,
+    user_id     TEXT      NOT NULL,
+    calendar_id TEXT REFERENCES calendars (id) ON DELETE SET NULL,
+    -- attendees JSON-массив адресов участников
+    attendees   TEXT      NOT NULL DEFAULT '[]',
+    version     INTEGER   NOT NULL DEFAULT 0,
+    updated_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    deleted_at  TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_start_time ON events (start_time);
+CREATE INDEX IF NOT EXISTS idx_events_end_time ON events (end_time);
+CREATE INDEX IF NOT EXISTS idx_events_calendar_id_start_time ON events (calendar_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_events_user_id_version ON events (user_id, version);
+
+CREATE TABLE IF NOT EXISTS notifications
+(
+    id       TEXT PRIMARY KEY,
+    event_id TEXT REFERENCES events (id) ON DELETE CASCADE,
+    user_id  TEXT      NOT NULL,
+    time     TIMESTAMP NOT NULL,
+    message  TEXT      NOT NULL,
+    sent     TEXT      NOT NULL DEFAULT 'wait',
+    version  INTEGER   NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_time ON notifications (time);
+CREATE INDEX IF NOT EXISTS idx_notifications_event_id_time ON notifications (event_id, time);
+
+-- В SQLite нет последовательностей, последняя выданная версия событий хранится в отдельной таблице
+CREATE TABLE IF NOT EXISTS events_version_seq
+(
+    value INTEGER NOT NULL
+);
+
+INSERT INTO events_version_seq (value)
+VALUES (0);
+
+-- Любое изменение строки получает новую версию
+CREATE TRIGGER IF NOT EXISTS events_version_on_insert
+    AFTER INSERT
+    ON events
+    FOR EACH ROW
+BEGIN
+    UPDATE events_version_seq SET value = value + 1;
+    UPDATE events SET version = (SELECT value FROM events_version_seq) WHERE rowid = NEW.rowid;
+END;
+
+CREATE TRIGGER IF NOT EXISTS events_version_on_update
+    AFTER UPDATE OF title, description, start_time, end_time, user_id, calendar_id, attendees, deleted_at
+    ON events
+    FOR EACH ROW
+BEGIN
+    UPDATE events_version_seq SET value = value + 1;
+    UPDATE events
+    SET version    = (SELECT value FROM events_version_seq),
+        updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+    WHERE rowid = NEW.rowid;
+END;
+
+-- События удаляемого календаря превращаются в tombstone, их уведомления удаляются
+CREATE TRIGGER IF NOT EXISTS calendars_delete_events
+    BEFORE DELETE
+    ON calendars
+    FOR EACH ROW
+BEGIN
+    UPDATE events
+    SET deleted_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+    WHERE calendar_id = OLD.id
+      AND deleted_at IS NULL;
+    DELETE FROM notifications WHERE event_id IN (SELECT id FROM events WHERE calendar_id = OLD.id);
+END;
+
+-- Полнотекстовый индекс названия и описания. unicode61 приводит слова к нижнему регистру без стемминга,
+-- как конфигурация simple в Postgres
+CREATE VIRTUAL TABLE IF NOT EXISTS events_search USING fts5
+(
+    title,
+    description,
+    content = 'events',
+    content_rowid = 'rowid',
+    tokenize = 'unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS events_search_on_insert
+    AFTER INSERT
+    ON events
+    FOR EACH ROW
+BEGIN
+    INSERT INTO events_search (rowid, title, description) VALUES (NEW.rowid, NEW.title, NEW.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS events_search_on_update
+    AFTER UPDATE OF title, description
+    ON events
+    FOR EACH ROW
+BEGIN
+    INSERT INTO events_search (events_search, rowid, title, description)
+    VALUES ('delete', OLD.rowid, OLD.title, OLD.description);
+    INSERT INTO events_search (rowid, title, description) VALUES (NEW.rowid, NEW.title, NEW.description);
+END;
@@ -0,0 +1,13 @@
+-- Ключ идемпотентности действует в пределах пользователя и операции
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    user_id      TEXT      NOT NULL,
+    operation    TEXT      NOT NULL,
+    key          TEXT      NOT NULL,
+    request_hash TEXT      NOT NULL,
+    response     BLOB      NOT NULL,
+    expires_at   TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, operation, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,167 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// notificationColumns перечисляет колонки уведомлений в порядке, который ожидает scanNotification.
+const notificationColumns = `id, event_id, user_id, time, message, sent, version`
+
+type NotificationRepo struct {
+	db     Querier
+	logger logger.Logger
+}
+
+func NewNotificationRepo(db Querier, logger logger.Logger) *NotificationRepo {
+	return &NotificationRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *NotificationRepo) CreateNotification(
+	ctx context.Context,
+	notification storage.Notification,
+) (uuid.UUID, error) {
+	id := uuid.New()
+	query := `INSERT INTO notifications (id, event_id, user_id, time, message, sent) 
+              VALUES ($1, $2, $3, $4, $5, $6)`
+	r.logger.Debugf("CreateNotification SQL: %s", query)
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		id,
+		notification.EventID,
+		notification.UserID,
+		notification.Time.UTC(),
+		notification.Message,
+		notification.Sent,
+	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func (r *NotificationRepo) UpdateNotification(
+	ctx context.Context,
+	id uuid.UUID,
+	notification storage.Notification,
+) error {
+	query := `UPDATE notifications SET event_id = $2, user_id = $3, time = $4, message = $5, sent = $6,
+				version = version + 1
+				WHERE id = $1 AND ($7 = 0 OR version = $7)`
+	r.logger.Debugf("UpdateNotification SQL: %s", query)
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		id,
+		notification.EventID,
+		notification.UserID,
+		notification.Time.UTC(),
+		notification.Message,
+		notification.Sent,
+		notification.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	existsQuery := `SELECT EXISTS(SELECT 1 FROM notifications WHERE id=$1)`
+	return checkVersioned(ctx, r.db, result, existsQuery, id, storage.ErrNotificationNotFound)
+}
+
+func (r *NotificationRepo) DeleteNotification(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM notifications WHERE id=$1`
+	r.logger.Debugf("DeleteNotification SQL: %s", query)
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result, storage.ErrNotificationNotFound)
+}
+
+func (r *NotificationRepo) GetNotification(ctx context.Context, id uuid.UUID) (storage.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE id=$1`
+	r.logger.Debugf("GetNotification SQL: %s", query)
+
+	notification, err := scanNotification(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Notification{}, storage.ErrNotificationNotFound
+	}
+	return notification, err
+}
+
+func (r *NotificationRepo) ListNotifications(
+	ctx context.Context,
+	start time.Time,
+	end time.Time,
+) ([]storage.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` 
+				FROM notifications WHERE time >= $1 AND time <= $2 AND sent = $3`
+	r.logger.Debugf("ListNotifications SQL: %s", query)
+
+	rows, err := r.db.QueryContext(ctx, query, start.UTC(), end.UTC(), dto.NotificationOnWait)
+	if err != nil {
+		return nil, fmt.Errorf("on list notifications: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.logger.Errorf("on closing rows in ListNotifications: %v", err)
+		}
+	}(rows)
+
+	var notifications []storage.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("on scan notifications: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *NotificationRepo) DeleteSentNotifications(ctx context.Context) error {
+	query := `DELETE FROM notifications WHERE sent = $1`
+	r.logger.Debugf("DeleteSentNotifications SQL: %s", query)
+
+	_, err := r.db.ExecContext(ctx, query, dto.NotificationSent)
+	return err
+}
+
+func (r *NotificationRepo) DeleteEventNotifications(ctx context.Context, eventID uuid.UUID) error {
+	query := `DELETE FROM notifications WHERE event_id=$1`
+	r.logger.Debugf("DeleteEventNotifications SQL: %s", query)
+
+	_, err := r.db.ExecContext(ctx, query, eventID)
+	return err
+}
+
+func scanNotification(row rowScanner) (storage.Notification, error) {
+	var notification storage.Notification
+	err := row.Scan(
+		&notification.ID,
+		&notification.EventID,
+		&notification.UserID,
+		&notification.Time,
+		&notification.Message,
+		&notification.Sent,
+		&notification.Version,
+	)
+	notification.Time = notification.Time.UTC()
+	return notification, err
+}
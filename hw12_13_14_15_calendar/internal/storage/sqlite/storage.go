@@ -0,0 +1,118 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	// "modernc.org/sqlite" подключение драйвера sqlite без cgo.
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// SQLiteStorage хранилище в файле SQLite для разработки и установок на одном узле.
+// Лента изменений работает внутри процесса: изменения, сделанные другими процессами, в нее не попадают.
+type SQLiteStorage struct {
+	db *sql.DB
+	// tx транзакция, в которой работают репозитории хранилища, полученного в WithTx
+	tx               *sql.Tx
+	eventRepo        *EventRepo
+	notificationRepo *NotificationRepo
+	calendarRepo     *CalendarRepo
+	idempotencyRepo  *IdempotencyRepo
+	broker           *storage.Broker
+	logger           logger.Logger
+}
+
+// New открывает базу в файле cfg.Path. Путь ":memory:" создает базу в памяти.
+func New(cfg config.DatabaseConfig, logger logger.Logger) (*SQLiteStorage, error) {
+	dsn := "file:" + cfg.Path +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite допускает одного писателя, а база в памяти существует только в рамках соединения
+	db.SetMaxOpenConns(1)
+
+	broker := storage.NewBroker()
+	return &SQLiteStorage{
+		db:               db,
+		eventRepo:        NewEventRepo(db, broker, logger),
+		notificationRepo: NewNotificationRepo(db, logger),
+		calendarRepo:     NewCalendarRepo(db, broker, logger),
+		idempotencyRepo:  NewIdempotencyRepo(db, logger),
+		broker:           broker,
+		logger:           logger,
+	}, nil
+}
+
+// Connect проверяет доступность базы и применяет встроенные миграции.
+func (s *SQLiteStorage) Connect(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return err
+	}
+	return s.migrate()
+}
+
+func (s *SQLiteStorage) migrate() error {
+	source, err := iofs.New(migrations, "migrations")
+	if err != nil {
+		return fmt.Errorf("on opening migrations: %w", err)
+	}
+	driver, err := migratesqlite.WithInstance(s.db, &migratesqlite.Config{})
+	if err != nil {
+		return fmt.Errorf("on creating migration driver: %w", err)
+	}
+	// Закрытие migrate закрыло бы и s.db, поэтому экземпляр не закрывается
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	if err != nil {
+		return fmt.Errorf("on creating migrate instance: %w", err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("on applying migrations: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) Close() error {
+	if s.tx != nil {
+		return storage.ErrInTransaction
+	}
+	s.broker.Close()
+	return s.db.Close()
+}
+
+func (s *SQLiteStorage) EventRepository() storage.EventRepository {
+	return s.eventRepo
+}
+
+func (s *SQLiteStorage) NotificationRepository() storage.NotificationRepository {
+	return s.notificationRepo
+}
+
+func (s *SQLiteStorage) CalendarRepository() storage.CalendarRepository {
+	return s.calendarRepo
+}
+
+func (s *SQLiteStorage) IdempotencyRepository() storage.IdempotencyRepository {
+	return s.idempotencyRepo
+}
+
+func (s *SQLiteStorage) ChangeFeed() storage.ChangeFeed {
+	return s.broker
+}
+
+func (s *SQLiteStorage) HealthCheck(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
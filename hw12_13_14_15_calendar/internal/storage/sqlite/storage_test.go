@@ -0,0 +1,318 @@
+package sqlitestorage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStorage(t *testing.T) *SQLiteStorage {
+	t.Helper()
+
+	logInstance, err := logger.New(config.LoggerConfig{
+		Level:            "fatal",
+		Encoding:         "json",
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	})
+	require.NoError(t, err)
+
+	store, err := New(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "calendar.db")}, logInstance)
+	require.NoError(t, err)
+	require.NoError(t, store.Connect(context.Background()))
+	t.Cleanup(func() {
+		assert.NoError(t, store.Close())
+	})
+	return store
+}
+
+func TestSQLiteStorage_Connect(t *testing.T) {
+	store := newTestStorage(t)
+
+	// Повторное подключение не применяет миграции заново
+	assert.NoError(t, store.Connect(context.Background()))
+	assert.NoError(t, store.HealthCheck(context.Background()))
+}
+
+func TestEventRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestStorage(t).EventRepository()
+
+	start := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	event := storage.Event{
+		ID:          uuid.New(),
+		Title:       "Sprint planning",
+		Description: "Обсуждение задач",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		UserID:      uuid.New(),
+		Attendees:   []string{"alice@example.com"},
+	}
+	id, err := repo.CreateEvent(ctx, event)
+	require.NoError(t, err)
+
+	created, err := repo.GetEvent(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, event.Title, created.Title)
+	assert.Equal(t, event.StartTime, created.StartTime)
+	assert.Equal(t, event.Attendees, created.Attendees)
+	assert.Positive(t, created.Version)
+
+	event.Title = "Sprint review"
+	event.Version = created.Version
+	require.NoError(t, repo.UpdateEvent(ctx, id, event))
+	assert.ErrorIs(t, repo.UpdateEvent(ctx, id, event), storage.ErrVersionConflict)
+	assert.ErrorIs(t, repo.UpdateEvent(ctx, uuid.New(), event), storage.ErrEventNotFound)
+
+	// Границы периода включаются в выборку
+	events, err := repo.ListEvents(ctx, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Sprint review", events[0].Title)
+	assert.Greater(t, events[0].Version, created.Version)
+
+	require.NoError(t, repo.DeleteEvent(ctx, id))
+	_, err = repo.GetEvent(ctx, id)
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+	assert.ErrorIs(t, repo.DeleteEvent(ctx, id), storage.ErrEventNotFound)
+}
+
+func TestEventRepo_SyncEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestStorage(t).EventRepository()
+
+	userID := uuid.New()
+	newEvent := func() storage.Event {
+		return storage.Event{ID: uuid.New(), StartTime: time.Now(), EndTime: time.Now(), UserID: userID}
+	}
+	kept, _ := repo.CreateEvent(ctx, newEvent())
+	deleted, _ := repo.CreateEvent(ctx, newEvent())
+
+	initial, err := repo.SyncEvents(ctx, userID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, initial, 2)
+	since := initial[1].Version
+
+	require.NoError(t, repo.DeleteEvent(ctx, deleted))
+	require.NoError(t, repo.UpdateEvent(ctx, kept, newEvent()))
+
+	changes, err := repo.SyncEvents(ctx, userID, since, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, deleted, changes[0].ID)
+	assert.True(t, changes[0].Deleted)
+	assert.Equal(t, kept, changes[1].ID)
+
+	page, err := repo.SyncEvents(ctx, userID, since, 1)
+	require.NoError(t, err)
+	assert.Len(t, page, 1)
+}
+
+func TestEventRepo_SearchEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestStorage(t).EventRepository()
+
+	userID := uuid.New()
+	start := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	planning, _ := repo.CreateEvent(ctx, storage.Event{
+		ID:          uuid.New(),
+		Title:       "Sprint planning",
+		Description: "Обсуждение задач на спринт",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		UserID:      userID,
+		Attendees:   []string{"alice@example.com", "bob@example.com"},
+	})
+	review, _ := repo.CreateEvent(ctx, storage.Event{
+		ID:        uuid.New(),
+		Title:     "Sprint review",
+		StartTime: start.Add(24 * time.Hour),
+		EndTime:   start.Add(25 * time.Hour),
+		UserID:    userID,
+	})
+
+	tests := []struct {
+		name     string
+		filter   storage.EventFilter
+		expected []uuid.UUID
+	}{
+		{"query", storage.EventFilter{Query: "SPRINT", UserID: userID}, []uuid.UUID{planning, review}},
+		{"all words", storage.EventFilter{Query: "sprint planning"}, []uuid.UUID{planning}},
+		{"description", storage.EventFilter{Query: "ЗАДАЧ"}, []uuid.UUID{planning}},
+		{"operators", storage.EventFilter{Query: `review OR "planning`}, nil},
+		{"attendee", storage.EventFilter{Attendee: "bob@example.com"}, []uuid.UUID{planning}},
+		{"period", storage.EventFilter{Start: start.Add(2 * time.Hour), End: start.Add(48 * time.Hour)}, []uuid.UUID{review}},
+		{"limit", storage.EventFilter{UserID: userID, Limit: 1}, []uuid.UUID{planning}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := repo.SearchEvents(ctx, tt.filter)
+			require.NoError(t, err)
+
+			var ids []uuid.UUID
+			for _, event := range events {
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+
+	// Индекс следует за изменением названия
+	renamed, _ := repo.GetEvent(ctx, review)
+	renamed.Title = "Demo"
+	require.NoError(t, repo.UpdateEvent(ctx, review, renamed))
+
+	events, err := repo.SearchEvents(ctx, storage.EventFilter{Query: "review"})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	events, err = repo.SearchEvents(ctx, storage.EventFilter{Query: "demo"})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestCalendarRepo_DeleteCalendar(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStorage(t)
+
+	userID := uuid.New()
+	changes, err := store.ChangeFeed().Subscribe(ctx, userID)
+	require.NoError(t, err)
+
+	calendarID, err := store.CalendarRepository().CreateCalendar(ctx, storage.Calendar{
+		UserID:          userID,
+		Name:            "Work",
+		DefaultReminder: 15 * time.Minute,
+		Timezone:        "Europe/Moscow",
+	})
+	require.NoError(t, err)
+
+	calendar, err := store.CalendarRepository().GetCalendar(ctx, calendarID)
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, calendar.DefaultReminder)
+
+	eventID, err := store.EventRepository().CreateEvent(ctx, storage.Event{
+		ID:         uuid.New(),
+		StartTime:  time.Now(),
+		EndTime:    time.Now(),
+		UserID:     userID,
+		CalendarID: calendarID,
+	})
+	require.NoError(t, err)
+	notificationID, err := store.NotificationRepository().CreateNotification(ctx, storage.Notification{
+		EventID: eventID,
+		UserID:  userID,
+		Time:    time.Now(),
+		Sent:    dto.NotificationOnWait,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.CalendarRepository().DeleteCalendar(ctx, calendarID))
+	assert.ErrorIs(t, store.CalendarRepository().DeleteCalendar(ctx, calendarID), storage.ErrCalendarNotFound)
+
+	_, err = store.EventRepository().GetEvent(ctx, eventID)
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+	_, err = store.NotificationRepository().GetNotification(ctx, notificationID)
+	assert.ErrorIs(t, err, storage.ErrNotificationNotFound)
+
+	for _, expected := range []storage.ChangeType{storage.ChangeCreated, storage.ChangeDeleted} {
+		change := <-changes
+		assert.Equal(t, expected, change.Type)
+		assert.Equal(t, eventID, change.Event.ID)
+	}
+}
+
+func TestNotificationRepo(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStorage(t)
+	repo := store.NotificationRepository()
+
+	eventID, err := store.EventRepository().CreateEvent(ctx, storage.Event{
+		ID:        uuid.New(),
+		StartTime: time.Now(),
+		EndTime:   time.Now(),
+		UserID:    uuid.New(),
+	})
+	require.NoError(t, err)
+
+	at := time.Date(2024, 7, 1, 9, 45, 0, 0, time.UTC)
+	notification := storage.Notification{EventID: eventID, Time: at, Message: "Soon", Sent: dto.NotificationOnWait}
+	waiting, err := repo.CreateNotification(ctx, notification)
+	require.NoError(t, err)
+	notification.Sent = dto.NotificationSent
+	sent, err := repo.CreateNotification(ctx, notification)
+	require.NoError(t, err)
+
+	stored, err := repo.GetNotification(ctx, waiting)
+	require.NoError(t, err)
+	assert.Equal(t, at, stored.Time)
+	assert.Equal(t, int64(1), stored.Version)
+
+	stored.Message = "Now"
+	require.NoError(t, repo.UpdateNotification(ctx, waiting, stored))
+	assert.ErrorIs(t, repo.UpdateNotification(ctx, waiting, stored), storage.ErrVersionConflict)
+
+	// В выборку попадают только ожидающие отправки уведомления
+	notifications, err := repo.ListNotifications(ctx, at, at)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, waiting, notifications[0].ID)
+
+	require.NoError(t, repo.DeleteSentNotifications(ctx))
+	_, err = repo.GetNotification(ctx, sent)
+	assert.ErrorIs(t, err, storage.ErrNotificationNotFound)
+
+	require.NoError(t, repo.DeleteEventNotifications(ctx, eventID))
+	assert.ErrorIs(t, repo.DeleteNotification(ctx, waiting), storage.ErrNotificationNotFound)
+}
+
+func TestSQLiteStorage_WithTx(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStorage(t)
+
+	userID := uuid.New()
+	changes, err := store.ChangeFeed().Subscribe(ctx, userID)
+	require.NoError(t, err)
+
+	errFailed := errors.New("failed")
+	var id uuid.UUID
+	err = store.WithTx(ctx, func(tx storage.Storage) error {
+		id, err = tx.EventRepository().CreateEvent(ctx, storage.Event{
+			ID:        uuid.New(),
+			StartTime: time.Now(),
+			EndTime:   time.Now(),
+			UserID:    userID,
+		})
+		require.NoError(t, err)
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+
+	_, err = store.EventRepository().GetEvent(ctx, id)
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+
+	err = store.WithTx(ctx, func(tx storage.Storage) error {
+		id, err = tx.EventRepository().CreateEvent(ctx, storage.Event{
+			ID:        uuid.New(),
+			StartTime: time.Now(),
+			EndTime:   time.Now(),
+			UserID:    userID,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	// Изменения отмененной транзакции не рассылаются
+	change := <-changes
+	assert.Equal(t, id, change.Event.ID)
+}
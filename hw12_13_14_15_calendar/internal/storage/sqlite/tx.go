@@ -0,0 +1,78 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Querier общие методы *sql.DB и *sql.Tx, через которые репозитории выполняют запросы.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// changePublisher принимает изменения событий для ленты изменений.
+type changePublisher interface {
+	Publish(change storage.EventChange)
+}
+
+// pendingChanges копит изменения транзакции, чтобы разослать их только после фиксации.
+type pendingChanges struct {
+	changes []storage.EventChange
+}
+
+func (p *pendingChanges) Publish(change storage.EventChange) {
+	p.changes = append(p.changes, change)
+}
+
+// WithTx выполняет fn в транзакции базы данных. Репозитории tx выполняют запросы в этой транзакции,
+// изменения событий рассылаются подписчикам после фиксации.
+// Вызов на хранилище транзакции присоединяется к ней: фиксацию и откат выполняет внешний WithTx.
+func (s *SQLiteStorage) WithTx(ctx context.Context, fn func(tx storage.Storage) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("on begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	pending := &pendingChanges{}
+	txStorage := &SQLiteStorage{
+		db:               s.db,
+		tx:               tx,
+		eventRepo:        NewEventRepo(tx, pending, s.logger),
+		notificationRepo: NewNotificationRepo(tx, s.logger),
+		calendarRepo:     NewCalendarRepo(tx, pending, s.logger),
+		idempotencyRepo:  NewIdempotencyRepo(tx, s.logger),
+		broker:           s.broker,
+		logger:           s.logger,
+	}
+
+	if err := fn(txStorage); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			s.logger.Errorf("on rollback transaction: %v", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("on commit transaction: %w", err)
+	}
+
+	for _, change := range pending.changes {
+		s.broker.Publish(change)
+	}
+	return nil
+}
@@ -8,4 +8,12 @@ type Storage interface {
 	HealthCheck(ctx context.Context) error
 	EventRepository() EventRepository
 	NotificationRepository() NotificationRepository
+	CalendarRepository() CalendarRepository
+	IdempotencyRepository() IdempotencyRepository
+	ChangeFeed() ChangeFeed
+	// WithTx выполняет fn в транзакции: изменения, сделанные через tx, фиксируются вместе,
+	// если fn вернула nil, и отменяются, если fn вернула ошибку или запаниковала.
+	// Внутри fn нужно обращаться только к tx, а не к исходному хранилищу. Вызов WithTx на tx
+	// присоединяется к уже открытой транзакции.
+	WithTx(ctx context.Context, fn func(tx Storage) error) error
 }
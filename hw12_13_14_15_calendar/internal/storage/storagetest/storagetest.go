@@ -0,0 +1,659 @@
+// Package storagetest содержит набор тестов контракта storage.Storage, который должна проходить
+// каждая реализация хранилища.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/dto"
+	"github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewStorage возвращает подключенное хранилище для теста.
+type NewStorage func(t *testing.T) storage.Storage
+
+// Run запускает набор тестов контракта. Тесты не рассчитывают на пустое хранилище: данные каждого теста
+// отделены собственными пользователями и периодом, поэтому newStorage может возвращать общую базу.
+func Run(t *testing.T, newStorage NewStorage) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, store storage.Storage)
+	}{
+		{"CreateEvent", testCreateEvent},
+		{"UpdateEvent", testUpdateEvent},
+		{"DeleteEvent", testDeleteEvent},
+		{"ListEvents", testListEvents},
+		{"ListEventsByCalendars", testListEventsByCalendars},
+		{"SyncEvents", testSyncEvents},
+		{"SearchEvents", testSearchEvents},
+		{"Notifications", testNotifications},
+		{"ListNotifications", testListNotifications},
+		{"DeleteNotifications", testDeleteNotifications},
+		{"Calendars", testCalendars},
+		{"DeleteCalendar", testDeleteCalendar},
+		{"Idempotency", testIdempotency},
+		{"WithTx", testWithTx},
+		{"ChangeFeed", testChangeFeed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+// basePeriod возвращает начало периода, в котором тест создает события и уведомления.
+// Период выбирается случайно далеко в будущем, чтобы не пересекаться с данными других тестов.
+func basePeriod() time.Time {
+	//nolint:gosec
+	return time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(rand.Intn(1_000_000)) * time.Hour)
+}
+
+func newEvent(userID uuid.UUID, start time.Time) storage.Event {
+	return storage.Event{
+		Title:       "Event",
+		Description: "Description",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		UserID:      userID,
+	}
+}
+
+func createEvent(t *testing.T, store storage.Storage, event storage.Event) uuid.UUID {
+	t.Helper()
+	id, err := store.EventRepository().CreateEvent(context.Background(), event)
+	require.NoError(t, err)
+	return id
+}
+
+func eventIDs(events []storage.Event) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func testCreateEvent(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	repo := store.EventRepository()
+
+	event := newEvent(uuid.New(), basePeriod())
+	event.Attendees = []string{"alice@example.com", "bob@example.com"}
+	id := createEvent(t, store, event)
+	assert.NotEqual(t, uuid.Nil, id)
+
+	stored, err := repo.GetEvent(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, stored.ID)
+	assert.Equal(t, event.Title, stored.Title)
+	assert.Equal(t, event.Description, stored.Description)
+	assert.WithinDuration(t, event.StartTime, stored.StartTime, 0)
+	assert.WithinDuration(t, event.EndTime, stored.EndTime, 0)
+	assert.Equal(t, event.UserID, stored.UserID)
+	assert.Equal(t, uuid.Nil, stored.CalendarID)
+	assert.Equal(t, event.Attendees, stored.Attendees)
+	assert.Positive(t, stored.Version)
+	assert.False(t, stored.Deleted)
+
+	// Заданный идентификатор сохраняется
+	event.ID = uuid.New()
+	assert.Equal(t, event.ID, createEvent(t, store, event))
+
+	withoutAttendees, err := repo.GetEvent(ctx, createEvent(t, store, newEvent(uuid.New(), basePeriod())))
+	require.NoError(t, err)
+	assert.Empty(t, withoutAttendees.Attendees)
+
+	_, err = repo.GetEvent(ctx, uuid.New())
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+}
+
+func testUpdateEvent(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	repo := store.EventRepository()
+
+	event := newEvent(uuid.New(), basePeriod())
+	id := createEvent(t, store, event)
+	created, err := repo.GetEvent(ctx, id)
+	require.NoError(t, err)
+
+	event.Title = "Updated"
+	event.Version = created.Version
+	require.NoError(t, repo.UpdateEvent(ctx, id, event))
+
+	updated, err := repo.GetEvent(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", updated.Title)
+	assert.Greater(t, updated.Version, created.Version)
+
+	// Устаревшая версия не перезаписывает изменения, нулевая версия обновляет без проверки
+	event.Title = "Stale"
+	assert.ErrorIs(t, repo.UpdateEvent(ctx, id, event), storage.ErrVersionConflict)
+	event.Version = 0
+	require.NoError(t, repo.UpdateEvent(ctx, id, event))
+
+	assert.ErrorIs(t, repo.UpdateEvent(ctx, uuid.New(), event), storage.ErrEventNotFound)
+}
+
+func testDeleteEvent(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	repo := store.EventRepository()
+
+	event := newEvent(uuid.New(), basePeriod())
+	id := createEvent(t, store, event)
+	require.NoError(t, repo.DeleteEvent(ctx, id))
+
+	_, err := repo.GetEvent(ctx, id)
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+	assert.ErrorIs(t, repo.DeleteEvent(ctx, id), storage.ErrEventNotFound)
+	assert.ErrorIs(t, repo.UpdateEvent(ctx, id, event), storage.ErrEventNotFound)
+
+	events, err := repo.ListEvents(ctx, event.StartTime, event.EndTime)
+	require.NoError(t, err)
+	assert.NotContains(t, eventIDs(events), id)
+}
+
+func testListEvents(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	start := basePeriod()
+	userID := uuid.New()
+
+	// Период включает свои границы
+	atBounds := createEvent(t, store, storage.Event{UserID: userID, StartTime: start, EndTime: start.Add(time.Hour)})
+	inside := createEvent(t, store, newEvent(userID, start.Add(time.Minute)))
+	createEvent(t, store, newEvent(userID, start.Add(-time.Minute)))
+	createEvent(t, store, newEvent(userID, start.Add(time.Hour)))
+
+	events, err := store.EventRepository().ListEvents(ctx, start, start.Add(time.Hour+time.Minute))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{atBounds, inside}, eventIDs(events))
+}
+
+func testListEventsByCalendars(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	start := basePeriod()
+	userID := uuid.New()
+
+	var calendarIDs []uuid.UUID
+	var expected []uuid.UUID
+	for _, name := range []string{"Work", "Personal", "Other"} {
+		calendarID, err := store.CalendarRepository().CreateCalendar(ctx, storage.Calendar{UserID: userID, Name: name})
+		require.NoError(t, err)
+		calendarIDs = append(calendarIDs, calendarID)
+
+		event := newEvent(userID, start)
+		event.CalendarID = calendarID
+		expected = append(expected, createEvent(t, store, event))
+	}
+	createEvent(t, store, newEvent(userID, start))
+
+	events, err := store.EventRepository().ListEventsByCalendars(ctx, calendarIDs[:2], start, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected[:2], eventIDs(events))
+	for _, event := range events {
+		assert.Contains(t, calendarIDs[:2], event.CalendarID)
+	}
+
+	events, err = store.EventRepository().ListEventsByCalendars(ctx, nil, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func testSyncEvents(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	repo := store.EventRepository()
+	userID := uuid.New()
+
+	event := newEvent(userID, basePeriod())
+	kept := createEvent(t, store, event)
+	deleted := createEvent(t, store, event)
+	createEvent(t, store, newEvent(uuid.New(), event.StartTime))
+
+	initial, err := repo.SyncEvents(ctx, userID, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{kept, deleted}, eventIDs(initial))
+	assert.Less(t, initial[0].Version, initial[1].Version)
+	since := initial[1].Version
+
+	require.NoError(t, repo.DeleteEvent(ctx, deleted))
+	require.NoError(t, repo.UpdateEvent(ctx, kept, event))
+
+	changes, err := repo.SyncEvents(ctx, userID, since, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{deleted, kept}, eventIDs(changes))
+	assert.True(t, changes[0].Deleted)
+	assert.False(t, changes[1].Deleted)
+
+	page, err := repo.SyncEvents(ctx, userID, since, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{deleted}, eventIDs(page))
+
+	// Полная синхронизация не возвращает tombstone
+	full, err := repo.SyncEvents(ctx, userID, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{kept}, eventIDs(full))
+}
+
+func testSearchEvents(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	repo := store.EventRepository()
+
+	userID := uuid.New()
+	start := basePeriod()
+	planning := createEvent(t, store, storage.Event{
+		Title:       "Sprint planning",
+		Description: "Обсуждение задач на спринт",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		UserID:      userID,
+		Attendees:   []string{"alice@example.com", "bob@example.com"},
+	})
+	review := createEvent(t, store, storage.Event{
+		Title:     "Sprint review",
+		StartTime: start.Add(24 * time.Hour),
+		EndTime:   start.Add(25 * time.Hour),
+		UserID:    userID,
+		Attendees: []string{"alice@example.com"},
+	})
+	deleted := createEvent(t, store, storage.Event{
+		Title:     "Sprint retro",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		UserID:    userID,
+	})
+	require.NoError(t, repo.DeleteEvent(ctx, deleted))
+
+	tests := []struct {
+		name     string
+		filter   storage.EventFilter
+		expected []uuid.UUID
+	}{
+		{"query", storage.EventFilter{Query: "SPRINT"}, []uuid.UUID{planning, review}},
+		{"all words", storage.EventFilter{Query: "sprint planning"}, []uuid.UUID{planning}},
+		{"description", storage.EventFilter{Query: "задач"}, []uuid.UUID{planning}},
+		{"attendee", storage.EventFilter{Attendee: "bob@example.com"}, []uuid.UUID{planning}},
+		{"period", storage.EventFilter{Start: start.Add(time.Hour), End: start.Add(48 * time.Hour)}, []uuid.UUID{review}},
+		{"limit", storage.EventFilter{Limit: 1}, []uuid.UUID{planning}},
+		{"no match", storage.EventFilter{Query: "demo"}, []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			filter.UserID = userID
+			events, err := repo.SearchEvents(ctx, filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, eventIDs(events))
+		})
+	}
+}
+
+func testNotifications(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	repo := store.NotificationRepository()
+
+	event := newEvent(uuid.New(), basePeriod())
+	eventID := createEvent(t, store, event)
+
+	notification := storage.Notification{
+		EventID: eventID,
+		UserID:  event.UserID,
+		Time:    event.StartTime.Add(-15 * time.Minute),
+		Message: "Soon",
+		Sent:    dto.NotificationOnWait,
+	}
+	id, err := repo.CreateNotification(ctx, notification)
+	require.NoError(t, err)
+
+	stored, err := repo.GetNotification(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, stored.ID)
+	assert.Equal(t, eventID, stored.EventID)
+	assert.Equal(t, event.UserID, stored.UserID)
+	assert.WithinDuration(t, notification.Time, stored.Time, 0)
+	assert.Equal(t, notification.Message, stored.Message)
+	assert.Equal(t, dto.NotificationOnWait, stored.Sent)
+	assert.Equal(t, int64(1), stored.Version)
+
+	stored.Sent = dto.NotificationOnQueue
+	require.NoError(t, repo.UpdateNotification(ctx, id, stored))
+	assert.ErrorIs(t, repo.UpdateNotification(ctx, id, stored), storage.ErrVersionConflict)
+
+	updated, err := repo.GetNotification(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, dto.NotificationOnQueue, updated.Sent)
+	assert.Equal(t, int64(2), updated.Version)
+
+	stored.Version = 0
+	require.NoError(t, repo.UpdateNotification(ctx, id, stored))
+	assert.ErrorIs(t, repo.UpdateNotification(ctx, uuid.New(), stored), storage.ErrNotificationNotFound)
+
+	require.NoError(t, repo.DeleteNotification(ctx, id))
+	_, err = repo.GetNotification(ctx, id)
+	assert.ErrorIs(t, err, storage.ErrNotificationNotFound)
+	assert.ErrorIs(t, repo.DeleteNotification(ctx, id), storage.ErrNotificationNotFound)
+}
+
+func testListNotifications(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	repo := store.NotificationRepository()
+
+	start := basePeriod()
+	event := newEvent(uuid.New(), start)
+	eventID := createEvent(t, store, event)
+
+	create := func(at time.Time, sent string) uuid.UUID {
+		id, err := repo.CreateNotification(ctx, storage.Notification{
+			EventID: eventID,
+			UserID:  event.UserID,
+			Time:    at,
+			Message: "Reminder",
+			Sent:    sent,
+		})
+		require.NoError(t, err)
+		return id
+	}
+
+	// Период включает свои границы, в выборку попадают только ожидающие отправки уведомления
+	atStart := create(start, dto.NotificationOnWait)
+	atEnd := create(start.Add(time.Hour), dto.NotificationOnWait)
+	create(start.Add(time.Minute), dto.NotificationOnQueue)
+	create(start.Add(time.Minute), dto.NotificationSent)
+	create(start.Add(-time.Minute), dto.NotificationOnWait)
+
+	notifications, err := repo.ListNotifications(ctx, start, start.Add(time.Hour))
+	require.NoError(t, err)
+
+	ids := make([]uuid.UUID, 0, len(notifications))
+	for _, notification := range notifications {
+		ids = append(ids, notification.ID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{atStart, atEnd}, ids)
+}
+
+func testDeleteNotifications(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	repo := store.NotificationRepository()
+
+	event := newEvent(uuid.New(), basePeriod())
+	eventID := createEvent(t, store, event)
+	otherEventID := createEvent(t, store, event)
+
+	create := func(eventID uuid.UUID, sent string) uuid.UUID {
+		id, err := repo.CreateNotification(ctx, storage.Notification{
+			EventID: eventID,
+			UserID:  event.UserID,
+			Time:    event.StartTime,
+			Sent:    sent,
+		})
+		require.NoError(t, err)
+		return id
+	}
+
+	sent := create(eventID, dto.NotificationSent)
+	waiting := create(eventID, dto.NotificationOnWait)
+	other := create(otherEventID, dto.NotificationOnWait)
+
+	require.NoError(t, repo.DeleteSentNotifications(ctx))
+	_, err := repo.GetNotification(ctx, sent)
+	assert.ErrorIs(t, err, storage.ErrNotificationNotFound)
+	_, err = repo.GetNotification(ctx, waiting)
+	assert.NoError(t, err)
+
+	require.NoError(t, repo.DeleteEventNotifications(ctx, eventID))
+	_, err = repo.GetNotification(ctx, waiting)
+	assert.ErrorIs(t, err, storage.ErrNotificationNotFound)
+	_, err = repo.GetNotification(ctx, other)
+	assert.NoError(t, err)
+}
+
+func testCalendars(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	repo := store.CalendarRepository()
+	userID := uuid.New()
+
+	calendar := storage.Calendar{
+		UserID:          userID,
+		Name:            "Work",
+		Color:           "#ff0000",
+		DefaultReminder: 15 * time.Minute,
+		Timezone:        "Europe/Moscow",
+	}
+	workID, err := repo.CreateCalendar(ctx, calendar)
+	require.NoError(t, err)
+
+	stored, err := repo.GetCalendar(ctx, workID)
+	require.NoError(t, err)
+	calendar.ID = workID
+	assert.Equal(t, calendar, stored)
+
+	calendar.Name = "Work hours"
+	calendar.DefaultReminder = time.Hour
+	require.NoError(t, repo.UpdateCalendar(ctx, workID, calendar))
+	stored, err = repo.GetCalendar(ctx, workID)
+	require.NoError(t, err)
+	assert.Equal(t, calendar, stored)
+	assert.ErrorIs(t, repo.UpdateCalendar(ctx, uuid.New(), calendar), storage.ErrCalendarNotFound)
+
+	personalID, err := repo.CreateCalendar(ctx, storage.Calendar{UserID: userID, Name: "Personal"})
+	require.NoError(t, err)
+	_, err = repo.CreateCalendar(ctx, storage.Calendar{UserID: uuid.New(), Name: "Foreign"})
+	require.NoError(t, err)
+
+	// Календари пользователя упорядочены по названию
+	calendars, err := repo.ListCalendars(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, calendars, 2)
+	assert.Equal(t, personalID, calendars[0].ID)
+	assert.Equal(t, workID, calendars[1].ID)
+
+	_, err = repo.GetCalendar(ctx, uuid.New())
+	assert.ErrorIs(t, err, storage.ErrCalendarNotFound)
+}
+
+func testIdempotency(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	repo := store.IdempotencyRepository()
+	newKey := func() storage.IdempotencyKey {
+		return storage.IdempotencyKey{UserID: uuid.New(), Operation: "CreateEvent", Key: uuid.NewString()}
+	}
+
+	record := storage.IdempotencyRecord{
+		IdempotencyKey: newKey(),
+		RequestHash:    "hash",
+		Response:       []byte(`{"id":"1"}`),
+		ExpiresAt:      time.Now().Add(time.Hour).Truncate(time.Microsecond),
+	}
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, record))
+	stored, err := repo.GetIdempotencyRecord(ctx, record.IdempotencyKey)
+	require.NoError(t, err)
+	assert.Equal(t, record.IdempotencyKey, stored.IdempotencyKey)
+	assert.Equal(t, record.RequestHash, stored.RequestHash)
+	assert.Equal(t, record.Response, stored.Response)
+	assert.True(t, record.ExpiresAt.Equal(stored.ExpiresAt))
+
+	// Действующая запись не перезаписывается
+	another := record
+	another.RequestHash = "another"
+	assert.ErrorIs(t, repo.CreateIdempotencyRecord(ctx, another), storage.ErrIdempotencyKeyExists)
+
+	_, err = repo.GetIdempotencyRecord(ctx, newKey())
+	assert.ErrorIs(t, err, storage.ErrIdempotencyRecordNotFound)
+
+	// Тот же ключ другого пользователя или другой операции - отдельная запись
+	otherUser := another
+	otherUser.UserID = uuid.New()
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, otherUser))
+	otherOperation := another
+	otherOperation.Operation = "ImportEvents"
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, otherOperation))
+	stored, err = repo.GetIdempotencyRecord(ctx, record.IdempotencyKey)
+	require.NoError(t, err)
+	assert.Equal(t, "hash", stored.RequestHash)
+	stored, err = repo.GetIdempotencyRecord(ctx, otherUser.IdempotencyKey)
+	require.NoError(t, err)
+	assert.Equal(t, "another", stored.RequestHash)
+
+	// Истекшая запись не находится и заменяется новой
+	expired := storage.IdempotencyRecord{
+		IdempotencyKey: newKey(),
+		RequestHash:    "hash",
+		Response:       []byte(`{}`),
+		ExpiresAt:      time.Now().Add(-time.Minute),
+	}
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, expired))
+	_, err = repo.GetIdempotencyRecord(ctx, expired.IdempotencyKey)
+	assert.ErrorIs(t, err, storage.ErrIdempotencyRecordNotFound)
+
+	replaced := expired
+	replaced.RequestHash = "replaced"
+	replaced.ExpiresAt = time.Now().Add(time.Hour)
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, replaced))
+	stored, err = repo.GetIdempotencyRecord(ctx, expired.IdempotencyKey)
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", stored.RequestHash)
+
+	expired.IdempotencyKey = newKey()
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, expired))
+	require.NoError(t, repo.DeleteExpiredIdempotencyRecords(ctx, time.Now()))
+	// После удаления истекших записей ключ свободен, действующие записи остаются
+	_, err = repo.GetIdempotencyRecord(ctx, record.IdempotencyKey)
+	require.NoError(t, err)
+	expired.ExpiresAt = time.Now().Add(time.Hour)
+	require.NoError(t, repo.CreateIdempotencyRecord(ctx, expired))
+}
+
+func testDeleteCalendar(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	calendarID, err := store.CalendarRepository().CreateCalendar(ctx, storage.Calendar{UserID: userID, Name: "Work"})
+	require.NoError(t, err)
+
+	event := newEvent(userID, basePeriod())
+	event.CalendarID = calendarID
+	eventID := createEvent(t, store, event)
+	notificationID, err := store.NotificationRepository().CreateNotification(ctx, storage.Notification{
+		EventID: eventID,
+		UserID:  userID,
+		Time:    event.StartTime,
+		Sent:    dto.NotificationOnWait,
+	})
+	require.NoError(t, err)
+	otherEventID := createEvent(t, store, newEvent(userID, event.StartTime))
+	before, err := store.EventRepository().SyncEvents(ctx, userID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, before, 2)
+
+	// Вместе с календарем удаляются его события и их уведомления, события остаются tombstone для синхронизации
+	require.NoError(t, store.CalendarRepository().DeleteCalendar(ctx, calendarID))
+	assert.ErrorIs(t, store.CalendarRepository().DeleteCalendar(ctx, calendarID), storage.ErrCalendarNotFound)
+
+	_, err = store.CalendarRepository().GetCalendar(ctx, calendarID)
+	assert.ErrorIs(t, err, storage.ErrCalendarNotFound)
+	_, err = store.EventRepository().GetEvent(ctx, eventID)
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+	_, err = store.NotificationRepository().GetNotification(ctx, notificationID)
+	assert.ErrorIs(t, err, storage.ErrNotificationNotFound)
+	_, err = store.EventRepository().GetEvent(ctx, otherEventID)
+	assert.NoError(t, err)
+
+	changes, err := store.EventRepository().SyncEvents(ctx, userID, before[1].Version, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{eventID}, eventIDs(changes))
+	assert.True(t, changes[0].Deleted)
+}
+
+func testWithTx(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+	userID := uuid.New()
+	errFailed := errors.New("failed")
+
+	var eventID, notificationID uuid.UUID
+	err := store.WithTx(ctx, func(tx storage.Storage) error {
+		event := newEvent(userID, basePeriod())
+		var err error
+		eventID, err = tx.EventRepository().CreateEvent(ctx, event)
+		if err != nil {
+			return err
+		}
+		notificationID, err = tx.NotificationRepository().CreateNotification(ctx, storage.Notification{
+			EventID: eventID,
+			UserID:  userID,
+			Time:    event.StartTime,
+			Sent:    dto.NotificationOnWait,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	_, err = store.EventRepository().GetEvent(ctx, eventID)
+	assert.NoError(t, err)
+	_, err = store.NotificationRepository().GetNotification(ctx, notificationID)
+	assert.NoError(t, err)
+
+	// Ошибка откатывает все изменения транзакции, включая сделанные во вложенном WithTx
+	var createdID uuid.UUID
+	err = store.WithTx(ctx, func(tx storage.Storage) error {
+		if err := tx.EventRepository().DeleteEvent(ctx, eventID); err != nil {
+			return err
+		}
+		err := tx.WithTx(ctx, func(nested storage.Storage) error {
+			var err error
+			createdID, err = nested.EventRepository().CreateEvent(ctx, newEvent(userID, basePeriod()))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		// Внутри транзакции ее изменения видны
+		_, err = tx.EventRepository().GetEvent(ctx, eventID)
+		assert.ErrorIs(t, err, storage.ErrEventNotFound)
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+
+	_, err = store.EventRepository().GetEvent(ctx, eventID)
+	assert.NoError(t, err)
+	_, err = store.EventRepository().GetEvent(ctx, createdID)
+	assert.ErrorIs(t, err, storage.ErrEventNotFound)
+}
+
+func testChangeFeed(t *testing.T, store storage.Storage) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	userID := uuid.New()
+
+	changes, err := store.ChangeFeed().Subscribe(ctx, userID)
+	require.NoError(t, err)
+
+	event := newEvent(userID, basePeriod())
+	id := createEvent(t, store, event)
+	event.Title = "Updated"
+	require.NoError(t, store.EventRepository().UpdateEvent(ctx, id, event))
+	require.NoError(t, store.EventRepository().DeleteEvent(ctx, id))
+
+	// Изменения других пользователей подписчику не приходят
+	createEvent(t, store, newEvent(uuid.New(), event.StartTime))
+
+	for _, expected := range []storage.ChangeType{storage.ChangeCreated, storage.ChangeUpdated, storage.ChangeDeleted} {
+		select {
+		case change := <-changes:
+			assert.Equal(t, expected, change.Type)
+			assert.Equal(t, id, change.Event.ID)
+			assert.Equal(t, userID, change.Event.UserID)
+		case <-ctx.Done():
+			require.FailNow(t, "change was not delivered", expected)
+		}
+	}
+}
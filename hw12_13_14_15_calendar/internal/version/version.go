@@ -0,0 +1,85 @@
+// Package version описывает сборку приложения. Значения задаются при сборке флагами
+// -ldflags "-X github.com/romangricuk/otus-go/hw12_13_14_15_calendar/internal/version.release=...",
+// а если не заданы, берутся из информации о сборке, которую go build записывает в бинарник.
+package version
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+const unknown = "unknown"
+
+// Переменные заполняются через -ldflags -X, поэтому не могут быть константами.
+var (
+	release   string
+	buildDate string
+	gitHash   string
+)
+
+// Info - сведения о сборке. Теги yaml нужны для вывода calendarctl.
+type Info struct {
+	Release   string `json:"release" yaml:"release"`
+	BuildDate string `json:"buildDate" yaml:"buildDate"`
+	GitHash   string `json:"gitHash" yaml:"gitHash"`
+	GoVersion string `json:"goVersion" yaml:"goVersion"`
+}
+
+var get = sync.OnceValue(func() Info {
+	buildInfo, _ := debug.ReadBuildInfo()
+	return resolve(release, buildDate, gitHash, buildInfo)
+})
+
+// Get возвращает сведения о текущей сборке.
+func Get() Info {
+	return get()
+}
+
+// Print выводит сведения о сборке в w в формате JSON.
+func Print(w io.Writer) error {
+	return json.NewEncoder(w).Encode(Get())
+}
+
+// resolve дополняет значения из ldflags данными debug.BuildInfo: версией модуля и полями vcs.*,
+// которые go build записывает при сборке из рабочей копии git.
+func resolve(release, buildDate, gitHash string, buildInfo *debug.BuildInfo) Info {
+	info := Info{Release: release, BuildDate: buildDate, GitHash: gitHash, GoVersion: runtime.Version()}
+	if buildInfo != nil {
+		settings := make(map[string]string, len(buildInfo.Settings))
+		for _, setting := range buildInfo.Settings {
+			settings[setting.Key] = setting.Value
+		}
+		if info.Release == "" && buildInfo.Main.Version != "" && buildInfo.Main.Version != "(devel)" {
+			info.Release = buildInfo.Main.Version
+		}
+		if info.GitHash == "" && settings["vcs.revision"] != "" {
+			info.GitHash = settings["vcs.revision"]
+			if len(info.GitHash) > 7 {
+				info.GitHash = info.GitHash[:7]
+			}
+			if settings["vcs.modified"] == "true" {
+				info.GitHash += "-dirty"
+			}
+		}
+		if info.BuildDate == "" {
+			info.BuildDate = settings["vcs.time"]
+		}
+		if buildInfo.GoVersion != "" {
+			info.GoVersion = buildInfo.GoVersion
+		}
+	}
+
+	if info.Release == "" {
+		info.Release = "develop"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = unknown
+	}
+	if info.GitHash == "" {
+		info.GitHash = unknown
+	}
+	return info
+}
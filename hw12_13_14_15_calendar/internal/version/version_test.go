@@ -0,0 +1,48 @@
+package version
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	buildInfo := &debug.BuildInfo{
+		GoVersion: "go1.22.5",
+		Main:      debug.Module{Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "8118ea7f5b1c2d3e4f5061728394a5b6c7d8e9f0"},
+			{Key: "vcs.time", Value: "2024-07-24T13:22:15Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	t.Run("ldflags", func(t *testing.T) {
+		info := resolve("v2.0.0", "2024-08-01T00:00:00", "abcdef0", buildInfo)
+		assert.Equal(t, Info{
+			Release:   "v2.0.0",
+			BuildDate: "2024-08-01T00:00:00",
+			GitHash:   "abcdef0",
+			GoVersion: "go1.22.5",
+		}, info)
+	})
+
+	t.Run("build info", func(t *testing.T) {
+		info := resolve("", "", "", buildInfo)
+		assert.Equal(t, Info{
+			Release:   "v1.2.3",
+			BuildDate: "2024-07-24T13:22:15Z",
+			GitHash:   "8118ea7-dirty",
+			GoVersion: "go1.22.5",
+		}, info)
+	})
+
+	t.Run("nothing known", func(t *testing.T) {
+		info := resolve("", "", "", &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}})
+		assert.Equal(t, "develop", info.Release)
+		assert.Equal(t, unknown, info.BuildDate)
+		assert.Equal(t, unknown, info.GitHash)
+		assert.NotEmpty(t, info.GoVersion)
+	})
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_events_calendar_id_start_time;
+ALTER TABLE events
+    DROP COLUMN calendar_id;
+DROP INDEX IF EXISTS idx_calendars_user_id;
+DROP TABLE IF EXISTS calendars;
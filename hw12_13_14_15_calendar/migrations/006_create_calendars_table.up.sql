@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS calendars
+(
+    id                       UUID PRIMARY KEY,
+    user_id                  UUID    NOT NULL,
+    name                     TEXT    NOT NULL,
+    color                    TEXT    NOT NULL DEFAULT '',
+    default_reminder_minutes INTEGER NOT NULL DEFAULT 0,
+    timezone                 TEXT    NOT NULL DEFAULT 'UTC'
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendars_user_id ON calendars (user_id);
+
+ALTER TABLE events
+    ADD COLUMN calendar_id UUID REFERENCES calendars (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_events_calendar_id_start_time ON events (calendar_id, start_time);
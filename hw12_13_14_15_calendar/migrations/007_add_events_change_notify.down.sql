@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS events_notify_change ON events;
+DROP FUNCTION IF EXISTS notify_event_change();
@@ -0,0 +1,30 @@
+-- Рассылка изменений событий через LISTEN/NOTIFY для ленты изменений. Уведомление содержит только
+-- идентификаторы: размер payload NOTIFY ограничен 8000 байт, а превышение прерывает сам запрос.
+-- Лента изменений перечитывает строку события из таблицы.
+CREATE OR REPLACE FUNCTION notify_event_change() RETURNS TRIGGER AS
+$$
+DECLARE
+    changed RECORD;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed = OLD;
+    ELSE
+        changed = NEW;
+    END IF;
+
+    -- Колонка version появляется в миграции 008, до нее в уведомлении передается null
+    PERFORM pg_notify('event_changes', json_build_object(
+            'op', TG_OP,
+            'id', changed.id,
+            'user_id', changed.user_id,
+            'version', to_jsonb(changed) -> 'version'
+        )::TEXT);
+    RETURN changed;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER events_notify_change
+    AFTER INSERT OR UPDATE OR DELETE
+    ON events
+    FOR EACH ROW
+EXECUTE FUNCTION notify_event_change();
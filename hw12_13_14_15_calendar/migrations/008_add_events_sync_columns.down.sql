@@ -0,0 +1,16 @@
+DELETE FROM events WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE events
+    DROP CONSTRAINT IF EXISTS events_calendar_id_fkey,
+    ADD CONSTRAINT events_calendar_id_fkey FOREIGN KEY (calendar_id) REFERENCES calendars (id) ON DELETE CASCADE;
+
+DROP TRIGGER IF EXISTS events_bump_version ON events;
+DROP FUNCTION IF EXISTS bump_event_version();
+DROP INDEX IF EXISTS idx_events_user_id_version;
+
+ALTER TABLE events
+    DROP COLUMN deleted_at,
+    DROP COLUMN updated_at,
+    DROP COLUMN version;
+
+DROP SEQUENCE IF EXISTS events_version_seq;
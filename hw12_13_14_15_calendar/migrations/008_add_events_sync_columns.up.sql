@@ -0,0 +1,44 @@
+-- Версии и tombstone событий для инкрементальной синхронизации клиентов
+CREATE SEQUENCE IF NOT EXISTS events_version_seq;
+
+ALTER TABLE events
+    ADD COLUMN version    BIGINT    NOT NULL DEFAULT nextval('events_version_seq'),
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC'),
+    ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_events_user_id_version ON events (user_id, version);
+
+-- Любое изменение строки получает новую версию. Версия назначается под блокировкой пользователя,
+-- которая держится до конца транзакции: транзакции одного пользователя получают версии в порядке
+-- фиксации, поэтому токен синхронизации не может обогнать версию еще не зафиксированного изменения.
+-- Блокировки - advisory с первым ключом 1701, второй ключ - хеш пользователя.
+CREATE OR REPLACE FUNCTION bump_event_version() RETURNS TRIGGER AS
+$$
+DECLARE
+    new_key INTEGER = hashtext(NEW.user_id::TEXT);
+    old_key INTEGER = new_key;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_key = hashtext(OLD.user_id::TEXT);
+    END IF;
+    -- При переносе события к другому пользователю блокировки берутся в одном порядке,
+    -- чтобы встречные транзакции не ждали друг друга
+    PERFORM pg_advisory_xact_lock(1701, least(old_key, new_key));
+    PERFORM pg_advisory_xact_lock(1701, greatest(old_key, new_key));
+
+    NEW.version = nextval('events_version_seq');
+    NEW.updated_at = now() AT TIME ZONE 'UTC';
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER events_bump_version
+    BEFORE INSERT OR UPDATE
+    ON events
+    FOR EACH ROW
+EXECUTE FUNCTION bump_event_version();
+
+-- События удаляемого календаря превращаются в tombstone в репозитории, каскадное удаление больше не нужно
+ALTER TABLE events
+    DROP CONSTRAINT IF EXISTS events_calendar_id_fkey,
+    ADD CONSTRAINT events_calendar_id_fkey FOREIGN KEY (calendar_id) REFERENCES calendars (id) ON DELETE SET NULL;
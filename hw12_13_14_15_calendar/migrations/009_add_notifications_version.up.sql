@@ -0,0 +1,2 @@
+ALTER TABLE notifications
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_events_attendees;
+DROP INDEX IF EXISTS idx_events_search_vector;
+
+ALTER TABLE events
+    DROP COLUMN search_vector,
+    DROP COLUMN attendees;
@@ -0,0 +1,9 @@
+ALTER TABLE events
+    ADD COLUMN attendees     TEXT[]   NOT NULL DEFAULT '{}',
+    ADD COLUMN search_vector TSVECTOR NOT NULL GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', title), 'A') ||
+        setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+        ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_events_search_vector ON events USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_events_attendees ON events USING GIN (attendees);
@@ -0,0 +1,9 @@
+// Package migrations содержит миграции схемы Postgres, встроенные в бинарники.
+package migrations
+
+import "embed"
+
+// FS файлы миграций в формате golang-migrate.
+//
+//go:embed *.sql
+var FS embed.FS